@@ -0,0 +1,93 @@
+// Command jwtkeys manages the JWT signing keys read by the server.
+//
+//	jwtkeys rotate [-dir keys] [-alg RS256|EdDSA]   add a key, it becomes the signing key
+//	jwtkeys prune  [-dir keys] [-older-than 72h]    remove keys older than the duration
+//	jwtkeys list   [-dir keys]                      print the keys, newest first
+//
+// Running servers pick up changes within five minutes. A new key is published
+// right away but signs only after tokens.KeyActivationDelay, once verifiers
+// caching the JWKS have seen it. Only prune a key once
+// every token it signed has expired, i.e. older-than must exceed the access
+// token lifetime plus the time since the following key was added.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"yefe_app/v1/pkg/tokens"
+	"yefe_app/v1/pkg/utils"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dir := flags.String("dir", defaultKeysDir(), "directory holding the signing keys")
+	alg := flags.String("alg", tokens.AlgRS256, "algorithm of the new key, RS256 or EdDSA")
+	olderThan := flags.Duration("older-than", 72*time.Hour, "minimum age of the keys to prune")
+	flags.Parse(os.Args[2:])
+
+	if *dir == "" {
+		fail(fmt.Errorf("no keys directory, pass -dir or set JWT_KEYS_DIR"))
+	}
+
+	switch os.Args[1] {
+	case "rotate":
+		now := time.Now()
+		kid, err := tokens.GenerateKey(*dir, *alg, now)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("created key %s, it signs from %s\n", kid, now.Add(tokens.KeyActivationDelay).UTC().Format(time.RFC3339))
+
+	case "prune":
+		removed, err := tokens.PruneKeys(*dir, time.Now().Add(-*olderThan))
+		for _, kid := range removed {
+			fmt.Printf("removed key %s\n", kid)
+		}
+		if err != nil {
+			fail(err)
+		}
+
+	case "list":
+		keys, err := tokens.NewKeySet(*dir, "")
+		if err != nil {
+			fail(err)
+		}
+		for _, key := range keys.JWKS().Keys {
+			marker := ""
+			if key.KeyID == keys.ActiveKeyID() {
+				marker = " (signing)"
+			} else if activeFrom := time.Unix(key.NotBefore, 0); key.NotBefore != 0 && time.Now().Before(activeFrom) {
+				marker = " (signs from " + activeFrom.UTC().Format(time.RFC3339) + ")"
+			}
+			fmt.Printf("%s %s%s\n", key.KeyID, key.Algorithm, marker)
+		}
+
+	default:
+		usage()
+	}
+}
+
+// defaultKeysDir reads the keys directory from the server configuration
+func defaultKeysDir() string {
+	config, err := utils.LoadConfig()
+	if err != nil {
+		return ""
+	}
+	return config.Server.JWTKeysDir
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jwtkeys rotate|prune|list [flags]")
+	os.Exit(2)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,97 @@
+// Command points maintains the points ledger.
+//
+//	points backfill       replay puzzle progress, completed challenges and
+//	                      earned achievements into the ledger, then rebuild
+//	                      the leaderboards
+//	points leaderboards   rebuild the leaderboards of the current periods
+//	                      from the ledger
+//
+// It uses the database and Redis of the server configuration. Sources already
+// in the ledger are skipped, so a backfill can be run again, e.g. after it was
+// interrupted or while the previous release still served traffic.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure"
+	"yefe_app/v1/internal/repository"
+	usecase "yefe_app/v1/internal/useCase"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/storage"
+	"yefe_app/v1/pkg/utils"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "backfill":
+		points, leaderboard := setup()
+		result, err := points.Backfill(context.Background())
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("added %d puzzle, %d challenge and %d achievement entries\n",
+			result.Puzzles, result.Challenges, result.Achievements)
+
+		if err := leaderboard.Rebuild(context.Background()); err != nil {
+			fail(err)
+		}
+		fmt.Println("rebuilt leaderboards")
+
+	case "leaderboards":
+		_, leaderboard := setup()
+		if err := leaderboard.Rebuild(context.Background()); err != nil {
+			fail(err)
+		}
+		fmt.Println("rebuilt leaderboards")
+
+	default:
+		usage()
+	}
+}
+
+func setup() (domain.PointsUseCase, domain.LeaderboardUseCase) {
+	logger.Init()
+	config, err := utils.LoadConfig()
+	if err != nil {
+		fail(err)
+	}
+	db, err := infrastructure.NewDB(config.Persistence.PostgresSQl)
+	if err != nil {
+		fail(err)
+	}
+	redisClient, err := repository.NewRedisClient(config.Persistence.Redis)
+	if err != nil {
+		fail(err)
+	}
+	blobStore, err := storage.New(config.Storage, config.Server.PublicURL())
+	if err != nil {
+		fail(err)
+	}
+
+	pointsRepo := repository.NewPointsRepository(db)
+	leaderboard := usecase.NewLeaderboardUseCase(
+		repository.NewRedisLeaderboardRepository(redisClient),
+		pointsRepo,
+		repository.NewUserProfileRepository(db),
+		blobStore,
+		config.Storage,
+	)
+	return usecase.NewPointsUseCase(pointsRepo, leaderboard), leaderboard
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: points backfill | leaderboards")
+	os.Exit(2)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,98 @@
+// Command puzzles moves the puzzle bank in and out of the database.
+//
+//	puzzles import [file]   add the puzzles of a JSON file, extras/puzzles.json
+//	                        by default
+//	puzzles export <file>   write every puzzle, retired ones included, to a
+//	                        JSON file
+//
+// Both use the {"puzzles": [...]} shape of extras/puzzles.json, so an export
+// can be imported again. Puzzles already in the bank are skipped on import,
+// it is safe to run more than once.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure"
+	"yefe_app/v1/internal/repository"
+	usecase "yefe_app/v1/internal/useCase"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+)
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "import":
+		file := os.Args[len(os.Args)-1]
+		if len(os.Args) == 2 {
+			basePath, _ := utils.GetBasePath()
+			file = path.Join(basePath, "extras", "puzzles.json")
+		}
+
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			fail(err)
+		}
+		var data domain.PuzzleData
+		if err := json.Unmarshal(raw, &data); err != nil {
+			fail(fmt.Errorf("failed to read %s: %w", file, err))
+		}
+
+		result, err := setup().ImportPuzzles(context.Background(), data)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("imported %d puzzles, skipped %d already in the bank\n", result.Imported, result.Skipped)
+
+	case "export":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		data, err := setup().ExportPuzzles(context.Background())
+		if err != nil {
+			fail(err)
+		}
+		raw, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		if err := os.WriteFile(os.Args[2], append(raw, '\n'), 0o644); err != nil {
+			fail(err)
+		}
+		fmt.Printf("exported %d puzzles\n", len(data.Puzzles))
+
+	default:
+		usage()
+	}
+}
+
+func setup() domain.PuzzleAdminUseCase {
+	logger.Init()
+	config, err := utils.LoadConfig()
+	if err != nil {
+		fail(err)
+	}
+	db, err := infrastructure.NewDB(config.Persistence.PostgresSQl)
+	if err != nil {
+		fail(err)
+	}
+	return usecase.NewPuzzleAdminUseCase(repository.NewPuzzleRepository(db))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: puzzles import [file] | export <file>")
+	os.Exit(2)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -8,14 +8,21 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"syscall"
 	"time"
+	// Embedded so user timezones resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/internal/infrastructure"
 	"yefe_app/v1/internal/repository"
 	"yefe_app/v1/pkg/cache"
 	"yefe_app/v1/pkg/logger"
 	service "yefe_app/v1/pkg/services"
 	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/services/oidc"
+	"yefe_app/v1/pkg/storage"
+	"yefe_app/v1/pkg/tokens"
 	"yefe_app/v1/pkg/utils"
 
 	"github.com/stripe/stripe-go/v74"
@@ -26,7 +33,6 @@ func main() {
 	logger.Init()
 
 	basePath, _ := utils.GetBasePath()
-	pathToPuzzles := path.Join(basePath, "extras", "puzzles.json")
 	pathToChallenges := path.Join(basePath, "extras", "challenges.json")
 	pathToSongs := path.Join(basePath, "extras", "mood_music_catalog.json")
 	firebasedb := path.Join(basePath, "extras", "firebase.db")
@@ -42,6 +48,7 @@ func main() {
 
 	fmcConfig := fire_base.FCMServiceConfig{
 		Config:                 config.FirebaseConfig,
+		Push:                   config.Push,
 		DatabasePath:           firebasedb,
 		NotificationWorkerName: "daily-notifications-worker",
 	}
@@ -75,11 +82,28 @@ func main() {
 		"port": config.Server.Port,
 	}).Debug("Configuration loaded")
 
-	sessionRepo, err := repository.NewRedisSessionRepository(config.Persistence.Redis)
+	jwtKeys, err := tokens.NewKeySet(config.Server.JWTKeysDir, config.Server.Secret)
+	if err != nil {
+		logger.Log.WithError(err).Fatal("Failed to load JWT signing keys")
+		return
+	}
+	if jwtKeys.ActiveKeyID() == "" {
+		logger.Log.Warn("No JWT signing keys found, signing with the legacy HS256 secret")
+	}
+
+	scheduler.AddJob("reload-jwt-keys", "Reload JWT keys", utils.EVERY_FIVE_MINUTES, func(ctx context.Context) error {
+		return jwtKeys.Reload()
+	})
+
+	redisClient, err := repository.NewRedisClient(config.Persistence.Redis)
 	if err != nil {
 		logger.Log.WithError(err).Fatal("Failed to initialize redis")
 		return
 	}
+	sessionRepo := repository.NewRedisSessionRepository(redisClient)
+	loginAttemptRepo := repository.NewRedisLoginAttemptRepository(redisClient)
+	mfaChallengeRepo := repository.NewRedisMFAChallengeRepository(redisClient)
+	oauthNonceRepo := repository.NewRedisOAuthNonceRepository(redisClient)
 	// Initialize DB
 	db, err := infrastructure.NewDB(config.Persistence.PostgresSQl)
 	if err != nil {
@@ -93,7 +117,7 @@ func main() {
 	userRepo := repository.NewUserRepository(db, secEventRepo)
 	journalRepo := repository.NewJournalRepository(db)
 	userPuzzledRepo := repository.NewUserPuzzleRepository(db)
-	puzzleRepo := repository.NewPuzzleRepository(pathToPuzzles)
+	puzzleRepo := repository.NewPuzzleRepository(db)
 	adminRepo := repository.NewAdminUserRepository(db, userRepo)
 	challengeRepo, err := repository.NewChallengeRepository(db, pathToChallenges)
 
@@ -111,6 +135,35 @@ func main() {
 
 	}
 	paymentRepo := repository.NewPaymentRepository(db)
+	verificationRepo := repository.NewEmailVerificationRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
+	profileRepo := repository.NewUserProfileRepository(db)
+	accountDeleteRepo := repository.NewAccountDeletionRepository(db)
+	campaignRepo := repository.NewCampaignRepository(db)
+	inboxRepo := repository.NewInboxRepository(db)
+	achievementRepo := repository.NewAchievementRepository(db)
+	pointsRepo := repository.NewPointsRepository(db)
+	leaderboardRepo := repository.NewRedisLeaderboardRepository(redisClient)
+	groupRepo := repository.NewGroupRepository(db)
+
+	blobStore, err := storage.New(config.Storage, config.Server.PublicURL())
+	if err != nil {
+		logger.Log.Fatal("Failed to create blob storage:", err)
+	}
+
+	oidcVerifiers := map[string]domain.IDTokenVerifier{}
+	for name, provider := range config.OAuth.Providers {
+		if provider.ClientIDs == "" {
+			continue
+		}
+		oidcVerifiers[name] = oidc.NewVerifier(oidc.Config{
+			Issuer:    provider.Issuer,
+			ClientIDs: strings.Split(provider.ClientIDs, ","),
+		})
+		logger.Log.WithField("provider", name).Info("OAuth sign-in enabled")
+	}
 
 	scheduler.AddJob("set-daily-puzzle", "Daily Puzzly", utils.DAILY, func(ctx context.Context) error {
 		_, ok := inmemeoryCache.Get("daily-puzzle")
@@ -149,7 +202,8 @@ func main() {
 	serverConfig := infrastructure.ServerConfig{
 		DB:                db,
 		AllowedHosts:      config.Server.AllowedHosts,
-		JWT_SECRET:        config.Server.Secret,
+		JWTKeys:           jwtKeys,
+		ServerSettings:    config.Server,
 		EmailService:      emailService,
 		PaymentConfig:     paymentConfig,
 		UserRepo:          userRepo,
@@ -164,8 +218,29 @@ func main() {
 		AdminRepo:         adminRepo,
 		SongRepo:          songRepo,
 		PaymentRepo:       paymentRepo,
+		VerificationRepo:  verificationRepo,
+		PasswordResetRepo: passwordResetRepo,
+		LoginAttemptRepo:  loginAttemptRepo,
+		MFARepo:           mfaRepo,
+		MFAChallengeRepo:  mfaChallengeRepo,
+		IdentityRepo:      identityRepo,
+		OAuthNonceRepo:    oauthNonceRepo,
+		ProfileRepo:       profileRepo,
+		AccountDeleteRepo: accountDeleteRepo,
+		CampaignRepo:      campaignRepo,
+		InboxRepo:         inboxRepo,
+		AchievementRepo:   achievementRepo,
+		PointsRepo:        pointsRepo,
+		LeaderboardRepo:   leaderboardRepo,
+		GroupRepo:         groupRepo,
+		OIDCVerifiers:     oidcVerifiers,
+		BlobStore:         blobStore,
+		StorageConfig:     config.Storage,
 	}
 
+	// Reminders are written from the user's challenge, puzzle and journal
+	fmcConfig.ReminderContent = serverConfig.ReminderContentUsecase()
+	fmcConfig.Inbox = serverConfig.InboxUsecase()
 	fcmService, err := fire_base.NewFCMNotificationService(serverCtx, serverStopCtx, fmcConfig, serverConfig.AdminUserUsecase(), scheduler)
 	if err != nil {
 		logger.Log.Fatal("Failed to create FCM notification service:", err)
@@ -173,6 +248,62 @@ func main() {
 
 	serverConfig.FMCService = fcmService
 
+	// Scheduler jobs only live in memory, bring back the stored reminders
+	restored, err := fcmService.RestoreReminders(serverCtx)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not restore all reminders")
+	}
+	logger.Log.WithField("reminders", restored).Info("Restored reminders")
+
+	// Scheduled campaigns are waiting jobs as well
+	restored, err = serverConfig.CampaignUsecase().RestoreCampaigns(serverCtx)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not restore campaigns")
+	}
+	logger.Log.WithField("campaigns", restored).Info("Restored campaigns")
+
+	accountUsecase := serverConfig.AccountUsecase()
+	scheduler.AddJob("purge-deleted-accounts", "Purge deleted accounts", utils.DAILY, func(ctx context.Context) error {
+		purged, err := accountUsecase.PurgeDeletedAccounts(ctx)
+		if purged > 0 {
+			logger.Log.WithField("accounts", purged).Info("Purged deleted accounts")
+		}
+		return err
+	})
+
+	inboxUsecase := serverConfig.InboxUsecase()
+	scheduler.AddJob("purge-inbox", "Purge old inbox notifications", utils.DAILY, func(ctx context.Context) error {
+		purged, err := inboxUsecase.PurgeOldNotifications(ctx)
+		if purged > 0 {
+			logger.Log.WithField("notifications", purged).Info("Purged old inbox notifications")
+		}
+		return err
+	})
+
+	// Boards of new weeks and months start empty on their own, the rebuild
+	// corrects any drift from the ledger
+	leaderboardUsecase := serverConfig.LeaderboardUsecase()
+	if err := leaderboardUsecase.Rebuild(context.Background()); err != nil {
+		logger.Log.WithError(err).Error("Could not rebuild leaderboards")
+	}
+	scheduler.AddJob("rebuild-leaderboards", "Rebuild leaderboards", utils.DAILY, leaderboardUsecase.Rebuild)
+
+	scheduler.AddJob("retry-notifications", "Retry notifications", utils.EVERY_MINUTE, func(ctx context.Context) error {
+		delivered, err := fcmService.RetryPendingNotifications(ctx)
+		if delivered > 0 {
+			logger.Log.WithField("notifications", delivered).Info("Delivered retried notifications")
+		}
+		return err
+	})
+
+	scheduler.AddJob("release-deferred-notifications", "Release deferred notifications", utils.EVERY_MINUTE, func(ctx context.Context) error {
+		delivered, err := fcmService.ReleaseDeferredNotifications(ctx)
+		if delivered > 0 {
+			logger.Log.WithField("notifications", delivered).Info("Delivered notifications deferred by quiet hours")
+		}
+		return err
+	})
+
 	// Start the service (this will start background workers and scheduler)
 	if err := fcmService.Start(); err != nil {
 		log.Fatal("Failed to start FCM notification service:", err)
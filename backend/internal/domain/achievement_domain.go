@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"yefe_app/v1/internal/handlers/dto"
+)
+
+// Metrics the criteria of an achievement can test
+const (
+	MetricJournalEntries          = "journal_entries"
+	MetricJournalStreak           = "journal_streak"
+	MetricPuzzlesCompleted        = "puzzles_completed"
+	MetricPuzzlesCorrect          = "puzzles_correct"
+	MetricPuzzleStreak            = "puzzle_streak"
+	MetricChallengesCompleted     = "challenges_completed"
+	MetricChallengeStreak         = "challenge_streak"
+	MetricChallengeTypesCompleted = "challenge_types_completed"
+)
+
+// Sources of the events achievements are evaluated on
+const (
+	AchievementSourceJournal   = "journal"
+	AchievementSourcePuzzle    = "puzzle"
+	AchievementSourceChallenge = "challenge"
+)
+
+// AchievementCriteria is what a user has to reach to earn an achievement: the
+// metric at or above the threshold. Types narrows journal_entries to entry
+// types, and challenge_types_completed to challenge types, in which case the
+// threshold defaults to all of them.
+type AchievementCriteria struct {
+	Metric    string   `json:"metric"`
+	Threshold int      `json:"threshold"`
+	Types     []string `json:"types,omitempty"`
+}
+
+// Achievement is a badge users earn once its criteria are met
+type Achievement struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	IconName    string              `json:"icon_name"`
+	BadgeColor  string              `json:"badge_color"`
+	Points      int                 `json:"points"`
+	Criteria    AchievementCriteria `json:"criteria"`
+	IsActive    bool                `json:"is_active"`
+	SortOrder   int                 `json:"sort_order"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// UserAchievement is an achievement a user earned
+type UserAchievement struct {
+	Achievement Achievement `json:"achievement"`
+	EarnedAt    time.Time   `json:"earned_at"`
+}
+
+// AchievementProgress shows how far a user is from an achievement
+type AchievementProgress struct {
+	Achievement Achievement `json:"achievement"`
+	Progress    int         `json:"progress"`
+	Target      int         `json:"target"`
+	Earned      bool        `json:"earned"`
+	EarnedAt    *time.Time  `json:"earned_at"`
+}
+
+type AchievementRepository interface {
+	Create(ctx context.Context, achievement *Achievement) error
+	Update(ctx context.Context, achievement *Achievement) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (*Achievement, error)
+	List(ctx context.Context, activeOnly bool) ([]Achievement, error)
+	// Award records that a user earned an achievement, it reports false when
+	// the user already had it
+	Award(ctx context.Context, userID, achievementID string, earnedAt time.Time) (bool, error)
+	GetUserAchievements(ctx context.Context, userID string) ([]UserAchievement, error)
+	// CompletedChallengeTypes lists the distinct types of the challenges a user completed
+	CompletedChallengeTypes(ctx context.Context, userID string) ([]string, error)
+}
+
+type AchievementUseCase interface {
+	// EvaluateAchievements awards the achievements a user reached with an
+	// event from source and returns the ones earned just now
+	EvaluateAchievements(ctx context.Context, userID, source string) ([]Achievement, error)
+	ListAchievements(ctx context.Context, userID string) ([]AchievementProgress, error)
+	GetUserAchievements(ctx context.Context, userID string) ([]UserAchievement, error)
+
+	ListAllAchievements(ctx context.Context) ([]Achievement, error)
+	GetAchievement(ctx context.Context, id string) (*Achievement, error)
+	CreateAchievement(ctx context.Context, req dto.AchievementRequest) (*Achievement, error)
+	UpdateAchievement(ctx context.Context, id string, req dto.AchievementRequest) (*Achievement, error)
+	DeleteAchievement(ctx context.Context, id string) error
+}
@@ -77,9 +77,9 @@ type UserChallengeRepository interface {
 
 // ChallengeStatsRepository defines the interface for challenge statistics operations
 type ChallengeStatsRepository interface {
+	// GetUserStats takes the total points of the user from the points ledger
 	GetUserStats(userID string) (ChallengeStats, error)
-	UpdateUserStats(string, int) error
-	GetLeaderboard(limit int) ([]ChallengeStats, error)
+	UpdateUserStats(string) error
 }
 
 // Use Case Interfaces
@@ -100,7 +100,6 @@ type ChallengeUseCase interface {
 
 	// Statistics and progress
 	GetUserStats(userID string) (ChallengeStats, error)
-	GetLeaderboard(limit int) ([]ChallengeStats, error)
 }
 
 const (
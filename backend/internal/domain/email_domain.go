@@ -15,7 +15,7 @@ type EmailService interface {
 	//SendBulkEmail(ctx context.Context, requests []EmailRequest) error
 
 	// Email verification and notifications
-	//SendEmailVerification(ctx context.Context, email string, token string) error
-	//SendPasswordReset(ctx context.Context, email string, token string) error
+	SendEmailVerification(ctx context.Context, req dto.EmailVerificationEmailData) error
+	SendPasswordReset(ctx context.Context, req dto.PasswordResetEmailData) error
 	//SendWelcomeEmail(ctx context.Context, email string, userName string) error
 }
@@ -10,10 +10,12 @@ var (
 	ErrAccountLocked      = errors.New("account is locked")
 	ErrAccountInactive    = errors.New("account is inactive")
 	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrEmailVerified      = errors.New("email already verified")
 	ErrMissingAuthHeader  = errors.New("authorization header not found")
 	ErrInvalidAuthHeader  = errors.New("invalid authorization header")
 	ErrInvalidToken       = errors.New("invalid or expired token")
 	ErrUnauthorized       = errors.New("unauthorized access")
+	ErrUnknownProvider    = errors.New("unknown identity provider")
 )
 
 // User Management Errors
@@ -22,6 +24,9 @@ var (
 	ErrUsernameAlreadyExists = errors.New("username already exists")
 	ErrWeakPassword          = errors.New("password does not meet requirements")
 	ErrInvalidUserStatus     = errors.New("invalid user status")
+	ErrInvalidDateOfBirth    = errors.New("invalid date of birth")
+	ErrInvalidTimezone       = errors.New("invalid timezone")
+	ErrInvalidQuietHours     = errors.New("invalid quiet hours")
 )
 
 // Session Errors
@@ -30,6 +35,17 @@ var (
 	ErrSessionInactive        = errors.New("session inactive")
 	ErrSessionExpired         = errors.New("session expired")
 	ErrSessionAlreadyInactive = errors.New("session already inactive")
+	ErrRefreshTokenReused     = errors.New("refresh token reuse detected")
+)
+
+// Two-Factor Authentication Errors
+var (
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrMFARequired         = errors.New("two-factor authentication required")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication not enabled")
+	ErrMFASetupNotStarted  = errors.New("two-factor setup not started")
+	ErrMFAMandatoryForRole = errors.New("two-factor authentication is mandatory for this role")
 )
 
 // Rate Limiting Errors
@@ -62,12 +78,43 @@ var (
 	ErrInvalidMusicFormat   = errors.New("invalid music format")
 )
 
+// Notification Errors
+var (
+	ErrCampaignNotScheduled = errors.New("campaign is no longer scheduled")
+	ErrInvalidCampaignData  = errors.New("invalid campaign data")
+)
+
+// Achievement Errors
+var (
+	ErrInvalidAchievementCriteria = errors.New("invalid achievement criteria")
+)
+
+// Puzzle Errors
+var (
+	ErrInvalidPuzzle = errors.New("invalid puzzle")
+)
+
+// Points Errors
+var (
+	ErrPointsAlreadyReversed = errors.New("points entry is already reversed")
+)
+
+// Group Errors
+var (
+	ErrGroupFull          = errors.New("group is full")
+	ErrAlreadyGroupMember = errors.New("already a member of the group")
+	ErrTooManyGroups      = errors.New("too many groups")
+	ErrGroupOwnerOnly     = errors.New("only the group owner can do this")
+)
+
 // API/Request Errors
 var (
 	ErrInvalidRequest      = errors.New("invalid request")
 	ErrResourceNotFound    = errors.New("resource not found")
 	ErrConflict            = errors.New("resource conflict")
 	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrUnsupportedMedia    = errors.New("unsupported media type")
+	ErrFileTooLarge        = errors.New("file too large")
 )
 
 // Helper functions for error classification
@@ -82,6 +129,9 @@ func IsNotFoundError(err error) bool {
 func IsAuthorizationError(err error) bool {
 	return errors.Is(err, ErrUnauthorized) ||
 		errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrRefreshTokenReused) ||
+		errors.Is(err, ErrInvalidMFACode) ||
+		errors.Is(err, ErrMFARequired) ||
 		errors.Is(err, ErrMissingAuthHeader) ||
 		errors.Is(err, ErrInvalidAuthHeader) ||
 		errors.Is(err, ErrInvalidCredentials)
@@ -92,6 +142,8 @@ func IsConflictError(err error) bool {
 		errors.Is(err, ErrUsernameAlreadyExists) ||
 		errors.Is(err, ErrDuplicateEntry) ||
 		errors.Is(err, ErrUserAlreadyHasPlan) ||
+		errors.Is(err, ErrEmailVerified) ||
+		errors.Is(err, ErrMFAAlreadyEnabled) ||
 		errors.Is(err, ErrPlanUpdateConflict) ||
 		errors.Is(err, ErrConflict)
 }
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"yefe_app/v1/internal/handlers/dto"
+)
+
+// Roles of group members, a group has one owner
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleMember = "member"
+)
+
+// Group is a small accountability group users join with its invite code
+type Group struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	InviteCode  string    `json:"invite_code"`
+	OwnerID     string    `json:"owner_id"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type GroupMember struct {
+	GroupID  string    `json:"group_id"`
+	UserID   string    `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// GroupNudge is an encouragement a member sent another
+type GroupNudge struct {
+	ID          string    `json:"id"`
+	GroupID     string    `json:"group_id"`
+	SenderID    string    `json:"sender_id"`
+	RecipientID string    `json:"recipient_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Streak is a run of consecutive days
+type Streak struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+// GroupMemberProgress is what members see of each other: their streaks, never
+// the contents of their journal
+type GroupMemberProgress struct {
+	UserID          string    `json:"user_id"`
+	Name            string    `json:"name"`
+	AvatarURL       string    `json:"avatar_url,omitempty"`
+	Role            string    `json:"role"`
+	JoinedAt        time.Time `json:"joined_at"`
+	ChallengeStreak Streak    `json:"challenge_streak"`
+	JournalStreak   Streak    `json:"journal_streak"`
+	IsCurrentUser   bool      `json:"is_current_user"`
+}
+
+type GroupDetails struct {
+	Group
+	Members []GroupMemberProgress `json:"members"`
+}
+
+type GroupList struct {
+	Groups []Group `json:"groups"`
+	Total  int64   `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+}
+
+// GroupMemberSummary shows a member to admins
+type GroupMemberSummary struct {
+	GroupMember
+	Name       string `json:"name"`
+	NudgesSent int64  `json:"nudges_sent"`
+}
+
+// GroupModeration is a group as admins review it
+type GroupModeration struct {
+	Group
+	Members    []GroupMemberSummary `json:"members"`
+	NudgesSent int64                `json:"nudges_sent"`
+}
+
+type GroupRepository interface {
+	// Create adds a group with its owner as the first member
+	Create(ctx context.Context, group *Group) error
+	GetByID(ctx context.Context, id string) (*Group, error)
+	GetByInviteCode(ctx context.Context, code string) (*Group, error)
+	ListByUser(ctx context.Context, userID string) ([]Group, error)
+	// List pages through every group, with a name matching search when set
+	List(ctx context.Context, search string, limit, offset int) ([]Group, int64, error)
+	Update(ctx context.Context, id string, updates map[string]any) error
+	Delete(ctx context.Context, id string) error
+
+	// AddMember fails with ErrGroupFull when the group has maxMembers already,
+	// and ErrAlreadyGroupMember when the user is one of them
+	AddMember(ctx context.Context, member GroupMember, maxMembers int) error
+	// RemoveMember takes a user out of a group. The member who joined first
+	// takes over a group its owner leaves, and a group left empty is deleted.
+	RemoveMember(ctx context.Context, groupID, userID string) error
+	// GetMember returns ErrResourceNotFound when the user is not in the group
+	GetMember(ctx context.Context, groupID, userID string) (*GroupMember, error)
+	// ListMembers lists the active users of a group, in the order they joined
+	ListMembers(ctx context.Context, groupID string) ([]GroupMember, error)
+	CountUserGroups(ctx context.Context, userID string) (int64, error)
+
+	// AddNudge fails with ErrRateLimitExceeded when the sender already nudged
+	// the recipient in the group since a time, also across parallel requests
+	AddNudge(ctx context.Context, nudge GroupNudge, since time.Time) error
+	// NudgesBySender counts the nudges sent in a group per member
+	NudgesBySender(ctx context.Context, groupID string) (map[string]int64, error)
+}
+
+// GroupUseCase runs accountability groups. Only members see a group, and
+// only its owner changes it.
+type GroupUseCase interface {
+	CreateGroup(ctx context.Context, req dto.CreateGroupRequest) (*Group, error)
+	UpdateGroup(ctx context.Context, req dto.UpdateGroupRequest) (*Group, error)
+	DeleteGroup(ctx context.Context, userID, groupID string) error
+	ListGroups(ctx context.Context, userID string) ([]Group, error)
+	GetGroup(ctx context.Context, userID, groupID string) (*GroupDetails, error)
+	JoinGroup(ctx context.Context, userID, inviteCode string) (*Group, error)
+	LeaveGroup(ctx context.Context, userID, groupID string) error
+	RemoveMember(ctx context.Context, userID, groupID, memberID string) error
+	// ResetInviteCode replaces the invite code, the old one stops working
+	ResetInviteCode(ctx context.Context, userID, groupID string) (*Group, error)
+	// Encourage sends a member a push notification from another, once a day
+	Encourage(ctx context.Context, userID, groupID, memberID string) error
+	GetLeaderboard(ctx context.Context, userID, groupID, window string) (*Leaderboard, error)
+
+	// AdminListGroups, AdminGetGroup, AdminDeleteGroup and AdminRemoveMember
+	// moderate any group
+	AdminListGroups(ctx context.Context, filter dto.GroupListFilter) (*GroupList, error)
+	AdminGetGroup(ctx context.Context, groupID string) (*GroupModeration, error)
+	AdminDeleteGroup(ctx context.Context, groupID string) error
+	AdminRemoveMember(ctx context.Context, groupID, memberID string) error
+}
@@ -47,4 +47,7 @@ type JournalUseCase interface {
 	GetTodayEntry(ctx context.Context, userID, entryType string) (*dto.TodayEntryResponse, error)
 	GetStats(ctx context.Context, userID string) (*dto.JournalStatsResponse, error)
 	SearchEntries(ctx context.Context, userID, query string, limit, offset int) (*dto.JournalEntriesResponse, error)
+	// GetStreaks returns the current and longest runs of days with an entry,
+	// as in the journal stats
+	GetStreaks(ctx context.Context, userID string) (current int, longest int)
 }
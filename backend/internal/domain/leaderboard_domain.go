@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Windows users are ranked over, weeks start on Monday and periods in UTC
+const (
+	LeaderboardWeekly  = "weekly"
+	LeaderboardMonthly = "monthly"
+	LeaderboardAllTime = "all_time"
+)
+
+// LeaderboardPeriod is a period of a window, such as the week starting on
+// 2025-08-04. Start and End are zero for all time.
+type LeaderboardPeriod struct {
+	Window string
+	Start  time.Time
+	End    time.Time
+}
+
+// LeaderboardScore is the place of a user on a board, Rank starts at 1
+type LeaderboardScore struct {
+	UserID string
+	Points int
+	Rank   int
+}
+
+// PublicProfile is what other users may see of a user
+type PublicProfile struct {
+	UserID              string
+	Name                string
+	AvatarURL           string
+	HideFromLeaderboard bool
+}
+
+// LeaderboardEntry shows a user on a leaderboard by name and avatar only
+type LeaderboardEntry struct {
+	Rank          int    `json:"rank"`
+	Name          string `json:"name"`
+	AvatarURL     string `json:"avatar_url,omitempty"`
+	Points        int    `json:"points"`
+	IsCurrentUser bool   `json:"is_current_user"`
+}
+
+type Leaderboard struct {
+	Window      string             `json:"window"`
+	PeriodStart *time.Time         `json:"period_start,omitempty"`
+	PeriodEnd   *time.Time         `json:"period_end,omitempty"`
+	Entries     []LeaderboardEntry `json:"entries"`
+	// Me is the place of the current user, also outside the top entries. It
+	// is nil when the user has no points in the period or opted out.
+	Me *LeaderboardEntry `json:"me"`
+}
+
+// LeaderboardRepository keeps a ranked board of points per period
+type LeaderboardRepository interface {
+	AddPoints(ctx context.Context, periods []LeaderboardPeriod, userID string, points int) error
+	SetPoints(ctx context.Context, period LeaderboardPeriod, userID string, points int) error
+	// Replace swaps the board of a period for the given points per user
+	Replace(ctx context.Context, period LeaderboardPeriod, points map[string]int) error
+	Remove(ctx context.Context, periods []LeaderboardPeriod, userID string) error
+	Top(ctx context.Context, period LeaderboardPeriod, limit int) ([]LeaderboardScore, error)
+	// Rank returns nil when the user is not on the board
+	Rank(ctx context.Context, period LeaderboardPeriod, userID string) (*LeaderboardScore, error)
+	// Scores returns the points of the given users, users off the board are left out
+	Scores(ctx context.Context, period LeaderboardPeriod, userIDs []string) (map[string]int, error)
+}
+
+// LeaderboardUseCase ranks users on the points they earned this week, this
+// month and of all time. The boards follow the points ledger.
+type LeaderboardUseCase interface {
+	// RecordPoints moves a user on the boards of the periods entry falls in
+	RecordPoints(ctx context.Context, entry PointsEntry) error
+	GetLeaderboard(ctx context.Context, userID, window string, limit int) (*Leaderboard, error)
+	// GetMembersLeaderboard ranks the given users among themselves, such as
+	// the members of a group, those without points included
+	GetMembersLeaderboard(ctx context.Context, userID, window string, userIDs []string) (*Leaderboard, error)
+	// SyncUser recomputes the places of a user from the ledger, or takes the
+	// user off the boards after opting out
+	SyncUser(ctx context.Context, userID string) error
+	// Rebuild recomputes the boards of the current periods from the ledger
+	Rebuild(ctx context.Context) error
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"yefe_app/v1/internal/handlers/dto"
+)
+
+// Campaign statuses
+const (
+	CampaignScheduled = "scheduled"
+	CampaignSent      = "sent"
+	CampaignFailed    = "failed"
+	CampaignCancelled = "cancelled"
+)
+
+// Campaign is a push notification an admin broadcasts to a segment of users,
+// now or at ScheduledAt
+type Campaign struct {
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Data        map[string]string `json:"data"` // Deep link and other data passed to the app
+	Segment     CampaignSegment   `json:"segment"`
+	Status      string            `json:"status"`
+	ScheduledAt time.Time         `json:"scheduled_at"`
+	SentAt      *time.Time        `json:"sent_at"`
+	Recipients  int               `json:"recipients"` // Devices the campaign is sent to
+	Error       string            `json:"error,omitempty"`
+	CreatedBy   string            `json:"created_by"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type CampaignList struct {
+	Campaigns []Campaign `json:"campaigns"`
+	Total     int64      `json:"total"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+
+// CampaignSegment selects the users a campaign goes to, empty fields match everyone
+type CampaignSegment struct {
+	PlanType     string `json:"plan_type"`
+	Language     string `json:"language"`
+	InactiveDays int    `json:"inactive_days"` // Users who have not logged in for this many days
+}
+
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign *Campaign) error
+	// GetByID returns ErrResourceNotFound when there is no such campaign
+	GetByID(ctx context.Context, id string) (*Campaign, error)
+	List(ctx context.Context, status string, limit, offset int) ([]Campaign, int64, error)
+	GetByStatus(ctx context.Context, status string) ([]Campaign, error)
+	// UpdateStatus moves a campaign from one status to another, ErrConflict
+	// when it is no longer in the from status
+	UpdateStatus(ctx context.Context, id, from, to string, updates map[string]any) error
+	Update(ctx context.Context, id string, updates map[string]any) error
+	// AudienceUserIDs returns the active users in a segment
+	AudienceUserIDs(ctx context.Context, segment CampaignSegment) ([]string, error)
+}
+
+// CampaignUseCase lets admins broadcast push notifications
+type CampaignUseCase interface {
+	CreateCampaign(ctx context.Context, req dto.CreateCampaignRequest) (*Campaign, error)
+	PreviewCampaign(ctx context.Context, req dto.CreateCampaignRequest) (*dto.CampaignPreviewResponse, error)
+	GetCampaign(ctx context.Context, id string) (*Campaign, error)
+	ListCampaigns(ctx context.Context, filter dto.CampaignListFilter) (*CampaignList, error)
+	CancelCampaign(ctx context.Context, id string) (*Campaign, error)
+	GetCampaignStats(ctx context.Context, id string) (*dto.NotificationDeliveryStats, error)
+	// RestoreCampaigns schedules again the campaigns that were waiting when
+	// the server stopped, it returns how many there were
+	RestoreCampaigns(ctx context.Context) (int, error)
+}
+
+// NotificationAdminUseCase reports on push notification delivery
+type NotificationAdminUseCase interface {
+	GetDeliveryStats(ctx context.Context, filter dto.NotificationStatsFilter) (*dto.NotificationStatsResponse, error)
+}
+
+// DeviceUseCase manages the devices a user receives push notifications on
+type DeviceUseCase interface {
+	RegisterDevice(ctx context.Context, req dto.RegisterDeviceRequest) (*dto.DeviceResponse, error)
+	UnregisterDevice(ctx context.Context, req dto.UnregisterDeviceRequest) error
+	ListDevices(ctx context.Context, userID string) ([]dto.DeviceResponse, error)
+}
+
+// Categories of inbox notifications
+const (
+	InboxReminder    = "reminder"
+	InboxCampaign    = "campaign"
+	InboxPayment     = "payment"
+	InboxAchievement = "achievement"
+)
+
+// InboxNotification is a notification in the in-app inbox of a user. It is
+// kept whether or not a push reached one of the user's devices.
+type InboxNotification struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"-"`
+	Category  string            `json:"category"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data"`
+	ReadAt    *time.Time        `json:"read_at"` // Nil while unread
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type InboxList struct {
+	Notifications []InboxNotification `json:"notifications"`
+	Total         int64               `json:"total"`
+	Unread        int64               `json:"unread"`
+	Limit         int                 `json:"limit"`
+	Offset        int                 `json:"offset"`
+}
+
+type InboxRepository interface {
+	// CreateForUsers adds a copy of notification to the inbox of each user
+	CreateForUsers(ctx context.Context, userIDs []string, notification InboxNotification) error
+	List(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]InboxNotification, int64, error)
+	CountUnread(ctx context.Context, userID string) (int64, error)
+	// MarkRead returns ErrResourceNotFound when the user has no such notification
+	MarkRead(ctx context.Context, userID, id string) error
+	MarkAllRead(ctx context.Context, userID string) (int64, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+// InboxUseCase keeps the notifications sent to users so the app can list
+// them, also for users who do not receive pushes
+type InboxUseCase interface {
+	// AddToInbox keeps a notification in the inbox of each of the users
+	AddToInbox(ctx context.Context, userIDs []string, notification InboxNotification) error
+	ListNotifications(ctx context.Context, filter dto.InboxFilter) (*InboxList, error)
+	UnreadCount(ctx context.Context, userID string) (int64, error)
+	MarkRead(ctx context.Context, userID, id string) error
+	MarkAllRead(ctx context.Context, userID string) (int64, error)
+	// PurgeOldNotifications removes the notifications past the retention period
+	PurgeOldNotifications(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+	"yefe_app/v1/internal/handlers/dto"
+)
+
+// Sources points are awarded for
+const (
+	PointsSourcePuzzle      = "puzzle"
+	PointsSourceChallenge   = "challenge"
+	PointsSourceJournal     = "journal"
+	PointsSourceAchievement = "achievement"
+)
+
+// PointsEntry is a line of the points ledger. Entries are never changed or
+// removed: an award is taken back with a reversal, an entry of the opposite
+// points on the same source that names the entry it reverses.
+type PointsEntry struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Points     int       `json:"points"`
+	SourceType string    `json:"source_type"`
+	SourceID   string    `json:"source_id"`
+	Reason     string    `json:"reason"`
+	ReversalOf string    `json:"reversal_of,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PointsBalance is the total of the ledger of a user, and its share per source
+type PointsBalance struct {
+	Total    int            `json:"total"`
+	BySource map[string]int `json:"by_source"`
+}
+
+type PointsLedger struct {
+	Entries []PointsEntry `json:"entries"`
+	Total   int64         `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+}
+
+// PointsBackfillResult counts the entries a backfill added per source
+type PointsBackfillResult struct {
+	Puzzles      int64 `json:"puzzles"`
+	Challenges   int64 `json:"challenges"`
+	Achievements int64 `json:"achievements"`
+}
+
+type PointsRepository interface {
+	// Add records entries, skipping the ones already in the ledger, and
+	// reports how many were recorded. An award is identified by its user and
+	// source, a reversal by the entry it reverses.
+	Add(ctx context.Context, entries ...PointsEntry) (int64, error)
+	GetByID(ctx context.Context, id string) (*PointsEntry, error)
+	// GetAward returns the award for a source, ErrResourceNotFound when the
+	// source earned the user nothing
+	GetAward(ctx context.Context, userID, sourceType, sourceID string) (*PointsEntry, error)
+	List(ctx context.Context, userID string, limit, offset int) ([]PointsEntry, int64, error)
+	TotalsBySource(ctx context.Context, userID string) (map[string]int, error)
+	// UserTotal sums the points of a user earned since the given time, a zero
+	// time sums them all
+	UserTotal(ctx context.Context, userID string, since time.Time) (int, error)
+	// RankedTotals sums the points earned since the given time per user, for
+	// the active users with points who did not opt out of leaderboards
+	RankedTotals(ctx context.Context, since time.Time) (map[string]int, error)
+
+	// PuzzleAwards, ChallengeAwards and AchievementAwards page through the
+	// points earned before the ledger existed, as unsaved entries
+	PuzzleAwards(ctx context.Context, limit, offset int) ([]PointsEntry, error)
+	ChallengeAwards(ctx context.Context, limit, offset int) ([]PointsEntry, error)
+	AchievementAwards(ctx context.Context, limit, offset int) ([]PointsEntry, error)
+}
+
+// PointsUseCase keeps the points users earn in an append-only ledger that
+// totals and leaderboards are derived from
+type PointsUseCase interface {
+	// Award credits a user for a source, a source is credited once
+	Award(ctx context.Context, userID, sourceType, sourceID string, points int, reason string) error
+	// ReverseSource takes back the award of a source, if it had one
+	ReverseSource(ctx context.Context, userID, sourceType, sourceID, reason string) error
+	ReverseEntry(ctx context.Context, id, reason string) (*PointsEntry, error)
+	GetBalance(ctx context.Context, userID string) (*PointsBalance, error)
+	ListEntries(ctx context.Context, filter dto.PointsFilter) (*PointsLedger, error)
+	// Backfill replays the puzzle progress, completed challenges and earned
+	// achievements into the ledger, it can be run again safely. The
+	// leaderboards are not updated, rebuild them afterwards.
+	Backfill(ctx context.Context) (*PointsBackfillResult, error)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 	"yefe_app/v1/internal/handlers/dto"
 )
@@ -15,12 +16,45 @@ type Puzzle struct {
 	Category      string            `json:"category"`
 	Points        int               `json:"points"`
 	Explanation   string            `json:"explanation,omitempty"`
+	Version       int               `json:"version"`
+	RetiredAt     *time.Time        `json:"retiredAt,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// Difficulties of puzzles
+const (
+	PuzzleDifficultyEasy   = "Easy"
+	PuzzleDifficultyMedium = "Medium"
+	PuzzleDifficultyHard   = "Hard"
+)
+
+// PuzzleVersion is the content of a puzzle at one of its versions, kept so
+// earlier answers can be read against the puzzle they were given for
+type PuzzleVersion struct {
+	PuzzleID      string            `json:"puzzleId"`
+	Version       int               `json:"version"`
+	Title         string            `json:"title"`
+	Question      string            `json:"question"`
+	Options       map[string]string `json:"options"`
+	CorrectAnswer int               `json:"correctAnswer"`
+	Difficulty    string            `json:"difficulty"`
+	Category      string            `json:"category"`
+	Points        int               `json:"points"`
+	Explanation   string            `json:"explanation,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
+
+type PuzzleImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
 }
 
 type UserPuzzleProgress struct {
 	ID             string     `json:"id"`
 	UserID         string     `json:"userId"`
 	PuzzleID       string     `json:"puzzleId"`
+	PuzzleVersion  int        `json:"puzzleVersion,omitempty"`
 	IsCompleted    bool       `json:"isCompleted"`
 	SelectedAnswer *int       `json:"selectedAnswer,omitempty"`
 	IsCorrect      *bool      `json:"isCorrect,omitempty"`
@@ -45,9 +79,23 @@ type PuzzleData struct {
 }
 
 type PuzzleRepository interface {
+	// GetAllPuzzles and GetRandomPuzzle only see the puzzles in rotation,
+	// GetPuzzleByID finds retired ones too
 	GetAllPuzzles() ([]Puzzle, error)
 	GetPuzzleByID(string) (*Puzzle, error)
 	GetRandomPuzzle() (*Puzzle, error)
+
+	// Create adds a puzzle at version 1
+	Create(ctx context.Context, puzzle *Puzzle) error
+	// Update replaces the content of a puzzle with its next version
+	Update(ctx context.Context, puzzle *Puzzle) error
+	// SetRetired takes a puzzle out of rotation, or puts it back
+	SetRetired(ctx context.Context, id string, retired bool) error
+	List(ctx context.Context, includeRetired bool) ([]Puzzle, error)
+	// ListVersions lists the versions of a puzzle, latest first
+	ListVersions(ctx context.Context, id string) ([]PuzzleVersion, error)
+	// Import adds puzzles as they are, IDs already in the bank are skipped
+	Import(ctx context.Context, puzzles []Puzzle) (*PuzzleImportResult, error)
 }
 
 type UserPuzzleRepository interface {
@@ -69,3 +117,21 @@ type PuzzleUseCase interface {
 	GetUserCompletedPuzzles(userID string) ([]UserPuzzleProgress, error)
 	SubmitPuzzleAnswer(userID, puzzleID string, selectedAnswer int) (*dto.PuzzleSubmissionResult, error)
 }
+
+// PuzzleAdminUseCase authors the puzzle bank. A puzzle is never deleted, it
+// is retired so the answers users gave keep pointing at it.
+type PuzzleAdminUseCase interface {
+	ListPuzzles(ctx context.Context, includeRetired bool) ([]Puzzle, error)
+	GetPuzzle(ctx context.Context, id string) (*Puzzle, error)
+	CreatePuzzle(ctx context.Context, req dto.PuzzleRequest) (*Puzzle, error)
+	// UpdatePuzzle saves a new version of a puzzle when its content changed
+	UpdatePuzzle(ctx context.Context, id string, req dto.PuzzleRequest) (*Puzzle, error)
+	RetirePuzzle(ctx context.Context, id string) error
+	RestorePuzzle(ctx context.Context, id string) error
+	ListPuzzleVersions(ctx context.Context, id string) ([]PuzzleVersion, error)
+	// ImportPuzzles checks every puzzle before adding any of them
+	ImportPuzzles(ctx context.Context, data PuzzleData) (*PuzzleImportResult, error)
+	// ExportPuzzles returns the whole bank, retired puzzles included, in the
+	// shape ImportPuzzles reads
+	ExportPuzzles(ctx context.Context) (*PuzzleData, error)
+}
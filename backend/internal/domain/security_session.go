@@ -15,9 +15,12 @@ type Session struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 	IPAddress    string    `json:"ip_address"`
 	UserAgent    string    `json:"user_agent"`
+	DeviceLabel  string    `json:"device_label"`
+	MFAVerified  bool      `json:"mfa_verified"`
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
 	LoggedOutAt  time.Time `json:"loggedout_at"`
 }
 
@@ -31,6 +34,67 @@ type EmailVerificationToken struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// PasswordResetToken for the forgot-password flow
+type PasswordResetToken struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserMFA holds a user's TOTP enrollment. Recovery codes are stored hashed.
+// A record with Enabled false is a setup that was started but not confirmed.
+type UserMFA struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Secret        string     `json:"secret"`
+	Enabled       bool       `json:"enabled"`
+	RecoveryCodes []string   `json:"recovery_codes"`
+	LastUsedStep  int64      `json:"last_used_step"`
+	EnabledAt     *time.Time `json:"enabled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// MFAChallenge is issued by Login once the password checked out, and is
+// exchanged for a session together with a valid second factor
+type MFAChallenge struct {
+	UserID      string    `json:"user_id"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	DeviceLabel string    `json:"device_label"`
+	Attempts    int       `json:"attempts"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// UserIdentity links an account to a subject at an external OpenID Connect provider
+type UserIdentity struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// ExternalIdentity is what a verified ID token says about its holder
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IDTokenVerifier checks an ID token issued by one OpenID Connect provider.
+// The token must carry nonce, or its SHA-256 hex digest.
+type IDTokenVerifier interface {
+	Verify(ctx context.Context, rawIDToken, nonce string) (*ExternalIdentity, error)
+}
+
 // Security events for audit logging
 type SecurityEvent struct {
 	ID        string                  `json:"id"`
@@ -50,6 +114,72 @@ type SecurityEventRepository interface {
 	LogSecurityEvent(ctx context.Context, userID string, eventType types.SecurityEventType, ipAddress, userAgent string, details types.JSONMap) error
 }
 
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *EmailVerificationToken) error
+	GetByToken(ctx context.Context, token string) (*EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id string) error
+	GetLatestByUserID(ctx context.Context, userID string) (*EmailVerificationToken, error)
+	CountSince(ctx context.Context, userID string, since time.Time) (int64, error)
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	GetByToken(ctx context.Context, token string) (*PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id string) error
+	InvalidateByUserID(ctx context.Context, userID string) error
+	CountSince(ctx context.Context, userID string, since time.Time) (int64, error)
+}
+
+type MFARepository interface {
+	GetByUserID(ctx context.Context, userID string) (*UserMFA, error)
+	// Save creates or replaces the enrollment of mfa.UserID
+	Save(ctx context.Context, mfa *UserMFA) error
+	Delete(ctx context.Context, userID string) error
+	// UpdateLastUsedStep records the TOTP step of an accepted code. It fails with
+	// ErrInvalidMFACode when a code of the same or a later step was already used.
+	UpdateLastUsedStep(ctx context.Context, userID string, step int64) error
+	// ConsumeRecoveryCode removes a hashed recovery code, failing with
+	// ErrInvalidMFACode if the user does not have it
+	ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) error
+}
+
+// MFAChallengeRepository stores pending second login steps, keyed by the hash
+// of the challenge token handed to the client
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, tokenHash string, challenge *MFAChallenge) error
+	Get(ctx context.Context, tokenHash string) (*MFAChallenge, error)
+	// IncrementAttempts counts an attempt at the challenge and returns the
+	// attempts so far, the count is atomic across parallel requests
+	IncrementAttempts(ctx context.Context, tokenHash string) (int, error)
+	Delete(ctx context.Context, tokenHash string) error
+}
+
+// OAuthNonceRepository stores the nonces handed out for OAuth sign-ins, keyed
+// by their hash, until they are used once or expire
+type OAuthNonceRepository interface {
+	Create(ctx context.Context, nonceHash string, expiresAt time.Time) error
+	// Consume removes a nonce, failing with ErrInvalidToken if it was already
+	// used, expired or never issued
+	Consume(ctx context.Context, nonceHash string) error
+}
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *UserIdentity) error
+	// GetByProviderSubject returns ErrResourceNotFound when the pair is not linked yet
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*UserIdentity, error)
+	GetByUserID(ctx context.Context, userID string) ([]UserIdentity, error)
+	UpdateLastLogin(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// LoginAttemptRepository tracks failed logins in short sliding windows, per
+// client IP and per email, independently of any persisted account lockout
+type LoginAttemptRepository interface {
+	RecordFailure(ctx context.Context, ipAddress, email string) (ipFailures int64, emailFailures int64, err error)
+	GetFailures(ctx context.Context, ipAddress, email string) (ipFailures int64, emailFailures int64, err error)
+	ResetEmail(ctx context.Context, email string) error
+}
+
 type UserActivityUsecase interface {
 	GetRecentActivity(ctx context.Context, limit int) ([]SecurityEvent, error)
 }
@@ -58,7 +188,15 @@ type SessionRepository interface {
 	Create(ctx context.Context, session *Session) error
 	GetByID(ctx context.Context, sessionID string) (*Session, error)
 	GetByUserID(ctx context.Context, userID string) ([]*Session, error)
+	// RotateRefreshToken atomically swaps the session's refresh token and extends its
+	// expiry. Presenting a token that was already rotated returns ErrRefreshTokenReused
+	// together with the session it belonged to.
+	RotateRefreshToken(ctx context.Context, refreshToken, newRefreshToken string, expiresAt time.Time) (*Session, error)
+	// Update writes back a session that was read, it never reactivates a
+	// session that was revoked in the meantime
 	Update(ctx context.Context, session *Session) error
+	// UpdateLastSeen changes only when and from where a session was last used
+	UpdateLastSeen(ctx context.Context, sessionID string, lastSeenAt time.Time, ipAddress string) error
 	Delete(ctx context.Context, id string) error
 	DeleteByUserID(ctx context.Context, userID string) error
 	DeleteExpired(ctx context.Context) error
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// BlobStore keeps binary objects such as avatars, song files and journal
+// attachments under slash separated keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns ErrResourceNotFound when the key does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds when the key does not exist
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that serves the object without authentication until ttl passes
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
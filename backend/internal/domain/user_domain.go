@@ -75,6 +75,10 @@ type User struct {
 	PlanEndDate   *time.Time `json:"plan_end_date"`
 	PlanAutoRenew bool       `json:"plan_auto_renew"`
 	PlanStatus    string     `json:"plan_status"`
+
+	// DeletionScheduledAt is set while a self-service deletion is pending, the
+	// account is purged once it passes unless the user logs back in
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
 }
 
 type UserProfile struct {
@@ -86,16 +90,26 @@ type UserProfile struct {
 	Bio                     string                  `json:"bio"`
 	Location                string                  `json:"location"`
 	NotificationPreferences types.NotificationsPref `json:"notification_preferences"`
-	CreatedAt               time.Time               `json:"created_at"`
-	UpdatedAt               time.Time               `json:"updated_at"`
+	// Timezone is an IANA name such as "Africa/Lagos", reminders follow its wall clock
+	Timezone string `json:"timezone"`
+	// HideFromLeaderboard keeps the user off the leaderboards
+	HideFromLeaderboard bool      `json:"hide_from_leaderboard"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	// AvatarThumbnails holds signed links to the square thumbnails keyed by
+	// their size in pixels, AvatarURL is signed too when handed to clients
+	AvatarThumbnails map[string]string `json:"avatar_thumbnails,omitempty"`
 }
 
+// MaxAvatarSize is the largest avatar upload accepted, in bytes
+const MaxAvatarSize = 5 << 20
+
 type DashboardUsecase interface {
 	GetDashboardData(ctx context.Context) (*DashboardData, error)
 }
 
 type UserRepository interface {
-	Create(ctx context.Context, user *User, notificationsPrefs types.NotificationsPref) error
+	Create(ctx context.Context, user *User, notificationsPrefs types.NotificationsPref, timezone string) error
 	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
@@ -103,6 +117,25 @@ type UserRepository interface {
 	UpdateLastLogin(ctx context.Context, userID string) error
 	CreateAdminUser(ctx context.Context, user *User, role string) error
 	UpdateUserRole(ctx context.Context, userID string, role string) error
+	MarkEmailVerified(ctx context.Context, userID string) error
+	UpdatePassword(ctx context.Context, userID, passwordHash, salt string) error
+	// ClearPassword removes the password, the user can then only sign in through a provider
+	ClearPassword(ctx context.Context, userID string) error
+	UpdateName(ctx context.Context, userID, name string) error
+	IncrementFailedLogin(ctx context.Context, userID string) (int, error)
+	LockAccount(ctx context.Context, userID string, until time.Time) error
+	UnlockAccount(ctx context.Context, userID string) error
+	ScheduleDeletion(ctx context.Context, userID string, at time.Time) error
+	CancelDeletion(ctx context.Context, userID string) error
+	// GetDueForDeletion returns the IDs of users whose deletion is scheduled before the given time
+	GetDueForDeletion(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// AccountDeletionRepository erases the personal data of a user
+type AccountDeletionRepository interface {
+	// Purge removes everything the user created, anonymises their payments, which
+	// have to be kept, and scrubs and soft deletes the user row
+	Purge(ctx context.Context, userID string) error
 }
 
 type UserProfileRepository interface {
@@ -116,6 +149,8 @@ type UserProfileRepository interface {
 	UpdateNotificationPreferences(ctx context.Context, userID string, prefs types.NotificationsPref) error
 	Count(ctx context.Context) (int64, error)
 	Exists(ctx context.Context, userID string) (bool, error)
+	// GetPublicProfiles returns the profiles of the active users among userIDs
+	GetPublicProfiles(ctx context.Context, userIDs []string) ([]PublicProfile, error)
 }
 type AdminUserRepository interface {
 	GetAllUsers(ctx context.Context, filter dto.UserListFilter) (dto.UserListResponse, error)
@@ -139,18 +174,53 @@ type AdminUserUseCase interface {
 	GetPendingInvitations(ctx context.Context) ([]AdminInvitation, error)
 	AcceptInvitation(ctx context.Context, invitationToken string) error
 	GetUserByID(ctx context.Context, userID string) (*User, error)
+	// Session management for any user
+	GetUserSessions(ctx context.Context, userID string) ([]dto.SessionResponse, error)
+	RevokeUserSession(ctx context.Context, req dto.RevokeSessionRequest) error
+	RevokeAllUserSessions(ctx context.Context, req dto.LogoutAllRequest) error
+	UnlockUser(ctx context.Context, req dto.UnlockUserRequest) error
+}
+
+// ProfileUseCase lets users read and edit their own account and profile
+type ProfileUseCase interface {
+	GetMe(ctx context.Context, userID string) (*User, error)
+	UpdateMe(ctx context.Context, req dto.UpdateMeRequest) (*User, error)
+	GetProfile(ctx context.Context, userID string) (*UserProfile, error)
+	UpdateProfile(ctx context.Context, req dto.UpdateProfileRequest) (*UserProfile, error)
+	// UploadAvatar stores a JPEG or PNG avatar, without its metadata, along with thumbnails
+	UploadAvatar(ctx context.Context, req dto.UploadAvatarRequest) (*UserProfile, error)
+	RemoveAvatar(ctx context.Context, req dto.RemoveAvatarRequest) error
+}
+
+type AccountUseCase interface {
+	// DeleteAccount logs the user out everywhere and schedules the account for
+	// deletion after the grace period, logging back in cancels it
+	DeleteAccount(ctx context.Context, req dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error)
+	// PurgeDeletedAccounts erases the accounts whose grace period is over
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
 }
 type AuthUseCase interface {
 	Register(ctx context.Context, req dto.RegisterRequest) (*User, error)
 	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
+	OAuthLogin(ctx context.Context, req dto.OAuthLoginRequest) (*dto.LoginResponse, error)
+	// IssueOAuthNonce hands out the nonce the app puts in its next provider sign-in
+	IssueOAuthNonce(ctx context.Context) (*dto.OAuthNonceResponse, error)
 	Logout(ctx context.Context, req dto.LogoutRequest) error
-	AcceptNotificaions(ctx context.Context, fcmToken string, user *User) error
-	//RefreshToken(ctx context.Context, refreshToken string) (*dto.LoginResponse, error)
-	//LogoutAll(ctx context.Context, userID string) error
-	//VerifyEmail(ctx context.Context, token string) error
-	//ForgotPassword(ctx context.Context, email string) error
-	//ResetPassword(ctx context.Context, token, newPassword string) error
-	//ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error
+	AcceptNotificaions(ctx context.Context, req dto.AcceptNotificationRequest, user *User) error
+	RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (*dto.LoginResponse, error)
+	LogoutAll(ctx context.Context, req dto.LogoutAllRequest) error
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, req dto.RevokeSessionRequest) error
+	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context, user *User) error
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, req dto.ChangePasswordRequest) error
+	// Two-factor authentication
+	VerifyMFA(ctx context.Context, req dto.VerifyMFARequest) (*dto.LoginResponse, error)
+	SetupMFA(ctx context.Context, user *User) (*dto.MFASetupResponse, error)
+	EnableMFA(ctx context.Context, req dto.EnableMFARequest) (*dto.MFARecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, req dto.DisableMFARequest) error
 }
 
 // Helper methods for plan management
@@ -0,0 +1,21 @@
+package dto
+
+// AchievementRequest defines an achievement, it replaces every field on update
+type AchievementRequest struct {
+	Name        string                     `json:"name" validate:"required,max=255"`
+	Description string                     `json:"description" validate:"max=1000"`
+	IconName    string                     `json:"icon_name" validate:"max=100"`
+	BadgeColor  string                     `json:"badge_color" validate:"omitempty,hexcolor"`
+	Points      int                        `json:"points" validate:"min=0,max=10000"`
+	Criteria    AchievementCriteriaRequest `json:"criteria"`
+	IsActive    *bool                      `json:"is_active"` // Defaults to true
+	SortOrder   int                        `json:"sort_order"`
+}
+
+// AchievementCriteriaRequest is the metric a user has to reach, see
+// domain.AchievementCriteria
+type AchievementCriteriaRequest struct {
+	Metric    string   `json:"metric" validate:"required,oneof=journal_entries journal_streak puzzles_completed puzzles_correct puzzle_streak challenges_completed challenge_streak challenge_types_completed"`
+	Threshold int      `json:"threshold" validate:"min=0,max=100000"`
+	Types     []string `json:"types" validate:"max=10"`
+}
@@ -21,6 +21,20 @@ type AdminInvitationEmailResponse struct {
 	InvitationLink string `json:"invitation_link"`
 }
 
+type EmailVerificationEmailData struct {
+	Name             string
+	Email            string
+	VerificationLink string
+	ExpiresAt        time.Time
+}
+
+type PasswordResetEmailData struct {
+	Name      string
+	Email     string
+	ResetLink string
+	ExpiresAt time.Time
+}
+
 type PaymentConfirmationEmailData struct {
 	Name          string
 	Amount        int8
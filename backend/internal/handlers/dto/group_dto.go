@@ -0,0 +1,25 @@
+package dto
+
+type CreateGroupRequest struct {
+	UserID      string `json:"-"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// UpdateGroupRequest changes the fields that are set
+type UpdateGroupRequest struct {
+	UserID      string  `json:"-"`
+	GroupID     string  `json:"-"`
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+}
+
+type JoinGroupRequest struct {
+	InviteCode string `json:"invite_code" validate:"required,max=20"`
+}
+
+type GroupListFilter struct {
+	Search string
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,104 @@
+package dto
+
+import "time"
+
+// NotificationStatsFilter selects the deliveries counted in the admin stats
+type NotificationStatsFilter struct {
+	GroupBy  string // "campaign" or "user"
+	Campaign string
+	UserID   string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// NotificationDeliveryStats counts the deliveries of one campaign or user.
+// DeliveryRate is the share of finished deliveries that reached FCM. Deferred
+// deliveries wait for quiet hours to end, suppressed ones were over a daily cap.
+type NotificationDeliveryStats struct {
+	Key          string  `json:"key"`
+	Total        int64   `json:"total"`
+	Sent         int64   `json:"sent"`
+	Failed       int64   `json:"failed"`
+	Pending      int64   `json:"pending"`
+	Deferred     int64   `json:"deferred"`
+	Suppressed   int64   `json:"suppressed"`
+	Retries      int64   `json:"retries"`
+	DeliveryRate float64 `json:"delivery_rate"`
+}
+
+type NotificationStatsResponse struct {
+	GroupBy string                      `json:"group_by"`
+	Stats   []NotificationDeliveryStats `json:"stats"`
+}
+
+// RegisterDeviceRequest registers the FCM token of a device of the current user
+type RegisterDeviceRequest struct {
+	UserID     string `json:"-"`
+	Token      string `json:"token" validate:"required,max=4096"`
+	Platform   string `json:"platform" validate:"omitempty,oneof=ios android web"`
+	AppVersion string `json:"app_version" validate:"omitempty,max=32"`
+}
+
+type UnregisterDeviceRequest struct {
+	UserID string `json:"-"`
+	Token  string `json:"token" validate:"required"`
+}
+
+type DeviceResponse struct {
+	ID         uint      `json:"id"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"app_version"`
+	IsActive   bool      `json:"is_active"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateCampaignRequest composes a push notification campaign. It is sent
+// right away without ScheduledAt.
+type CreateCampaignRequest struct {
+	Title       string                 `json:"title" validate:"required,max=100"`
+	Body        string                 `json:"body" validate:"required,max=500"`
+	Data        map[string]string      `json:"data" validate:"max=20"` // Deep link and other data for the app
+	Segment     CampaignSegmentRequest `json:"segment"`
+	ScheduledAt *time.Time             `json:"scheduled_at"`
+	CreatedBy   string                 `json:"-"`
+}
+
+// CampaignSegmentRequest selects the users of a campaign, empty fields match everyone
+type CampaignSegmentRequest struct {
+	PlanType     string `json:"plan_type" validate:"omitempty,oneof=free yefe_plus"`
+	Language     string `json:"language" validate:"omitempty,oneof=English French Spanish Portuguese"`
+	InactiveDays int    `json:"inactive_days" validate:"omitempty,min=1,max=365"`
+}
+
+// CampaignPreviewResponse shows a campaign as it would be sent and who it reaches
+type CampaignPreviewResponse struct {
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	Data        map[string]string `json:"data"`
+	ScheduledAt time.Time         `json:"scheduled_at"`
+	Users       int               `json:"users"`
+	Devices     int               `json:"devices"`
+}
+
+type CampaignListFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+type InboxFilter struct {
+	UserID     string
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+type InboxUnreadResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+type InboxMarkAllReadResponse struct {
+	Updated int64 `json:"updated"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type PointsFilter struct {
+	UserID string
+	Limit  int
+	Offset int
+}
+
+// ReversePointsRequest is the reason an admin gives for taking back an award
+type ReversePointsRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
@@ -19,3 +19,16 @@ type SubmitAnswerRequest struct {
 	PuzzleId       string `json:"puzzle_id" validate:"required"`
 	SelectedAnswer int    `json:"selectedAnswer" validate:"required"`
 }
+
+// PuzzleRequest defines a puzzle of the bank, it replaces every field on
+// update. The option keys are numbers and the correct answer is one of them.
+type PuzzleRequest struct {
+	Title         string            `json:"title" validate:"required,max=255"`
+	Question      string            `json:"question" validate:"required,max=2000"`
+	Options       map[string]string `json:"options" validate:"required,min=2,max=10,dive,required,max=500"`
+	CorrectAnswer int               `json:"correctAnswer"`
+	Difficulty    string            `json:"difficulty" validate:"required,oneof=Easy Medium Hard"`
+	Category      string            `json:"category" validate:"required,max=100"`
+	Points        int               `json:"points" validate:"min=0,max=1000"`
+	Explanation   string            `json:"explanation" validate:"max=2000"`
+}
@@ -7,12 +7,29 @@ type ReminderRequest struct {
 	EveningReminder string `json:"evening_reminder" validate:"required"`
 }
 
+// QuietHoursRequest holds notifications back between two times on the 24
+// hour clock, e.g. "22:00" and "07:00". Leave both empty to turn it off.
+type QuietHoursRequest struct {
+	Start string `json:"start" validate:"omitempty,len=5"`
+	End   string `json:"end" validate:"omitempty,len=5"`
+}
+
+// NotificationCapsRequest limits the notifications of a day, 0 is no limit
+type NotificationCapsRequest struct {
+	Daily      int `json:"daily" validate:"min=0,max=50"`
+	Reminders  int `json:"reminders" validate:"min=0,max=50"`
+	Challenges int `json:"challenges" validate:"min=0,max=50"`
+	Campaigns  int `json:"campaigns" validate:"min=0,max=50"`
+}
+
 type UserPrefsRequest struct {
-	MorningPrompt     bool            `json:"morning_prompt"`
-	EveningReflection bool            `json:"evening_reflection"`
-	Challenge         bool            `json:"challenge"`
-	Language          string          `json:"language" validate:"required,oneof=English French Spanish Portuguese"`
-	Reminders         ReminderRequest `json:"reminders"`
+	MorningPrompt     bool                    `json:"morning_prompt"`
+	EveningReflection bool                    `json:"evening_reflection"`
+	Challenge         bool                    `json:"challenge"`
+	Language          string                  `json:"language" validate:"required,oneof=English French Spanish Portuguese"`
+	Reminders         ReminderRequest         `json:"reminders"`
+	QuietHours        QuietHoursRequest       `json:"quiet_hours"`
+	Caps              NotificationCapsRequest `json:"caps"`
 }
 
 // Request/Response DTOs
@@ -22,21 +39,120 @@ type RegisterRequest struct {
 	Password        string           `json:"password" validate:"required,min=8"`
 	Prefs           UserPrefsRequest `json:"user_prefs" validate:"required"`
 	ConfirmPassword string           `json:"confirm_password" validate:"required,eqfield=Password"`
+	Timezone        string           `json:"timezone" validate:"omitempty,max=64"` // IANA name, UTC when left out
 	IPAddress       string           `json:"-"`
 	UserAgent       string           `json:"-"`
 }
 
 type LoginRequest struct {
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required"`
-	IPAddress string `json:"-"`
-	UserAgent string `json:"-"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// OAuthLoginRequest carries an OpenID Connect ID token obtained by the app
+type OAuthLoginRequest struct {
+	Provider string `json:"-"`
+	IDToken  string `json:"id_token" validate:"required"`
+	// Nonce is one issued by IssueOAuthNonce, the ID token must carry it
+	Nonce string `json:"nonce" validate:"required"`
+	// Name is used for new accounts when the ID token has none, as with Apple
+	Name       string `json:"name" validate:"omitempty,max=50"`
+	DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+	Timezone   string `json:"timezone" validate:"omitempty,max=64"` // IANA name, used for new accounts
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
 }
 
 type LoginResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"`
+	// MFARequired is set instead of the tokens when the password was correct but
+	// a second factor has to be sent to /auth/mfa/verify with ChallengeToken
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	// MFAEnrollmentRequired tells admins without two-factor to set it up before
+	// they can reach admin routes
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+	// DeletionCancelled reports that this login restored an account pending deletion
+	DeletionCancelled bool `json:"deletion_cancelled,omitempty"`
+}
+
+type VerifyMFARequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is either a 6 digit TOTP code or a recovery code
+	Code      string `json:"code" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// OAuthNonceResponse is a single-use nonce to request the provider ID token with
+type OAuthNonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type EnableMFARequest struct {
+	UserID    string `json:"-"`
+	SessionID string `json:"-"`
+	Code      string `json:"code" validate:"required,len=6"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableMFARequest struct {
+	UserID    string `json:"-"`
+	Password  string `json:"password" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	IPAddress    string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+type VerifyEmailRequest struct {
+	Token     string `json:"token" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ForgotPasswordRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ResetPasswordRequest struct {
+	Token           string `json:"token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+	IPAddress       string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+type ChangePasswordRequest struct {
+	UserID          string `json:"-"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=NewPassword"`
+	IPAddress       string `json:"-"`
+	UserAgent       string `json:"-"`
 }
 
 type LogoutRequest struct {
@@ -45,6 +161,92 @@ type LogoutRequest struct {
 	UserAgent string `json:"-"`
 }
 
+// LogoutAllRequest revokes every session of a user except ExceptSessionID, if set
+type LogoutAllRequest struct {
+	UserID          string `json:"-"`
+	ExceptSessionID string `json:"-"`
+	RevokedBy       string `json:"-"`
+	IPAddress       string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+// UpdateMeRequest edits the current user's account, nil fields are left unchanged
+type UpdateMeRequest struct {
+	UserID    string  `json:"-"`
+	Name      *string `json:"name" validate:"omitempty,min=3,max=50"`
+	IPAddress string  `json:"-"`
+	UserAgent string  `json:"-"`
+}
+
+// UpdateProfileRequest edits the current user's profile. Nil fields are left
+// unchanged and an empty string clears the field.
+type UpdateProfileRequest struct {
+	UserID   string  `json:"-"`
+	Bio      *string `json:"bio" validate:"omitempty,max=500"`
+	Location *string `json:"location" validate:"omitempty,max=255"`
+	// DateOfBirth is formatted as YYYY-MM-DD
+	DateOfBirth *string `json:"date_of_birth" validate:"omitempty,eq=|len=10"`
+	// PhoneNumber is in E.164 format, such as +2348012345678
+	PhoneNumber             *string           `json:"phone_number" validate:"omitempty,eq=|e164"`
+	NotificationPreferences *UserPrefsRequest `json:"notification_preferences"`
+	Timezone                *string           `json:"timezone" validate:"omitempty,min=1,max=64"` // IANA name, such as Africa/Lagos
+	HideFromLeaderboard     *bool             `json:"hide_from_leaderboard"`
+	IPAddress               string            `json:"-"`
+	UserAgent               string            `json:"-"`
+}
+
+type UploadAvatarRequest struct {
+	UserID    string
+	Data      []byte
+	IPAddress string
+	UserAgent string
+}
+
+type RemoveAvatarRequest struct {
+	UserID    string
+	IPAddress string
+	UserAgent string
+}
+
+// DeleteAccountRequest asks for the account to be deleted, Password is required
+// unless the account only signs in through an identity provider
+type DeleteAccountRequest struct {
+	UserID    string `json:"-"`
+	Password  string `json:"password"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+type RevokeSessionRequest struct {
+	UserID    string `json:"-"`
+	SessionID string `json:"-"`
+	RevokedBy string `json:"-"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type UnlockUserRequest struct {
+	UserID     string `json:"-"`
+	UnlockedBy string `json:"-"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
 type UserListFilter struct {
 	Status    string `json:"status"`
 	Plan      string `json:"plan"`
@@ -96,5 +298,7 @@ type UpdatePlanRequest struct {
 }
 
 type AcceptNotificationRequest struct {
-	FcmToken string `json:"fcm_token" validate:"required"`
+	FcmToken   string `json:"fcm_token" validate:"required"`
+	Platform   string `json:"platform" validate:"omitempty,oneof=ios android web"`
+	AppVersion string `json:"app_version" validate:"omitempty,max=32"`
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+)
+
+type AchievementHandler struct {
+	achievementUseCase domain.AchievementUseCase
+}
+
+func NewAchievementHandler(achievementUseCase domain.AchievementUseCase) *AchievementHandler {
+	return &AchievementHandler{achievementUseCase: achievementUseCase}
+}
+
+func (h AchievementHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListAchievementsRoute)
+	return router
+}
+
+// ListAchievementsRoute lists the achievements users can earn with the
+// progress of the current user
+func (h AchievementHandler) ListAchievementsRoute(w http.ResponseWriter, r *http.Request) {
+	achievements, err := h.achievementUseCase.ListAchievements(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievements", achievements)
+}
+
+type AdminAchievementHandler struct {
+	achievementUseCase domain.AchievementUseCase
+	validator          *validator.Validate
+}
+
+func NewAdminAchievementHandler(achievementUseCase domain.AchievementUseCase) *AdminAchievementHandler {
+	return &AdminAchievementHandler{
+		achievementUseCase: achievementUseCase,
+		validator:          validator.New(),
+	}
+}
+
+func (h AdminAchievementHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListAchievementsRoute)
+	router.Post("/", h.CreateAchievementRoute)
+	router.Get("/{achievementID}", h.GetAchievementRoute)
+	router.Put("/{achievementID}", h.UpdateAchievementRoute)
+	router.Delete("/{achievementID}", h.DeleteAchievementRoute)
+	return router
+}
+
+// decodeAchievement reads and validates the achievement in the request body,
+// it writes the error response when it fails
+func (h AdminAchievementHandler) decodeAchievement(w http.ResponseWriter, r *http.Request) (dto.AchievementRequest, bool) {
+	var req dto.AchievementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return req, false
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return req, false
+	}
+	return req, true
+}
+
+// ListAchievementsRoute lists every achievement, inactive ones included
+func (h AdminAchievementHandler) ListAchievementsRoute(w http.ResponseWriter, r *http.Request) {
+	achievements, err := h.achievementUseCase.ListAllAchievements(r.Context())
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievements", achievements)
+}
+
+func (h AdminAchievementHandler) CreateAchievementRoute(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeAchievement(w, r)
+	if !ok {
+		return
+	}
+
+	achievement, err := h.achievementUseCase.CreateAchievement(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Achievement created", achievement)
+}
+
+func (h AdminAchievementHandler) GetAchievementRoute(w http.ResponseWriter, r *http.Request) {
+	achievement, err := h.achievementUseCase.GetAchievement(r.Context(), chi.URLParam(r, "achievementID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievement", achievement)
+}
+
+func (h AdminAchievementHandler) UpdateAchievementRoute(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeAchievement(w, r)
+	if !ok {
+		return
+	}
+
+	achievement, err := h.achievementUseCase.UpdateAchievement(r.Context(), chi.URLParam(r, "achievementID"), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievement updated", achievement)
+}
+
+// DeleteAchievementRoute removes an achievement, users who earned it no
+// longer see it
+func (h AdminAchievementHandler) DeleteAchievementRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.achievementUseCase.DeleteAchievement(r.Context(), chi.URLParam(r, "achievementID")); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievement deleted", nil)
+}
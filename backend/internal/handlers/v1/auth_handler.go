@@ -8,6 +8,7 @@ import (
 	"yefe_app/v1/pkg/logger"
 	"yefe_app/v1/pkg/utils"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator"
 )
 
@@ -41,9 +42,301 @@ func (a AuthHandler) LoginRoute(w http.ResponseWriter, r *http.Request) {
 		utils.HandleDomainError(w, err)
 		return
 	}
+	if user.MFARequired {
+		utils.SuccessResponse(w, http.StatusOK, "Two-factor authentication required", user)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "User logined-in successfully", user)
+}
+
+// OAuthLoginRoute signs in with an ID token from the provider in the URL
+func (a AuthHandler) OAuthLoginRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.OAuthLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.Provider = chi.URLParam(r, "provider")
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	user, err := a.authUseCase.OAuthLogin(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	if user.MFARequired {
+		utils.SuccessResponse(w, http.StatusOK, "Two-factor authentication required", user)
+		return
+	}
 	utils.SuccessResponse(w, http.StatusCreated, "User logined-in successfully", user)
 }
 
+// OAuthNonceRoute issues the nonce the app requests its provider ID token with
+func (a AuthHandler) OAuthNonceRoute(w http.ResponseWriter, r *http.Request) {
+	nonce, err := a.authUseCase.IssueOAuthNonce(r.Context())
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Nonce issued", nonce)
+}
+
+// RefreshRoute exchanges a refresh token for a new access/refresh token pair
+func (a AuthHandler) RefreshRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	tokens, err := a.authUseCase.RefreshToken(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// VerifyEmailRoute verifies a user's email address from the link sent by email
+func (a AuthHandler) VerifyEmailRoute(w http.ResponseWriter, r *http.Request) {
+	req := dto.VerifyEmailRequest{Token: r.URL.Query().Get("token")}
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing verification token", nil)
+		return
+	}
+
+	if err := a.authUseCase.VerifyEmail(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Email verified successfully", nil)
+}
+
+// ResendVerificationRoute sends a new verification email to the current user
+func (a AuthHandler) ResendVerificationRoute(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+
+	if err := a.authUseCase.ResendVerificationEmail(r.Context(), user); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Verification email sent", nil)
+}
+
+// ForgotPasswordRoute sends a password reset link to the given email
+func (a AuthHandler) ForgotPasswordRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if err := a.authUseCase.ForgotPassword(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "If an account exists for this email, a reset link has been sent", nil)
+}
+
+// ResetPasswordRoute sets a new password using a reset token
+func (a AuthHandler) ResetPasswordRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if err := a.authUseCase.ResetPassword(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Password reset successfully", nil)
+}
+
+// ChangePasswordRoute changes the password of the current user
+func (a AuthHandler) ChangePasswordRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if err := a.authUseCase.ChangePassword(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Password changed successfully, please log in again", nil)
+}
+
+// VerifyMFARoute completes a login with a TOTP or recovery code
+func (a AuthHandler) VerifyMFARoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	tokens, err := a.authUseCase.VerifyMFA(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "User logined-in successfully", tokens)
+}
+
+// SetupMFARoute generates a TOTP secret for the current user
+func (a AuthHandler) SetupMFARoute(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r.Context())
+
+	setup, err := a.authUseCase.SetupMFA(r.Context(), user)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Scan the QR code with your authenticator app", setup)
+}
+
+// EnableMFARoute confirms the TOTP setup and returns the recovery codes
+func (a AuthHandler) EnableMFARoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.EnableMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+	req.SessionID = r.Context().Value("session_id").(string)
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	codes, err := a.authUseCase.EnableMFA(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Two-factor authentication enabled", codes)
+}
+
+// DisableMFARoute turns two-factor off for the current user
+func (a AuthHandler) DisableMFARoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.DisableMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := a.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	if err := a.authUseCase.DisableMFA(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
 // Logout Handles user logout request
 func (a AuthHandler) LogoutRoute(w http.ResponseWriter, r *http.Request) {
 	sessionId := r.Context().Value("session_id").(string)
@@ -99,6 +392,67 @@ func (a AuthHandler) RegisterRoute(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// LogoutAllRoute revokes every session of the current user, including this one
+func (a AuthHandler) LogoutAllRoute(w http.ResponseWriter, r *http.Request) {
+	req := dto.LogoutAllRequest{
+		UserID:    getUserIDFromContext(r.Context()),
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if err := a.authUseCase.LogoutAll(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Logged out from all devices", nil)
+}
+
+// ListSessionsRoute lists the active sessions of the current user
+func (a AuthHandler) ListSessionsRoute(w http.ResponseWriter, r *http.Request) {
+	userID := getUserIDFromContext(r.Context())
+	sessionID, _ := r.Context().Value("session_id").(string)
+
+	sessions, err := a.authUseCase.ListSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Active sessions", sessions)
+}
+
+// RevokeSessionRoute revokes one session of the current user
+func (a AuthHandler) RevokeSessionRoute(w http.ResponseWriter, r *http.Request) {
+	req := dto.RevokeSessionRequest{
+		UserID:    getUserIDFromContext(r.Context()),
+		SessionID: chi.URLParam(r, "sessionID"),
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if err := a.authUseCase.RevokeSession(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Session revoked", nil)
+}
+
+// RevokeOtherSessionsRoute revokes every session of the current user except this one
+func (a AuthHandler) RevokeOtherSessionsRoute(w http.ResponseWriter, r *http.Request) {
+	sessionID, _ := r.Context().Value("session_id").(string)
+	req := dto.LogoutAllRequest{
+		UserID:          getUserIDFromContext(r.Context()),
+		ExceptSessionID: sessionID,
+		IPAddress:       utils.GetClientIP(r),
+		UserAgent:       r.UserAgent(),
+	}
+
+	if err := a.authUseCase.LogoutAll(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Other sessions revoked", nil)
+}
+
 func (a AuthHandler) AcceptNotifications(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromContext(r.Context())
 	var req dto.AcceptNotificationRequest
@@ -119,7 +473,7 @@ func (a AuthHandler) AcceptNotifications(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := a.authUseCase.AcceptNotificaions(r.Context(), req.FcmToken, user)
+	err := a.authUseCase.AcceptNotificaions(r.Context(), req, user)
 
 	if err != nil {
 		logger.Log.WithError(err).Error("Could not register notification")
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+)
+
+type AdminCampaignHandler struct {
+	campaignUseCase domain.CampaignUseCase
+	validator       *validator.Validate
+}
+
+func NewAdminCampaignHandler(campaignUseCase domain.CampaignUseCase) *AdminCampaignHandler {
+	return &AdminCampaignHandler{
+		campaignUseCase: campaignUseCase,
+		validator:       validator.New(),
+	}
+}
+
+func (h AdminCampaignHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListCampaignsRoute)
+	router.Post("/", h.CreateCampaignRoute)
+	router.Post("/preview", h.PreviewCampaignRoute)
+	router.Get("/{campaignID}", h.GetCampaignRoute)
+	router.Post("/{campaignID}/cancel", h.CancelCampaignRoute)
+	router.Get("/{campaignID}/stats", h.CampaignStatsRoute)
+	return router
+}
+
+// decodeCampaign reads and validates the campaign in the request body, it
+// writes the error response when it fails
+func (h AdminCampaignHandler) decodeCampaign(w http.ResponseWriter, r *http.Request) (dto.CreateCampaignRequest, bool) {
+	var req dto.CreateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return req, false
+	}
+
+	req.CreatedBy = getUserIDFromContext(r.Context())
+
+	if err := h.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return req, false
+	}
+	return req, true
+}
+
+// CreateCampaignRoute sends a campaign to its segment now, or at scheduled_at
+func (h AdminCampaignHandler) CreateCampaignRoute(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.campaignUseCase.CreateCampaign(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Campaign scheduled", campaign)
+}
+
+// PreviewCampaignRoute shows how a campaign would be sent and how many users
+// and devices it reaches
+func (h AdminCampaignHandler) PreviewCampaignRoute(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.campaignUseCase.PreviewCampaign(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Campaign preview", preview)
+}
+
+// ListCampaignsRoute lists campaigns, latest first, optionally by status
+func (h AdminCampaignHandler) ListCampaignsRoute(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := dto.CampaignListFilter{Status: query.Get("status")}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil {
+		filter.Offset = offset
+	}
+
+	campaigns, err := h.campaignUseCase.ListCampaigns(r.Context(), filter)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Campaigns", campaigns)
+}
+
+func (h AdminCampaignHandler) GetCampaignRoute(w http.ResponseWriter, r *http.Request) {
+	campaign, err := h.campaignUseCase.GetCampaign(r.Context(), chi.URLParam(r, "campaignID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Campaign", campaign)
+}
+
+// CancelCampaignRoute stops a campaign that has not been sent yet
+func (h AdminCampaignHandler) CancelCampaignRoute(w http.ResponseWriter, r *http.Request) {
+	campaign, err := h.campaignUseCase.CancelCampaign(r.Context(), chi.URLParam(r, "campaignID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Campaign cancelled", campaign)
+}
+
+// CampaignStatsRoute counts the deliveries of a campaign
+func (h AdminCampaignHandler) CampaignStatsRoute(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.campaignUseCase.GetCampaignStats(r.Context(), chi.URLParam(r, "campaignID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Campaign delivery stats", stats)
+}
@@ -12,12 +12,14 @@ import (
 )
 
 type challengesHandler struct {
-	challengeUseCase domain.ChallengeUseCase
+	challengeUseCase   domain.ChallengeUseCase
+	leaderboardUseCase domain.LeaderboardUseCase
 }
 
-func NewChallengesHandler(challengeUseCase domain.ChallengeUseCase) *challengesHandler {
+func NewChallengesHandler(challengeUseCase domain.ChallengeUseCase, leaderboardUseCase domain.LeaderboardUseCase) *challengesHandler {
 	return &challengesHandler{
-		challengeUseCase: challengeUseCase,
+		challengeUseCase:   challengeUseCase,
+		leaderboardUseCase: leaderboardUseCase,
 	}
 }
 
@@ -171,7 +173,8 @@ func (h *challengesHandler) getUserStats(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(convertChallengeStatsToDTO(stats))
 }
 
-// getLeaderboard gets the leaderboard
+// getLeaderboard gets the leaderboard of a window, weekly, monthly or
+// all_time, with the place of the authenticated user
 func (h *challengesHandler) getLeaderboard(w http.ResponseWriter, r *http.Request) {
 	limitStr := r.URL.Query().Get("limit")
 	limit := 10 // default
@@ -181,16 +184,15 @@ func (h *challengesHandler) getLeaderboard(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	leaderboard, err := h.challengeUseCase.GetLeaderboard(limit)
+	userID := getUserIDFromContext(r.Context())
+	leaderboard, err := h.leaderboardUseCase.GetLeaderboard(r.Context(), userID, r.URL.Query().Get("window"), limit)
 	if err != nil {
-		http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
+		utils.HandleDomainError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"leaderboard": convertChallengeStatsSliceToDTO(leaderboard),
-	})
+	json.NewEncoder(w).Encode(leaderboard)
 }
 func convertToChallengeResponse(uc domain.UserChallenge) dto.ChallengeResponse {
 	response := dto.ChallengeResponse{
@@ -235,11 +237,3 @@ func convertChallengeStatsToDTO(stats domain.ChallengeStats) *dto.ChallengeStats
 		LongestStreak:   stats.LongestStreak,
 	}
 }
-
-func convertChallengeStatsSliceToDTO(statsSlice []domain.ChallengeStats) []*dto.ChallengeStatsDTO {
-	var dtos []*dto.ChallengeStatsDTO
-	for _, stats := range statsSlice {
-		dtos = append(dtos, convertChallengeStatsToDTO(stats))
-	}
-	return dtos
-}
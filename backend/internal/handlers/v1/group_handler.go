@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+)
+
+// decodeValid reads the JSON request body into req and validates it, it
+// writes the error response when it fails
+func decodeValid(w http.ResponseWriter, r *http.Request, v *validator.Validate, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return false
+	}
+	if err := v.Struct(req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return false
+	}
+	return true
+}
+
+type GroupHandler struct {
+	groupUseCase domain.GroupUseCase
+	validator    *validator.Validate
+}
+
+func NewGroupHandler(groupUseCase domain.GroupUseCase) *GroupHandler {
+	return &GroupHandler{
+		groupUseCase: groupUseCase,
+		validator:    validator.New(),
+	}
+}
+
+func (h GroupHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListGroupsRoute)
+	router.Post("/", h.CreateGroupRoute)
+	router.Post("/join", h.JoinGroupRoute)
+	router.Get("/{groupID}", h.GetGroupRoute)
+	router.Patch("/{groupID}", h.UpdateGroupRoute)
+	router.Delete("/{groupID}", h.DeleteGroupRoute)
+	router.Post("/{groupID}/leave", h.LeaveGroupRoute)
+	router.Post("/{groupID}/invite-code", h.ResetInviteCodeRoute)
+	router.Get("/{groupID}/leaderboard", h.LeaderboardRoute)
+	router.Delete("/{groupID}/members/{userID}", h.RemoveMemberRoute)
+	router.Post("/{groupID}/members/{userID}/encourage", h.EncourageRoute)
+	return router
+}
+
+// ListGroupsRoute lists the groups of the current user
+func (h GroupHandler) ListGroupsRoute(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groupUseCase.ListGroups(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Groups", groups)
+}
+
+func (h GroupHandler) CreateGroupRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateGroupRequest
+	if !decodeValid(w, r, h.validator, &req) {
+		return
+	}
+	req.UserID = getUserIDFromContext(r.Context())
+
+	group, err := h.groupUseCase.CreateGroup(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Group created", group)
+}
+
+func (h GroupHandler) JoinGroupRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.JoinGroupRequest
+	if !decodeValid(w, r, h.validator, &req) {
+		return
+	}
+
+	group, err := h.groupUseCase.JoinGroup(r.Context(), getUserIDFromContext(r.Context()), req.InviteCode)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Joined group", group)
+}
+
+// GetGroupRoute returns a group with the streaks of its members
+func (h GroupHandler) GetGroupRoute(w http.ResponseWriter, r *http.Request) {
+	group, err := h.groupUseCase.GetGroup(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group", group)
+}
+
+func (h GroupHandler) UpdateGroupRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateGroupRequest
+	if !decodeValid(w, r, h.validator, &req) {
+		return
+	}
+	req.UserID = getUserIDFromContext(r.Context())
+	req.GroupID = chi.URLParam(r, "groupID")
+
+	group, err := h.groupUseCase.UpdateGroup(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group updated", group)
+}
+
+func (h GroupHandler) DeleteGroupRoute(w http.ResponseWriter, r *http.Request) {
+	err := h.groupUseCase.DeleteGroup(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group deleted", nil)
+}
+
+func (h GroupHandler) LeaveGroupRoute(w http.ResponseWriter, r *http.Request) {
+	err := h.groupUseCase.LeaveGroup(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Left group", nil)
+}
+
+func (h GroupHandler) ResetInviteCodeRoute(w http.ResponseWriter, r *http.Request) {
+	group, err := h.groupUseCase.ResetInviteCode(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Invite code reset", group)
+}
+
+// LeaderboardRoute ranks the members of a group on their points in a window
+func (h GroupHandler) LeaderboardRoute(w http.ResponseWriter, r *http.Request) {
+	leaderboard, err := h.groupUseCase.GetLeaderboard(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"), r.URL.Query().Get("window"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group leaderboard", leaderboard)
+}
+
+func (h GroupHandler) RemoveMemberRoute(w http.ResponseWriter, r *http.Request) {
+	err := h.groupUseCase.RemoveMember(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"), chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Member removed", nil)
+}
+
+// EncourageRoute sends a member a push notification from the current user
+func (h GroupHandler) EncourageRoute(w http.ResponseWriter, r *http.Request) {
+	err := h.groupUseCase.Encourage(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "groupID"), chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Encouragement sent", nil)
+}
+
+type AdminGroupHandler struct {
+	groupUseCase domain.GroupUseCase
+}
+
+func NewAdminGroupHandler(groupUseCase domain.GroupUseCase) *AdminGroupHandler {
+	return &AdminGroupHandler{groupUseCase: groupUseCase}
+}
+
+func (h AdminGroupHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListGroupsRoute)
+	router.Get("/{groupID}", h.GetGroupRoute)
+	router.Delete("/{groupID}", h.DeleteGroupRoute)
+	router.Delete("/{groupID}/members/{userID}", h.RemoveMemberRoute)
+	return router
+}
+
+// ListGroupsRoute lists every group, latest first, optionally by name
+func (h AdminGroupHandler) ListGroupsRoute(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := dto.GroupListFilter{Search: query.Get("search")}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil {
+		filter.Offset = offset
+	}
+
+	groups, err := h.groupUseCase.AdminListGroups(r.Context(), filter)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Groups", groups)
+}
+
+// GetGroupRoute returns a group with its members and the nudges they sent
+func (h AdminGroupHandler) GetGroupRoute(w http.ResponseWriter, r *http.Request) {
+	group, err := h.groupUseCase.AdminGetGroup(r.Context(), chi.URLParam(r, "groupID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group", group)
+}
+
+func (h AdminGroupHandler) DeleteGroupRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.groupUseCase.AdminDeleteGroup(r.Context(), chi.URLParam(r, "groupID")); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Group deleted", nil)
+}
+
+func (h AdminGroupHandler) RemoveMemberRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.groupUseCase.AdminRemoveMember(r.Context(), chi.URLParam(r, "groupID"), chi.URLParam(r, "userID")); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Member removed", nil)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type InboxHandler struct {
+	inboxUseCase domain.InboxUseCase
+}
+
+func NewInboxHandler(inboxUseCase domain.InboxUseCase) *InboxHandler {
+	return &InboxHandler{inboxUseCase: inboxUseCase}
+}
+
+func (h InboxHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListNotificationsRoute)
+	router.Get("/unread-count", h.UnreadCountRoute)
+	router.Post("/read-all", h.MarkAllReadRoute)
+	router.Post("/{notificationID}/read", h.MarkReadRoute)
+	return router
+}
+
+// ListNotificationsRoute lists the notifications of the current user, latest
+// first, only the unread ones with unread=true
+func (h InboxHandler) ListNotificationsRoute(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := dto.InboxFilter{
+		UserID:     getUserIDFromContext(r.Context()),
+		UnreadOnly: query.Get("unread") == "true",
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil {
+		filter.Offset = offset
+	}
+
+	notifications, err := h.inboxUseCase.ListNotifications(r.Context(), filter)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Notifications", notifications)
+}
+
+func (h InboxHandler) UnreadCountRoute(w http.ResponseWriter, r *http.Request) {
+	unread, err := h.inboxUseCase.UnreadCount(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Unread notifications", dto.InboxUnreadResponse{Unread: unread})
+}
+
+func (h InboxHandler) MarkReadRoute(w http.ResponseWriter, r *http.Request) {
+	err := h.inboxUseCase.MarkRead(r.Context(), getUserIDFromContext(r.Context()), chi.URLParam(r, "notificationID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Notification marked as read", nil)
+}
+
+func (h InboxHandler) MarkAllReadRoute(w http.ResponseWriter, r *http.Request) {
+	updated, err := h.inboxUseCase.MarkAllRead(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Notifications marked as read", dto.InboxMarkAllReadResponse{Updated: updated})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"yefe_app/v1/pkg/tokens"
+	"yefe_app/v1/pkg/utils"
+)
+
+// jwksCacheControl lets verifiers cache the key set for as long as a new key is
+// published before it signs
+var jwksCacheControl = fmt.Sprintf("public, max-age=%d", int(tokens.JWKSMaxAge.Seconds()))
+
+type jwksHandler struct {
+	keys *tokens.KeySet
+}
+
+func NewJWKSHandler(keys *tokens.KeySet) jwksHandler {
+	return jwksHandler{keys}
+}
+
+// JWKSRoute serves the public signing keys as a JSON Web Key Set. It is a
+// plain JWKS document rather than the usual response envelope, so standard
+// JWT libraries can consume it directly.
+func (h jwksHandler) JWKSRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	utils.JSONResponse(w, http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+)
+
+// avatarFormOverhead is allowed on top of the file size for the multipart framing
+const avatarFormOverhead = 1 << 20
+
+type MeHandler struct {
+	profileUseCase     domain.ProfileUseCase
+	accountUseCase     domain.AccountUseCase
+	deviceUseCase      domain.DeviceUseCase
+	achievementUseCase domain.AchievementUseCase
+	validator          *validator.Validate
+}
+
+// NewMeHandler creates the handler for the current user's account
+func NewMeHandler(profileUseCase domain.ProfileUseCase, accountUseCase domain.AccountUseCase, deviceUseCase domain.DeviceUseCase, achievementUseCase domain.AchievementUseCase) *MeHandler {
+	return &MeHandler{
+		profileUseCase:     profileUseCase,
+		accountUseCase:     accountUseCase,
+		deviceUseCase:      deviceUseCase,
+		achievementUseCase: achievementUseCase,
+		validator:          validator.New(),
+	}
+}
+
+func (m MeHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", m.GetMeRoute)
+	router.Patch("/", m.UpdateMeRoute)
+	router.Delete("/", m.DeleteAccountRoute)
+	router.Get("/profile", m.GetProfileRoute)
+	router.Patch("/profile", m.UpdateProfileRoute)
+	router.Put("/profile/avatar", m.UploadAvatarRoute)
+	router.Delete("/profile/avatar", m.RemoveAvatarRoute)
+	router.Get("/devices", m.ListDevicesRoute)
+	router.Post("/devices", m.RegisterDeviceRoute)
+	router.Delete("/devices", m.UnregisterDeviceRoute)
+	router.Get("/achievements", m.ListAchievementsRoute)
+	return router
+}
+
+// GetMeRoute returns the current user with their profile
+func (m MeHandler) GetMeRoute(w http.ResponseWriter, r *http.Request) {
+	user, err := m.profileUseCase.GetMe(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "User retrieved successfully", user)
+}
+
+// UpdateMeRoute edits the current user's account
+func (m MeHandler) UpdateMeRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateMeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := m.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return
+	}
+
+	user, err := m.profileUseCase.UpdateMe(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "User updated successfully", user)
+}
+
+// GetProfileRoute returns the current user's profile
+func (m MeHandler) GetProfileRoute(w http.ResponseWriter, r *http.Request) {
+	profile, err := m.profileUseCase.GetProfile(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Profile retrieved successfully", profile)
+}
+
+// UpdateProfileRoute edits the current user's profile
+func (m MeHandler) UpdateProfileRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Validate request
+	if err := m.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return
+	}
+
+	profile, err := m.profileUseCase.UpdateProfile(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Profile updated successfully", profile)
+}
+
+// UploadAvatarRoute replaces the current user's avatar with the image in the
+// "avatar" field of a multipart form
+func (m MeHandler) UploadAvatarRoute(w http.ResponseWriter, r *http.Request) {
+	// Leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, domain.MaxAvatarSize+avatarFormOverhead)
+	if err := r.ParseMultipartForm(domain.MaxAvatarSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.HandleDomainError(w, domain.ErrFileTooLarge)
+			return
+		}
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid multipart form", nil)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "The avatar file is required", nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, domain.MaxAvatarSize+1))
+	if err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Could not read the avatar file", nil)
+		return
+	}
+
+	req := dto.UploadAvatarRequest{
+		UserID:    getUserIDFromContext(r.Context()),
+		Data:      data,
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	profile, err := m.profileUseCase.UploadAvatar(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Avatar updated successfully", profile)
+}
+
+// RemoveAvatarRoute deletes the current user's avatar
+func (m MeHandler) RemoveAvatarRoute(w http.ResponseWriter, r *http.Request) {
+	req := dto.RemoveAvatarRequest{
+		UserID:    getUserIDFromContext(r.Context()),
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if err := m.profileUseCase.RemoveAvatar(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Avatar removed successfully", nil)
+}
+
+// ListDevicesRoute returns the devices the current user receives notifications on
+func (m MeHandler) ListDevicesRoute(w http.ResponseWriter, r *http.Request) {
+	devices, err := m.deviceUseCase.ListDevices(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Devices retrieved successfully", devices)
+}
+
+// RegisterDeviceRoute registers the FCM token of the device the user logged in on
+func (m MeHandler) RegisterDeviceRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Validate request
+	if err := m.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return
+	}
+
+	device, err := m.deviceUseCase.RegisterDevice(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Device registered successfully", device)
+}
+
+// UnregisterDeviceRoute stops notifications to a device, to be called before logging out
+func (m MeHandler) UnregisterDeviceRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.UnregisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Validate request
+	if err := m.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return
+	}
+
+	if err := m.deviceUseCase.UnregisterDevice(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Device unregistered successfully", nil)
+}
+
+// ListAchievementsRoute returns the achievements the current user earned, latest first
+func (m MeHandler) ListAchievementsRoute(w http.ResponseWriter, r *http.Request) {
+	achievements, err := m.achievementUseCase.GetUserAchievements(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Achievements retrieved successfully", achievements)
+}
+
+// DeleteAccountRoute schedules the current user's account for deletion
+func (m MeHandler) DeleteAccountRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.DeleteAccountRequest
+	// The body is optional for accounts without a password
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	req.UserID = getUserIDFromContext(r.Context())
+
+	// Set IP and User Agent
+	req.IPAddress = utils.GetClientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	response, err := m.accountUseCase.DeleteAccount(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusAccepted, "Account scheduled for deletion, log in again before the date to cancel", response)
+}
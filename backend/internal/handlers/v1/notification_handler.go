@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminNotificationHandler struct {
+	notificationUseCase domain.NotificationAdminUseCase
+}
+
+func NewAdminNotificationHandler(notificationUseCase domain.NotificationAdminUseCase) *AdminNotificationHandler {
+	return &AdminNotificationHandler{notificationUseCase: notificationUseCase}
+}
+
+func (h AdminNotificationHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/stats", h.DeliveryStatsRoute)
+	return router
+}
+
+// DeliveryStatsRoute returns push notification delivery counts and rates per
+// campaign, or per user with group_by=user
+func (h AdminNotificationHandler) DeliveryStatsRoute(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := dto.NotificationStatsFilter{
+		GroupBy:  query.Get("group_by"),
+		Campaign: query.Get("campaign"),
+		UserID:   query.Get("user_id"),
+	}
+
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+
+	// Dates are whole days, the to date is included
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "from must be a date in the YYYY-MM-DD format", nil)
+			return
+		}
+		filter.From = &parsed
+	}
+	if to := query.Get("to"); to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "to must be a date in the YYYY-MM-DD format", nil)
+			return
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		filter.To = &parsed
+	}
+
+	stats, err := h.notificationUseCase.GetDeliveryStats(r.Context(), filter)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Notification delivery stats", stats)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+)
+
+// pointsFilter reads the paging of a ledger listing from the query string
+func pointsFilter(r *http.Request, userID string) dto.PointsFilter {
+	query := r.URL.Query()
+	filter := dto.PointsFilter{UserID: userID}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(query.Get("offset")); err == nil {
+		filter.Offset = offset
+	}
+	return filter
+}
+
+type PointsHandler struct {
+	pointsUseCase domain.PointsUseCase
+}
+
+func NewPointsHandler(pointsUseCase domain.PointsUseCase) *PointsHandler {
+	return &PointsHandler{pointsUseCase: pointsUseCase}
+}
+
+func (h PointsHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.GetBalanceRoute)
+	router.Get("/history", h.ListEntriesRoute)
+	return router
+}
+
+// GetBalanceRoute returns the points of the current user, in total and per
+// source
+func (h PointsHandler) GetBalanceRoute(w http.ResponseWriter, r *http.Request) {
+	balance, err := h.pointsUseCase.GetBalance(r.Context(), getUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Points", balance)
+}
+
+// ListEntriesRoute lists the points ledger of the current user, latest first
+func (h PointsHandler) ListEntriesRoute(w http.ResponseWriter, r *http.Request) {
+	ledger, err := h.pointsUseCase.ListEntries(r.Context(), pointsFilter(r, getUserIDFromContext(r.Context())))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Points history", ledger)
+}
+
+type AdminPointsHandler struct {
+	pointsUseCase domain.PointsUseCase
+	validator     *validator.Validate
+}
+
+func NewAdminPointsHandler(pointsUseCase domain.PointsUseCase) *AdminPointsHandler {
+	return &AdminPointsHandler{
+		pointsUseCase: pointsUseCase,
+		validator:     validator.New(),
+	}
+}
+
+func (h AdminPointsHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/users/{userID}", h.GetBalanceRoute)
+	router.Get("/users/{userID}/history", h.ListEntriesRoute)
+	router.Post("/entries/{entryID}/reverse", h.ReverseEntryRoute)
+	return router
+}
+
+func (h AdminPointsHandler) GetBalanceRoute(w http.ResponseWriter, r *http.Request) {
+	balance, err := h.pointsUseCase.GetBalance(r.Context(), chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Points", balance)
+}
+
+func (h AdminPointsHandler) ListEntriesRoute(w http.ResponseWriter, r *http.Request) {
+	ledger, err := h.pointsUseCase.ListEntries(r.Context(), pointsFilter(r, chi.URLParam(r, "userID")))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Points history", ledger)
+}
+
+// ReverseEntryRoute takes back an award with an entry of the opposite points
+func (h AdminPointsHandler) ReverseEntryRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReversePointsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ValidationErrorResponse(w, err)
+		return
+	}
+
+	reversal, err := h.pointsUseCase.ReverseEntry(r.Context(), chi.URLParam(r, "entryID"), req.Reason)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Points reversed", reversal)
+}
@@ -10,6 +10,7 @@ import (
 	"yefe_app/v1/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
 )
 
 type puzzleHandler struct {
@@ -122,3 +123,138 @@ func (h *puzzleHandler) GetUserCompletedPuzzles(w http.ResponseWriter, r *http.R
 		"data": completedPuzzles,
 	})
 }
+
+type AdminPuzzleHandler struct {
+	puzzleAdminUseCase domain.PuzzleAdminUseCase
+	validator          *validator.Validate
+}
+
+func NewAdminPuzzleHandler(puzzleAdminUseCase domain.PuzzleAdminUseCase) *AdminPuzzleHandler {
+	return &AdminPuzzleHandler{
+		puzzleAdminUseCase: puzzleAdminUseCase,
+		validator:          validator.New(),
+	}
+}
+
+func (h AdminPuzzleHandler) Handle() *chi.Mux {
+	router := chi.NewRouter()
+	router.Get("/", h.ListPuzzlesRoute)
+	router.Post("/", h.CreatePuzzleRoute)
+	router.Get("/export", h.ExportPuzzlesRoute)
+	router.Post("/import", h.ImportPuzzlesRoute)
+	router.Get("/{puzzleID}", h.GetPuzzleRoute)
+	router.Put("/{puzzleID}", h.UpdatePuzzleRoute)
+	router.Post("/{puzzleID}/retire", h.RetirePuzzleRoute)
+	router.Post("/{puzzleID}/restore", h.RestorePuzzleRoute)
+	router.Get("/{puzzleID}/versions", h.ListVersionsRoute)
+	return router
+}
+
+// ListPuzzlesRoute lists the puzzles in rotation, and retired ones with
+// ?retired=true
+func (h AdminPuzzleHandler) ListPuzzlesRoute(w http.ResponseWriter, r *http.Request) {
+	puzzles, err := h.puzzleAdminUseCase.ListPuzzles(r.Context(), r.URL.Query().Get("retired") == "true")
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzles", puzzles)
+}
+
+func (h AdminPuzzleHandler) CreatePuzzleRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.PuzzleRequest
+	if !decodeValid(w, r, h.validator, &req) {
+		return
+	}
+
+	puzzle, err := h.puzzleAdminUseCase.CreatePuzzle(r.Context(), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusCreated, "Puzzle created", puzzle)
+}
+
+func (h AdminPuzzleHandler) GetPuzzleRoute(w http.ResponseWriter, r *http.Request) {
+	puzzle, err := h.puzzleAdminUseCase.GetPuzzle(r.Context(), chi.URLParam(r, "puzzleID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzle", puzzle)
+}
+
+// UpdatePuzzleRoute saves the request as the next version of the puzzle
+func (h AdminPuzzleHandler) UpdatePuzzleRoute(w http.ResponseWriter, r *http.Request) {
+	var req dto.PuzzleRequest
+	if !decodeValid(w, r, h.validator, &req) {
+		return
+	}
+
+	puzzle, err := h.puzzleAdminUseCase.UpdatePuzzle(r.Context(), chi.URLParam(r, "puzzleID"), req)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzle updated", puzzle)
+}
+
+// RetirePuzzleRoute takes a puzzle out of rotation, answers given to it are
+// kept
+func (h AdminPuzzleHandler) RetirePuzzleRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.puzzleAdminUseCase.RetirePuzzle(r.Context(), chi.URLParam(r, "puzzleID")); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzle retired", nil)
+}
+
+func (h AdminPuzzleHandler) RestorePuzzleRoute(w http.ResponseWriter, r *http.Request) {
+	if err := h.puzzleAdminUseCase.RestorePuzzle(r.Context(), chi.URLParam(r, "puzzleID")); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzle restored", nil)
+}
+
+func (h AdminPuzzleHandler) ListVersionsRoute(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.puzzleAdminUseCase.ListPuzzleVersions(r.Context(), chi.URLParam(r, "puzzleID"))
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzle versions", versions)
+}
+
+// ImportPuzzlesRoute adds the puzzles of an export, puzzles already in the
+// bank are skipped
+func (h AdminPuzzleHandler) ImportPuzzlesRoute(w http.ResponseWriter, r *http.Request) {
+	var data domain.PuzzleData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		logger.Log.WithError(err).Error("")
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	result, err := h.puzzleAdminUseCase.ImportPuzzles(r.Context(), data)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+	utils.SuccessResponse(w, http.StatusOK, "Puzzles imported", result)
+}
+
+// ExportPuzzlesRoute downloads the whole bank as a backup the import reads
+func (h AdminPuzzleHandler) ExportPuzzlesRoute(w http.ResponseWriter, r *http.Request) {
+	data, err := h.puzzleAdminUseCase.ExportPuzzles(r.Context())
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="puzzles-`+time.Now().Format("2006-01-02")+`.json"`)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Log.WithError(err).Error("Failed to write puzzle export")
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"yefe_app/v1/pkg/logger"
+)
+
+// resetPasswordPage is the form behind the link in the password reset email.
+// It posts the token and the new password to POST /v1/auth/reset-password.
+var resetPasswordPage = template.Must(template.New("reset-password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="referrer" content="no-referrer">
+<title>Reset your password</title>
+<style>
+body { font-family: sans-serif; max-width: 24rem; margin: 3rem auto; padding: 0 1rem; }
+label, input, button { display: block; width: 100%; box-sizing: border-box; }
+input { margin: .25rem 0 1rem; padding: .5rem; }
+button { padding: .6rem; }
+</style>
+</head>
+<body>
+<h1>Reset your password</h1>
+{{if .Token}}
+<form id="reset-form">
+<input type="hidden" name="token" value="{{.Token}}">
+<label for="new_password">New password</label>
+<input id="new_password" name="new_password" type="password" minlength="8" autocomplete="new-password" required>
+<label for="confirm_password">Confirm new password</label>
+<input id="confirm_password" name="confirm_password" type="password" minlength="8" autocomplete="new-password" required>
+<button type="submit">Reset password</button>
+</form>
+<p id="message" role="status"></p>
+<script>
+document.getElementById("reset-form").addEventListener("submit", async function (event) {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  const message = document.getElementById("message");
+  if (form.get("new_password") !== form.get("confirm_password")) {
+    message.textContent = "The passwords do not match.";
+    return;
+  }
+  const response = await fetch(window.location.pathname, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(Object.fromEntries(form)),
+  });
+  const body = await response.json().catch(() => ({}));
+  if (response.ok) {
+    event.target.remove();
+    message.textContent = "Your password was reset, you can now log in with it in the app.";
+  } else {
+    message.textContent = body.message || "The password could not be reset.";
+  }
+});
+</script>
+{{else}}
+<p>This reset link is incomplete. Request a new one from the app.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// ResetPasswordPageRoute serves the page the password reset email links to
+func (a AuthHandler) ResetPasswordPageRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// The token is in the URL, keep it out of caches
+	w.Header().Set("Cache-Control", "no-store")
+
+	data := struct{ Token string }{Token: r.URL.Query().Get("token")}
+	if err := resetPasswordPage.Execute(w, data); err != nil {
+		logger.Log.WithError(err).Error("Could not render reset password page")
+	}
+}
@@ -28,6 +28,10 @@ func (h *adminUserHandler) Handle() *chi.Mux {
 	router.Get("/admins", h.getUser)
 	router.Put("/{userID}/status", h.updateUserStatus)
 	router.Put("/{userID}/plan", h.updateUserPlan)
+	router.Get("/{userID}/sessions", h.getUserSessions)
+	router.Delete("/{userID}/sessions", h.revokeAllUserSessions)
+	router.Delete("/{userID}/sessions/{sessionID}", h.revokeUserSession)
+	router.Post("/{userID}/unlock", h.unlockUser)
 	router.Post("/invite", h.inviteNewAdmin)
 	router.Get("/invitations", h.getPendingInvitations)
 	return router
@@ -199,6 +203,100 @@ func (h *adminUserHandler) updateUserPlan(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary List user sessions
+// @Description Get the active sessions of a user
+// @Tags Admin
+// @Param userID path string true "User ID"
+// @Success 200 {array} dto.SessionResponse
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 500 {object} web.ErrorResponse
+// @Router /admin/{userID}/sessions [get]
+func (h *adminUserHandler) getUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	sessions, err := h.adminUC.GetUserSessions(r.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+
+	utils.SuccessResponse(w, http.StatusOK, "sessions", sessions)
+}
+
+// @Summary Revoke user session
+// @Description Revoke a single session of a user
+// @Tags Admin
+// @Param userID path string true "User ID"
+// @Param sessionID path string true "Session ID"
+// @Success 204
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 500 {object} web.ErrorResponse
+// @Router /admin/{userID}/sessions/{sessionID} [delete]
+func (h *adminUserHandler) revokeUserSession(w http.ResponseWriter, r *http.Request) {
+	req := dto.RevokeSessionRequest{
+		UserID:    chi.URLParam(r, "userID"),
+		SessionID: chi.URLParam(r, "sessionID"),
+		RevokedBy: getUserIDFromContext(r.Context()),
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if err := h.adminUC.RevokeUserSession(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Revoke all user sessions
+// @Description Log a user out of every device
+// @Tags Admin
+// @Param userID path string true "User ID"
+// @Success 204
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 500 {object} web.ErrorResponse
+// @Router /admin/{userID}/sessions [delete]
+func (h *adminUserHandler) revokeAllUserSessions(w http.ResponseWriter, r *http.Request) {
+	req := dto.LogoutAllRequest{
+		UserID:    chi.URLParam(r, "userID"),
+		RevokedBy: getUserIDFromContext(r.Context()),
+		IPAddress: utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	if err := h.adminUC.RevokeAllUserSessions(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Unlock user
+// @Description Lift a login lockout before it expires
+// @Tags Admin
+// @Param userID path string true "User ID"
+// @Success 204
+// @Failure 404 {object} web.ErrorResponse
+// @Failure 500 {object} web.ErrorResponse
+// @Router /admin/{userID}/unlock [post]
+func (h *adminUserHandler) unlockUser(w http.ResponseWriter, r *http.Request) {
+	req := dto.UnlockUserRequest{
+		UserID:     chi.URLParam(r, "userID"),
+		UnlockedBy: getUserIDFromContext(r.Context()),
+		IPAddress:  utils.GetClientIP(r),
+		UserAgent:  r.UserAgent(),
+	}
+
+	if err := h.adminUC.UnlockUser(r.Context(), req); err != nil {
+		utils.HandleDomainError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // @Summary Invite new admin
 // @Description Send invitation to a new admin user
 // @Tags Admin
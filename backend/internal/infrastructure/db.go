@@ -81,6 +81,18 @@ func autoMigrate(db *gorm.DB) error {
 		&models.Achievement{},
 		&models.AdminInvitation{},
 		&models.Payment{},
+		&models.EmailVerificationToken{},
+		&models.PasswordResetToken{},
+		&models.UserMFA{},
+		&models.UserIdentity{},
+		&models.Campaign{},
+		&models.InboxNotification{},
+		&models.PointsEntry{},
+		&models.Group{},
+		&models.GroupMember{},
+		&models.GroupNudge{},
+		&models.Puzzle{},
+		&models.PuzzleVersion{},
 	)
 }
 
@@ -99,8 +99,8 @@ func (ChallengeType) TableName() string {
 // UserAchievement represents achievements earned by users
 type UserAchievement struct {
 	ID            string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	UserID        string         `gorm:"type:varchar(36);not null;index" json:"user_id"`
-	AchievementID string         `gorm:"type:varchar(36);not null;index" json:"achievement_id"`
+	UserID        string         `gorm:"type:varchar(36);not null;index;uniqueIndex:idx_user_achievement,priority:1" json:"user_id"`
+	AchievementID string         `gorm:"type:varchar(36);not null;index;uniqueIndex:idx_user_achievement,priority:2" json:"achievement_id"`
 	EarnedAt      time.Time      `gorm:"not null" json:"earned_at"`
 	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
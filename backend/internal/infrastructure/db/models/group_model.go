@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group is an accountability group users join with its invite code
+type Group struct {
+	ID          string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name        string         `gorm:"type:varchar(100);not null" json:"name"`
+	Description string         `gorm:"type:varchar(500)" json:"description"`
+	InviteCode  string         `gorm:"type:varchar(20);not null;uniqueIndex" json:"invite_code"`
+	OwnerID     string         `gorm:"type:varchar(36);not null;index" json:"owner_id"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	// Relationships
+	Members []GroupMember `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE" json:"members,omitempty"`
+}
+
+// TableName overrides the table name used by Group to `groups`
+func (Group) TableName() string {
+	return "groups"
+}
+
+// GroupMember is the membership of a user in a group
+type GroupMember struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	GroupID   string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_group_member,priority:1" json:"group_id"`
+	UserID    string    `gorm:"type:varchar(36);not null;index;uniqueIndex:idx_group_member,priority:2" json:"user_id"`
+	Role      string    `gorm:"type:varchar(20);not null" json:"role"`
+	JoinedAt  time.Time `gorm:"not null" json:"joined_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+}
+
+// TableName overrides the table name used by GroupMember to `group_members`
+func (GroupMember) TableName() string {
+	return "group_members"
+}
+
+// GroupNudge is an encouragement a member sent another, kept to limit how
+// often they can be sent
+type GroupNudge struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	GroupID     string    `gorm:"type:varchar(36);not null;index:idx_group_nudge,priority:1" json:"group_id"`
+	SenderID    string    `gorm:"type:varchar(36);not null;index:idx_group_nudge,priority:2" json:"sender_id"`
+	RecipientID string    `gorm:"type:varchar(36);not null;index:idx_group_nudge,priority:3" json:"recipient_id"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName overrides the table name used by GroupNudge to `group_nudges`
+func (GroupNudge) TableName() string {
+	return "group_nudges"
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+	"yefe_app/v1/pkg/types"
+)
+
+// Campaign is a push notification broadcast by an admin to a segment of users
+type Campaign struct {
+	ID          string          `gorm:"type:varchar(36);primaryKey" json:"id"`
+	Title       string          `gorm:"type:varchar(100);not null" json:"title"`
+	Body        string          `gorm:"type:text;not null" json:"body"`
+	Data        types.JSONMap   `gorm:"type:jsonb" json:"data"`
+	Segment     CampaignSegment `gorm:"embedded;embeddedPrefix:segment_" json:"segment"`
+	Status      string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	ScheduledAt time.Time       `gorm:"not null;index" json:"scheduled_at"`
+	SentAt      *time.Time      `json:"sent_at"`
+	Recipients  int             `gorm:"default:0" json:"recipients"`
+	Error       string          `gorm:"type:text" json:"error"`
+	CreatedBy   string          `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type CampaignSegment struct {
+	PlanType     string `gorm:"type:varchar(20)" json:"plan_type"`
+	Language     string `gorm:"type:varchar(20)" json:"language"`
+	InactiveDays int    `gorm:"default:0" json:"inactive_days"`
+}
+
+// InboxNotification is a notification kept for the in-app notification list
+type InboxNotification struct {
+	ID        string        `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID    string        `gorm:"type:varchar(36);not null;index:idx_inbox_user_created,priority:1" json:"user_id"`
+	Category  string        `gorm:"type:varchar(20);not null" json:"category"`
+	Title     string        `gorm:"type:varchar(255);not null" json:"title"`
+	Body      string        `gorm:"type:text" json:"body"`
+	Data      types.JSONMap `gorm:"type:jsonb" json:"data"`
+	ReadAt    *time.Time    `json:"read_at"`
+	CreatedAt time.Time     `gorm:"autoCreateTime;index:idx_inbox_user_created,priority:2" json:"created_at"`
+}
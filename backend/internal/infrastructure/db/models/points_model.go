@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PointsEntry is a line of the append-only points ledger. An award is unique
+// per user and source, a reversal per entry it reverses, ReversalOf is empty
+// for awards so the unique index covers both.
+type PointsEntry struct {
+	ID         string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID     string    `gorm:"type:varchar(36);not null;index;uniqueIndex:idx_points_source,priority:1" json:"user_id"`
+	Points     int       `gorm:"not null" json:"points"`
+	SourceType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_points_source,priority:2" json:"source_type"`
+	SourceID   string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_points_source,priority:3" json:"source_id"`
+	Reason     string    `gorm:"type:varchar(255);not null" json:"reason"`
+	ReversalOf string    `gorm:"type:varchar(36);not null;default:'';uniqueIndex:idx_points_source,priority:4" json:"reversal_of"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName overrides the table name used by PointsEntry to `points_ledger`
+func (PointsEntry) TableName() string {
+	return "points_ledger"
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Puzzle is a puzzle of the bank at its latest version
+type Puzzle struct {
+	ID            string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Title         string     `gorm:"type:varchar(255);not null" json:"title"`
+	Question      string     `gorm:"type:text;not null" json:"question"`
+	Options       string     `gorm:"type:text;not null" json:"options"` // JSON object of option key to text
+	CorrectAnswer int        `gorm:"not null" json:"correct_answer"`
+	Difficulty    string     `gorm:"type:varchar(20);not null;index" json:"difficulty"`
+	Category      string     `gorm:"type:varchar(100);index" json:"category"`
+	Points        int        `gorm:"default:0" json:"points"`
+	Explanation   string     `gorm:"type:text" json:"explanation"`
+	Version       int        `gorm:"not null;default:1" json:"version"`
+	RetiredAt     *time.Time `gorm:"index" json:"retired_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Versions []PuzzleVersion `gorm:"foreignKey:PuzzleID;constraint:OnDelete:CASCADE" json:"versions,omitempty"`
+}
+
+// TableName overrides the table name used by Puzzle to `puzzles`
+func (Puzzle) TableName() string {
+	return "puzzles"
+}
+
+// PuzzleVersion is the content a puzzle had at one of its versions
+type PuzzleVersion struct {
+	ID            string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	PuzzleID      string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_puzzle_version,priority:1" json:"puzzle_id"`
+	Version       int       `gorm:"not null;uniqueIndex:idx_puzzle_version,priority:2" json:"version"`
+	Title         string    `gorm:"type:varchar(255);not null" json:"title"`
+	Question      string    `gorm:"type:text;not null" json:"question"`
+	Options       string    `gorm:"type:text;not null" json:"options"` // JSON object of option key to text
+	CorrectAnswer int       `gorm:"not null" json:"correct_answer"`
+	Difficulty    string    `gorm:"type:varchar(20);not null" json:"difficulty"`
+	Category      string    `gorm:"type:varchar(100)" json:"category"`
+	Points        int       `gorm:"default:0" json:"points"`
+	Explanation   string    `gorm:"type:text" json:"explanation"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the table name used by PuzzleVersion to `puzzle_versions`
+func (PuzzleVersion) TableName() string {
+	return "puzzle_versions"
+}
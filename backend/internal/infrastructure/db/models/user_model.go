@@ -52,6 +52,8 @@ type User struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 	LastLoginAt *time.Time     `gorm:"index" json:"last_login_at"`
 	LastLoginIP string         `gorm:"type:varchar(45)" json:"-"` // IPv6 compatible
+
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at"`
 }
 
 // UserProfile for extended user information
@@ -66,6 +68,8 @@ type UserProfile struct {
 	AvatarURL               string                  `gorm:"type:varchar(500)" json:"avatar_url"`
 	Website                 string                  `gorm:"type:varchar(255)" json:"website"`
 	NotificationPreferences types.NotificationsPref `gorm:"embedded;embeddedPrefix:notification_" json:"notification_preferences"`
+	Timezone                string                  `gorm:"type:varchar(64);not null;default:'UTC'" json:"timezone"`
+	HideFromLeaderboard     bool                    `gorm:"default:false" json:"hide_from_leaderboard"`
 	CreatedAt               time.Time               `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt               time.Time               `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -104,11 +108,61 @@ type SecurityEvent struct {
 	ResolvedAt *time.Time              `json:"resolved_at"`
 	CreatedAt  time.Time               `gorm:"index" json:"created_at"`
 }
+
+// EmailVerificationToken stores the hash of a token sent to verify a user's email
+type EmailVerificationToken struct {
+	ID        string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	Used      bool      `gorm:"default:false" json:"used"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// PasswordResetToken stores the hash of a token sent to reset a user's password
+type PasswordResetToken struct {
+	ID        string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	Used      bool      `gorm:"default:false" json:"used"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// UserMFA stores a user's TOTP secret and the hashes of their recovery codes
+type UserMFA struct {
+	ID            string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID        string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"user_id"`
+	User          *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Secret        string     `gorm:"type:varchar(64);not null" json:"secret"`
+	Enabled       bool       `gorm:"default:false" json:"enabled"`
+	RecoveryCodes types.Tags `gorm:"type:text" json:"recovery_codes"`
+	LastUsedStep  int64      `gorm:"default:0" json:"last_used_step"`
+	EnabledAt     *time.Time `json:"enabled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// UserIdentity links a user to an account at an OpenID Connect provider
+type UserIdentity struct {
+	ID          string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID      string     `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Provider    string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject     string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	Email       string     `gorm:"type:varchar(255)" json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
 type UserPuzzleProgress struct {
 	ID             string     `json:"id" gorm:"primaryKey"`
 	UserID         string     `json:"userId" gorm:"not null;index"`
 	User           *User      `gorm:"foreignKey:UserID" json:"-"`
 	PuzzleID       string     `json:"puzzleId" gorm:"not null;index"`
+	PuzzleVersion  int        `json:"puzzleVersion" gorm:"default:0"`
 	IsCompleted    bool       `json:"isCompleted" gorm:"default:false"`
 	SelectedAnswer *int       `json:"selectedAnswer,omitempty"`
 	IsCorrect      *bool      `json:"isCorrect,omitempty"`
@@ -132,6 +186,22 @@ func (Session) TableName() string {
 	return "sessions"
 }
 
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
 func (j *UserProfile) BeforeCreate(tx *gorm.DB) error {
 
 	return j.NotificationPreferences.Reminders.Validate()
@@ -35,6 +35,22 @@ func (m *AuthMiddleware) AdminOnly(next http.Handler) http.Handler {
 			return
 		}
 
+		// Admin sessions must have passed a second factor
+		session, ok := r.Context().Value("session").(*domain.Session)
+		if !ok || session == nil || !session.MFAVerified {
+			logger.Log.Warnf("AdminOnly: Admin '%s' session without two-factor", user.ID)
+
+			m.secEventRepo.LogSecurityEvent(r.Context(), user.ID, types.EventUnauthorizedAccess,
+				r.Method, r.URL.Path, types.JSONMap{
+					"attempted_route": r.URL.Path,
+					"method":          r.Method,
+					"reason":          "mfa_required",
+				})
+
+			utils.ErrorResponse(w, http.StatusForbidden, "Two-factor authentication required", nil)
+			return
+		}
+
 		// Proceed to protected handler
 		next.ServeHTTP(w, r)
 	})
@@ -7,28 +7,35 @@ import (
 	"time"
 	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/tokens"
 	"yefe_app/v1/pkg/types"
 	"yefe_app/v1/pkg/utils"
 )
 
+// lastSeenUpdateInterval limits how often a session's last-seen time is written back
+const lastSeenUpdateInterval = 5 * time.Minute
+
 type AuthMiddleware struct {
-	jwtSecret    string
-	sessionRepo  domain.SessionRepository
-	userRepo     domain.UserRepository
-	secEventRepo domain.SecurityEventRepository
+	jwtKeys              *tokens.KeySet
+	sessionRepo          domain.SessionRepository
+	userRepo             domain.UserRepository
+	secEventRepo         domain.SecurityEventRepository
+	requireVerifiedEmail bool
 }
 
 func NewAuthMiddleware(
-	jwtSecret string,
+	jwtKeys *tokens.KeySet,
 	sessionRepo domain.SessionRepository,
 	userRepo domain.UserRepository,
 	secEventRepo domain.SecurityEventRepository,
+	requireVerifiedEmail bool,
 ) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtSecret:    jwtSecret,
-		sessionRepo:  sessionRepo,
-		userRepo:     userRepo,
-		secEventRepo: secEventRepo,
+		jwtKeys:              jwtKeys,
+		sessionRepo:          sessionRepo,
+		userRepo:             userRepo,
+		secEventRepo:         secEventRepo,
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
@@ -74,6 +81,31 @@ func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	})
 }
 
+// RequireVerifiedEmail middleware - blocks users with an unverified email when
+// the require_verified_email_for_paid server setting is enabled
+func (m *AuthMiddleware) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.requireVerifiedEmail || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Get user from context (set by RequireAuth middleware)
+		user, ok := r.Context().Value("user").(*domain.User)
+		if !ok || user == nil {
+			m.handleAuthError(w, domain.ErrUnauthorized)
+			return
+		}
+
+		if !user.IsEmailVerified {
+			m.handleAuthError(w, domain.ErrEmailNotVerified)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // authenticateRequest performs the actual authentication logic
 func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*domain.User, *domain.Session, error) {
 	// Extract token from Authorization header
@@ -83,7 +115,7 @@ func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*domain.User, *do
 	}
 
 	// Parse and validate JWT
-	sessionID, err := utils.ExtractSessionIDFromToken(token, m.jwtSecret)
+	sessionID, err := m.jwtKeys.ExtractSessionID(token)
 	if err != nil {
 		return nil, nil, domain.ErrInvalidToken
 	}
@@ -91,7 +123,7 @@ func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*domain.User, *do
 	// Get session from database
 	session, err := m.sessionRepo.GetByID(r.Context(), sessionID)
 	if err != nil || session == nil {
-		logger.Log.WithError(err).Errorf("Session not found: ", sessionID)
+		logger.Log.WithError(err).Errorf("Session not found: %s", sessionID)
 		return nil, nil, domain.ErrSessionNotFound
 	}
 
@@ -103,7 +135,7 @@ func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*domain.User, *do
 
 	// Check if session has expired
 	if session.ExpiresAt.Before(time.Now()) {
-		logger.Log.Errorf("Session expired: ", sessionID)
+		logger.Log.Errorf("Session expired: %s", sessionID)
 		return nil, nil, domain.ErrSessionExpired
 	}
 
@@ -112,6 +144,14 @@ func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*domain.User, *do
 		return nil, nil, domain.ErrUserNotFound
 	}
 
+	if time.Since(session.LastSeenAt) > lastSeenUpdateInterval {
+		session.LastSeenAt = time.Now()
+		session.IPAddress = utils.GetClientIP(r)
+		if err := m.sessionRepo.UpdateLastSeen(r.Context(), session.ID, session.LastSeenAt, session.IPAddress); err != nil {
+			logger.Log.WithError(err).Warn("Could not update session last seen time")
+		}
+	}
+
 	// Check if user is active
 	if !user.IsActive {
 		m.secEventRepo.LogSecurityEvent(r.Context(), user.ID, types.EventAuthFailed, "", "", types.JSONMap{
@@ -172,6 +212,9 @@ func (m *AuthMiddleware) handleAuthError(w http.ResponseWriter, err error) {
 	case domain.ErrAccountLocked:
 		w.WriteHeader(http.StatusLocked)
 		w.Write([]byte(`{"error": "account is locked"}`))
+	case domain.ErrEmailNotVerified:
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": "email not verified"}`))
 	default:
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"error": "internal server error"}`))
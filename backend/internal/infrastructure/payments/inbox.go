@@ -0,0 +1,27 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/logger"
+)
+
+// paymentAlreadyConfirmed reports whether a payment was confirmed before, by
+// the app or by a webhook, so the user is only told once
+func paymentAlreadyConfirmed(status string) bool {
+	return status == "completed" || status == "succeeded"
+}
+
+// addPaymentToInbox tells the user in the app that their payment went through
+func addPaymentToInbox(ctx context.Context, inbox domain.InboxUseCase, payment *domain.Payment) {
+	notification := domain.InboxNotification{
+		Category: domain.InboxPayment,
+		Title:    "Payment confirmed",
+		Body:     fmt.Sprintf("We received your payment of %.2f %s. Yefe Plus is now active.", float64(payment.Amount)/100, payment.Currency),
+		Data:     map[string]string{"type": "payment", "payment_id": payment.ID},
+	}
+	if err := inbox.AddToInbox(ctx, []string{payment.UserID}, notification); err != nil {
+		logger.Log.WithError(err).Errorf("Could not add payment %s to the inbox", payment.ID)
+	}
+}
@@ -18,6 +18,7 @@ type paystackPaymentProvider struct {
 	adminUC        domain.AdminUserUseCase
 	paystackClient domain.PaymentProviderClient
 	securityRepo   domain.SecurityEventRepository
+	inbox          domain.InboxUseCase
 	paymentConfig  utils.PaymentConfig // Changed from utils.Stripe to more generic name
 }
 
@@ -27,6 +28,7 @@ func NewPaystackPaymentProvider(
 	adminUC domain.AdminUserUseCase,
 	paystackClient domain.PaymentProviderClient,
 	securityRepo domain.SecurityEventRepository,
+	inbox domain.InboxUseCase,
 	paymentConfig utils.PaymentConfig,
 ) domain.PaymentProvider {
 	return &paystackPaymentProvider{
@@ -35,6 +37,7 @@ func NewPaystackPaymentProvider(
 		adminUC:        adminUC,
 		paystackClient: paystackClient,
 		securityRepo:   securityRepo,
+		inbox:          inbox,
 		paymentConfig:  paymentConfig,
 	}
 }
@@ -94,6 +97,8 @@ func (uc *paystackPaymentProvider) ConfirmPayment(ctx context.Context, req dto.C
 		return dto.ConfirmPaymentResponse{}, fmt.Errorf("payment not found: %w", err)
 	}
 
+	alreadyConfirmed := paymentAlreadyConfirmed(payment.Status)
+
 	// Update payment status based on verification
 	var status string
 	switch verifyResp.Data.Status {
@@ -119,6 +124,9 @@ func (uc *paystackPaymentProvider) ConfirmPayment(ctx context.Context, req dto.C
 		logger.Log.WithError(err).Errorf("Could not update user %s plan", payment.UserID)
 		return dto.ConfirmPaymentResponse{}, err
 	}
+	if status == "succeeded" && !alreadyConfirmed {
+		addPaymentToInbox(ctx, uc.inbox, payment)
+	}
 	err = uc.securityRepo.LogSecurityEvent(ctx, payment.UserID, types.EventConfirmPayment, "", "", types.JSONMap{
 		"payment_id": payment.ID,
 	})
@@ -187,6 +195,8 @@ func (u *paystackPaymentProvider) handlePaystackChargeSuccess(ctx context.Contex
 		return fmt.Errorf("payment not found: %w", err)
 	}
 
+	alreadyConfirmed := paymentAlreadyConfirmed(payment.Status)
+
 	// Update payment status
 	now := time.Now()
 	payment.Status = "completed"
@@ -207,6 +217,10 @@ func (u *paystackPaymentProvider) handlePaystackChargeSuccess(ctx context.Contex
 		}
 	}
 
+	if !alreadyConfirmed {
+		addPaymentToInbox(ctx, u.inbox, payment)
+	}
+
 	// Get user details for email
 	user, err := u.adminUC.GetUserByID(ctx, payment.UserID)
 	if err != nil {
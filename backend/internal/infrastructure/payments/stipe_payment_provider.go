@@ -20,10 +20,11 @@ type stripePaymentProvider struct {
 	paymentConfig utils.PaymentConfig
 	emailService  domain.EmailService
 	securityRepo  domain.SecurityEventRepository
+	inbox         domain.InboxUseCase
 }
 
-func NewStripePaymentProvider(repo domain.PaymentRepository, adminUC domain.AdminUserUseCase, paymentConfig utils.PaymentConfig, emailSerice domain.EmailService, securityRepo domain.SecurityEventRepository) domain.PaymentProvider {
-	return &stripePaymentProvider{repo: repo, adminUC: adminUC, paymentConfig: paymentConfig, emailService: emailSerice, securityRepo: securityRepo}
+func NewStripePaymentProvider(repo domain.PaymentRepository, adminUC domain.AdminUserUseCase, paymentConfig utils.PaymentConfig, emailSerice domain.EmailService, securityRepo domain.SecurityEventRepository, inbox domain.InboxUseCase) domain.PaymentProvider {
+	return &stripePaymentProvider{repo: repo, adminUC: adminUC, paymentConfig: paymentConfig, emailService: emailSerice, securityRepo: securityRepo, inbox: inbox}
 }
 
 func (u *stripePaymentProvider) CreatePaymentIntent(ctx context.Context, req dto.CreatePaymentIntentRequest) (dto.CreatePaymentIntentResponse, error) {
@@ -93,6 +94,8 @@ func (u *stripePaymentProvider) ConfirmPayment(ctx context.Context, req dto.Conf
 		return dto.ConfirmPaymentResponse{}, fmt.Errorf("payment not successful: %s", pi.Status)
 	}
 
+	alreadyConfirmed := paymentAlreadyConfirmed(payment.Status)
+
 	// Update payment status
 	now := time.Now()
 	payment.Status = "completed"
@@ -109,6 +112,9 @@ func (u *stripePaymentProvider) ConfirmPayment(ctx context.Context, req dto.Conf
 		logger.Log.WithError(err).Error("Could not update user %s plan", payment.UserID)
 		return dto.ConfirmPaymentResponse{}, err
 	}
+	if !alreadyConfirmed {
+		addPaymentToInbox(ctx, u.inbox, payment)
+	}
 	err = u.securityRepo.LogSecurityEvent(ctx, payment.UserID, types.EventConfirmPayment, "", "", types.JSONMap{
 		"payment_id": payment.ID,
 	})
@@ -182,6 +188,8 @@ func (u *stripePaymentProvider) handlePaymentSucceeded(ctx context.Context, data
 		return fmt.Errorf("payment not found: %w", err)
 	}
 
+	alreadyConfirmed := paymentAlreadyConfirmed(payment.Status)
+
 	// Update payment status to completed
 	now := time.Now()
 	payment.Status = "completed"
@@ -201,6 +209,10 @@ func (u *stripePaymentProvider) handlePaymentSucceeded(ctx context.Context, data
 		return err
 	}
 
+	if !alreadyConfirmed {
+		addPaymentToInbox(ctx, u.inbox, payment)
+	}
+
 	user, err := u.adminUC.GetUserByID(ctx, payment.UserID)
 	if err != nil {
 		logger.Log.WithError(err).Errorf("Could not get user: %s", payment.UserID)
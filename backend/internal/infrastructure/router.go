@@ -10,6 +10,7 @@ import (
 	usecase "yefe_app/v1/internal/useCase"
 	service "yefe_app/v1/pkg/services"
 	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/tokens"
 	"yefe_app/v1/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
@@ -19,11 +20,12 @@ import (
 )
 
 type ServerConfig struct {
-	DB           *gorm.DB
-	JWT_SECRET   string
-	AllowedHosts string
-	EmailService domain.EmailService
-	FMCService   *fire_base.FCMNotificationService
+	DB             *gorm.DB
+	JWTKeys        *tokens.KeySet
+	AllowedHosts   string
+	ServerSettings utils.ServerSettings
+	EmailService   domain.EmailService
+	FMCService     *fire_base.FCMNotificationService
 
 	PaymentConfig     utils.PaymentConfig
 	UserRepo          domain.UserRepository
@@ -38,14 +40,76 @@ type ServerConfig struct {
 	AdminRepo         domain.AdminUserRepository
 	SongRepo          domain.SongRepository
 	PaymentRepo       domain.PaymentRepository
+	VerificationRepo  domain.EmailVerificationRepository
+	PasswordResetRepo domain.PasswordResetRepository
+	LoginAttemptRepo  domain.LoginAttemptRepository
+	MFARepo           domain.MFARepository
+	MFAChallengeRepo  domain.MFAChallengeRepository
+	IdentityRepo      domain.UserIdentityRepository
+	OAuthNonceRepo    domain.OAuthNonceRepository
+	ProfileRepo       domain.UserProfileRepository
+	AccountDeleteRepo domain.AccountDeletionRepository
+	CampaignRepo      domain.CampaignRepository
+	InboxRepo         domain.InboxRepository
+	AchievementRepo   domain.AchievementRepository
+	PointsRepo        domain.PointsRepository
+	LeaderboardRepo   domain.LeaderboardRepository
+	GroupRepo         domain.GroupRepository
+	OIDCVerifiers     map[string]domain.IDTokenVerifier
+	BlobStore         domain.BlobStore
+	StorageConfig     utils.StorageConfig
 }
 
 func (conf ServerConfig) auth_usecase() domain.AuthUseCase {
-	return usecase.NewAuthUseCase(conf.UserRepo, conf.SessionRepo, conf.SecEventRepo, conf.JWT_SECRET, conf.FMCService)
+	return usecase.NewAuthUseCase(conf.UserRepo, conf.SessionRepo, conf.SecEventRepo, conf.VerificationRepo, conf.PasswordResetRepo, conf.LoginAttemptRepo, conf.MFARepo, conf.MFAChallengeRepo, conf.IdentityRepo, conf.OAuthNonceRepo, conf.OIDCVerifiers, conf.EmailService, conf.JWTKeys, conf.FMCService, conf.ServerSettings)
+}
+
+func (conf ServerConfig) profile_usecase() domain.ProfileUseCase {
+	return usecase.NewProfileUseCase(conf.UserRepo, conf.ProfileRepo, conf.SecEventRepo, conf.BlobStore, conf.FMCService, conf.LeaderboardUsecase(), conf.StorageConfig)
+}
+
+func (conf ServerConfig) AccountUsecase() domain.AccountUseCase {
+	return usecase.NewAccountUseCase(conf.UserRepo, conf.SessionRepo, conf.SecEventRepo, conf.AccountDeleteRepo, conf.ProfileRepo, conf.BlobStore, conf.FMCService, conf.ServerSettings)
+}
+
+func (conf ServerConfig) device_usecase() domain.DeviceUseCase {
+	return usecase.NewDeviceUseCase(conf.ProfileRepo, conf.FMCService)
+}
+
+func (conf ServerConfig) CampaignUsecase() domain.CampaignUseCase {
+	return usecase.NewCampaignUseCase(conf.CampaignRepo, conf.InboxUsecase(), conf.FMCService)
+}
+
+func (conf ServerConfig) InboxUsecase() domain.InboxUseCase {
+	return usecase.NewInboxUseCase(conf.InboxRepo)
+}
+
+func (conf ServerConfig) achievement_usecase() domain.AchievementUseCase {
+	return usecase.NewAchievementUseCase(conf.AchievementRepo, conf.JournalRepo, conf.UserPuzzleRepo, conf.UserChallengeRepo, conf.InboxUsecase(), conf.points_usecase())
+}
+
+func (conf ServerConfig) points_usecase() domain.PointsUseCase {
+	return usecase.NewPointsUseCase(conf.PointsRepo, conf.LeaderboardUsecase())
+}
+
+func (conf ServerConfig) group_usecase() domain.GroupUseCase {
+	return usecase.NewGroupUseCase(conf.GroupRepo, conf.StatsRepo, conf.journal_usecase(), conf.ProfileRepo, conf.LeaderboardUsecase(), conf.BlobStore, conf.FMCService, conf.StorageConfig)
+}
+
+func (conf ServerConfig) LeaderboardUsecase() domain.LeaderboardUseCase {
+	return usecase.NewLeaderboardUseCase(conf.LeaderboardRepo, conf.PointsRepo, conf.ProfileRepo, conf.BlobStore, conf.StorageConfig)
+}
+
+func (conf ServerConfig) ReminderContentUsecase() fire_base.ReminderContent {
+	return usecase.NewReminderContentUseCase(conf.ProfileRepo, conf.ChallengeRepo, conf.UserChallengeRepo, conf.UserPuzzleRepo, conf.JournalRepo)
+}
+
+func (conf ServerConfig) notification_admin_usecase() domain.NotificationAdminUseCase {
+	return usecase.NewNotificationAdminUseCase(conf.FMCService)
 }
 
 func (conf ServerConfig) AdminUserUsecase() domain.AdminUserUseCase {
-	return usecase.NewAdminUserUseCase(conf.AdminRepo, conf.UserRepo, conf.EmailService)
+	return usecase.NewAdminUserUseCase(conf.AdminRepo, conf.UserRepo, conf.SessionRepo, conf.SecEventRepo, conf.LoginAttemptRepo, conf.EmailService)
 }
 
 func (conf ServerConfig) payment_usercase() domain.PaymentUseCase {
@@ -53,11 +117,15 @@ func (conf ServerConfig) payment_usercase() domain.PaymentUseCase {
 }
 
 func (conf ServerConfig) journal_usecase() domain.JournalUseCase {
-	return usecase.NewJournalUseCase(conf.JournalRepo, conf.UserRepo)
+	return usecase.NewJournalUseCase(conf.JournalRepo, conf.UserRepo, conf.achievement_usecase(), conf.points_usecase())
+}
+
+func (conf ServerConfig) puzzle_admin_usecase() domain.PuzzleAdminUseCase {
+	return usecase.NewPuzzleAdminUseCase(conf.PuzzleRepo)
 }
 
 func (conf ServerConfig) puzzle_usecase() domain.PuzzleUseCase {
-	return usecase.NewPuzzleUseCase(conf.PuzzleRepo, conf.UserPuzzleRepo)
+	return usecase.NewPuzzleUseCase(conf.PuzzleRepo, conf.UserPuzzleRepo, conf.achievement_usecase(), conf.points_usecase())
 }
 func (conf ServerConfig) song_usecase() domain.SongUseCase {
 	return usecase.NewMusicUseCase(conf.SongRepo)
@@ -67,21 +135,21 @@ func (conf ServerConfig) user_activity_usecase() domain.UserActivityUsecase {
 	return usecase.NewUserActivityUsecase(conf.SecEventRepo)
 }
 func (conf ServerConfig) challenges_usecase() domain.ChallengeUseCase {
-	return usecase.NewChallengeUseCase(conf.ChallengeRepo, conf.UserChallengeRepo, conf.StatsRepo)
+	return usecase.NewChallengeUseCase(conf.ChallengeRepo, conf.UserChallengeRepo, conf.StatsRepo, conf.achievement_usecase(), conf.points_usecase())
 }
 func (conf ServerConfig) dashboard_usecase() domain.DashboardUsecase {
 	return usecase.NewDashboardUsecase(conf.AdminUserUsecase(), conf.user_activity_usecase())
 }
 func (conf ServerConfig) auth_middleware() *middlewares.AuthMiddleware {
-	return middlewares.NewAuthMiddleware(conf.JWT_SECRET, conf.SessionRepo, conf.UserRepo, conf.SecEventRepo)
+	return middlewares.NewAuthMiddleware(conf.JWTKeys, conf.SessionRepo, conf.UserRepo, conf.SecEventRepo, conf.ServerSettings.RequireVerifiedEmailForPaid)
 }
 func (conf ServerConfig) paystack_payemnt() domain.PaymentProvider {
 	paystackClient := service.NewpaystackClient(conf.PaymentConfig.PaystackPrivateKey)
-	return payments.NewPaystackPaymentProvider(conf.PaymentRepo, conf.EmailService, conf.AdminUserUsecase(), paystackClient, conf.SecEventRepo, conf.PaymentConfig)
+	return payments.NewPaystackPaymentProvider(conf.PaymentRepo, conf.EmailService, conf.AdminUserUsecase(), paystackClient, conf.SecEventRepo, conf.InboxUsecase(), conf.PaymentConfig)
 }
 
 func (conf ServerConfig) stripe_payemnt() domain.PaymentProvider {
-	return payments.NewStripePaymentProvider(conf.PaymentRepo, conf.AdminUserUsecase(), conf.PaymentConfig, conf.EmailService, conf.SecEventRepo)
+	return payments.NewStripePaymentProvider(conf.PaymentRepo, conf.AdminUserUsecase(), conf.PaymentConfig, conf.EmailService, conf.SecEventRepo, conf.InboxUsecase())
 }
 
 func (conf ServerConfig) getAllowedDomains() []string {
@@ -96,7 +164,8 @@ func NewRouter(config ServerConfig) http.Handler {
 	auth_handlers := handlers.NewAuthHandler(config.auth_usecase())
 	journal_handlers := handlers.NewJournalHandler(config.journal_usecase())
 	puzzle_handler := handlers.NewPuzzleHandler(config.puzzle_usecase())
-	challenges_handler := handlers.NewChallengesHandler(config.challenges_usecase())
+	admin_puzzle_handler := handlers.NewAdminPuzzleHandler(config.puzzle_admin_usecase())
+	challenges_handler := handlers.NewChallengesHandler(config.challenges_usecase(), config.LeaderboardUsecase())
 	admin_user_handelrs := handlers.NewAdminUserHandler(config.AdminUserUsecase())
 	song_handler := handlers.NewMusicHandler(config.song_usecase())
 	payments_handler := handlers.NewPaymentHandler(config.payment_usercase(), map[string]domain.PaymentProvider{
@@ -105,28 +174,61 @@ func NewRouter(config ServerConfig) http.Handler {
 	})
 	user_activity_handler := handlers.NewUserEventsHandler(config.user_activity_usecase())
 	dashboard_handler := handlers.NewDashboardHandler(config.dashboard_usecase())
+	jwks_handler := handlers.NewJWKSHandler(config.JWTKeys)
+	me_handler := handlers.NewMeHandler(config.profile_usecase(), config.AccountUsecase(), config.device_usecase(), config.achievement_usecase())
+	admin_notification_handler := handlers.NewAdminNotificationHandler(config.notification_admin_usecase())
+	admin_campaign_handler := handlers.NewAdminCampaignHandler(config.CampaignUsecase())
+	inbox_handler := handlers.NewInboxHandler(config.InboxUsecase())
+	achievement_handler := handlers.NewAchievementHandler(config.achievement_usecase())
+	admin_achievement_handler := handlers.NewAdminAchievementHandler(config.achievement_usecase())
+	points_handler := handlers.NewPointsHandler(config.points_usecase())
+	admin_points_handler := handlers.NewAdminPointsHandler(config.points_usecase())
+	group_handler := handlers.NewGroupHandler(config.group_usecase())
+	admin_group_handler := handlers.NewAdminGroupHandler(config.group_usecase())
 
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
 	r.Use(cors.New(cors.Options{
 		AllowedOrigins:   config.getAllowedDomains(),
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Payment-Provider"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}).Handler)
 
+	// Public keys for services verifying our access tokens
+	r.Get("/.well-known/jwks.json", jwks_handler.JWKSRoute)
+
+	// Signed links to uploaded files, when the store does not serve them itself
+	if files, ok := config.BlobStore.(http.Handler); ok {
+		r.Handle("/files/*", http.StripPrefix("/files/", files))
+	}
+
 	r.Route("/v1", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			r.Use(config.auth_middleware().RequireAuth)
 			r.Post("/auth/logout", auth_handlers.LogoutRoute)
 			r.Post("/auth/accept", auth_handlers.AcceptNotifications)
+			r.Post("/auth/verify-email/resend", auth_handlers.ResendVerificationRoute)
+			r.Post("/auth/change-password", auth_handlers.ChangePasswordRoute)
+			r.Post("/auth/mfa/setup", auth_handlers.SetupMFARoute)
+			r.Post("/auth/mfa/enable", auth_handlers.EnableMFARoute)
+			r.Post("/auth/mfa/disable", auth_handlers.DisableMFARoute)
+			r.Post("/auth/logout-all", auth_handlers.LogoutAllRoute)
+			r.Get("/auth/sessions", auth_handlers.ListSessionsRoute)
+			r.Delete("/auth/sessions", auth_handlers.RevokeOtherSessionsRoute)
+			r.Delete("/auth/sessions/{sessionID}", auth_handlers.RevokeSessionRoute)
+			r.Mount("/me", me_handler.Handle())
 			r.Mount("/journal", journal_handlers.Handle())
 			r.Mount("/puzzle", puzzle_handler.Handle())
 			r.Mount("/challenges", challenges_handler.Handle())
 			r.Mount("/songs", song_handler.Handle())
-			r.Mount("/payments", payments_handler.Handle())
+			r.Mount("/notifications", inbox_handler.Handle())
+			r.Mount("/achievements", achievement_handler.Handle())
+			r.Mount("/points", points_handler.Handle())
+			r.Mount("/groups", group_handler.Handle())
+			r.With(config.auth_middleware().RequireVerifiedEmail).Mount("/payments", payments_handler.Handle())
 		})
 
 		r.Group(func(r chi.Router) {
@@ -135,12 +237,26 @@ func NewRouter(config ServerConfig) http.Handler {
 			r.Post("/payments/upgrade", payments_handler.UpgradePackage)
 			r.Mount("/events", user_activity_handler.Handle())
 			r.Mount("/admin", admin_user_handelrs.Handle())
+			r.Mount("/admin/notifications", admin_notification_handler.Handle())
+			r.Mount("/admin/campaigns", admin_campaign_handler.Handle())
+			r.Mount("/admin/achievements", admin_achievement_handler.Handle())
+			r.Mount("/admin/points", admin_points_handler.Handle())
+			r.Mount("/admin/groups", admin_group_handler.Handle())
+			r.Mount("/admin/puzzles", admin_puzzle_handler.Handle())
 			r.Mount("/dashboard", dashboard_handler.Handle())
 		})
 
 		// auth routes
 		r.Post("/auth/login", auth_handlers.LoginRoute)
 		r.Post("/auth/register", auth_handlers.RegisterRoute)
+		r.Post("/auth/oauth/nonce", auth_handlers.OAuthNonceRoute)
+		r.Post("/auth/oauth/{provider}", auth_handlers.OAuthLoginRoute)
+		r.Post("/auth/refresh", auth_handlers.RefreshRoute)
+		r.Post("/auth/mfa/verify", auth_handlers.VerifyMFARoute)
+		r.Get("/auth/verify-email", auth_handlers.VerifyEmailRoute)
+		r.Post("/auth/forgot-password", auth_handlers.ForgotPasswordRoute)
+		r.Get("/auth/reset-password", auth_handlers.ResetPasswordPageRoute)
+		r.Post("/auth/reset-password", auth_handlers.ResetPasswordRoute)
 		r.Post("/accept-invitation", admin_user_handelrs.AcceptInvitation)
 		r.Post("/webhooks/stripe", payments_handler.StripeWebhook)
 		r.Post("/webhooks/paystack", payments_handler.PaystackWebhook)
@@ -0,0 +1,181 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fakes implement only what resetting a password needs, any other call
+// panics on the nil interface they embed
+
+type fakeUserRepo struct {
+	domain.UserRepository
+	user        domain.User
+	newPassword string
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if email != r.user.Email {
+		return nil, domain.ErrUserNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	if id != r.user.ID {
+		return nil, domain.ErrUserNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(ctx context.Context, userID, passwordHash, salt string) error {
+	r.newPassword = passwordHash
+	return nil
+}
+
+type fakeResetRepo struct {
+	domain.PasswordResetRepository
+	tokens map[string]*domain.PasswordResetToken
+}
+
+func (r *fakeResetRepo) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.tokens[token.Token] = token
+	return nil
+}
+
+func (r *fakeResetRepo) GetByToken(ctx context.Context, token string) (*domain.PasswordResetToken, error) {
+	found, ok := r.tokens[token]
+	if !ok {
+		return nil, domain.ErrInvalidToken
+	}
+	return found, nil
+}
+
+func (r *fakeResetRepo) MarkUsed(ctx context.Context, id string) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.Used = true
+		}
+	}
+	return nil
+}
+
+func (r *fakeResetRepo) InvalidateByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (r *fakeResetRepo) CountSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeSessionRepo struct {
+	domain.SessionRepository
+}
+
+func (r *fakeSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+type fakeSecurityEventRepo struct {
+	domain.SecurityEventRepository
+}
+
+func (r *fakeSecurityEventRepo) LogSecurityEvent(ctx context.Context, userID string, eventType types.SecurityEventType, ipAddress, userAgent string, details types.JSONMap) error {
+	return nil
+}
+
+type fakeEmailService struct {
+	domain.EmailService
+	resetLinks []string
+}
+
+func (s *fakeEmailService) SendPasswordReset(ctx context.Context, req dto.PasswordResetEmailData) error {
+	s.resetLinks = append(s.resetLinks, req.ResetLink)
+	return nil
+}
+
+func TestPasswordResetLinkOpensResetForm(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	logger.Log = log
+
+	users := &fakeUserRepo{user: domain.User{ID: "user-1", Email: "user@example.com", IsActive: true}}
+	emails := &fakeEmailService{}
+	// The public URL is only known once the server listens
+	var router http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	router = NewRouter(ServerConfig{
+		ServerSettings:    utils.ServerSettings{DevURl: server.URL},
+		UserRepo:          users,
+		SessionRepo:       &fakeSessionRepo{},
+		SecEventRepo:      &fakeSecurityEventRepo{},
+		PasswordResetRepo: &fakeResetRepo{tokens: map[string]*domain.PasswordResetToken{}},
+		EmailService:      emails,
+	})
+
+	resp, err := http.Post(server.URL+"/v1/auth/forgot-password", "application/json", strings.NewReader(`{"email": "user@example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(emails.resetLinks) != 1 {
+		t.Fatalf("sent %d reset emails, want 1", len(emails.resetLinks))
+	}
+
+	// Open the link from the email
+	link := emails.resetLinks[0]
+	resp, err = http.Get(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("reset link returned %d %s, want an HTML page", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := parsed.Query().Get("token")
+	if !strings.Contains(string(page), `value="`+token+`"`) {
+		t.Error("reset form does not carry the token of the link")
+	}
+
+	// Submit the form the way the page does
+	body, err := json.Marshal(map[string]string{"token": token, "new_password": "N3w-Passw0rd!", "confirm_password": "N3w-Passw0rd!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post(server.URL+parsed.Path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || users.newPassword == "" {
+		t.Errorf("submitting the reset form returned %d, want the password reset", resp.StatusCode)
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type accountDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountDeletionRepository creates a repository that purges deleted accounts
+func NewAccountDeletionRepository(db *gorm.DB) domain.AccountDeletionRepository {
+	return &accountDeletionRepository{db: db}
+}
+
+// userOwnedModels are removed outright when an account is purged
+var userOwnedModels = []any{
+	&models.JournalEntry{},
+	&models.UserPuzzleProgress{},
+	&models.UserChallenge{},
+	&models.ChallengeStats{},
+	&models.UserAchievement{},
+	&models.InboxNotification{},
+	&models.PointsEntry{},
+	&models.UserProfile{},
+	&models.EmailVerificationToken{},
+	&models.PasswordResetToken{},
+	&models.UserMFA{},
+	&models.UserIdentity{},
+	&models.Session{},
+}
+
+// Purge erases the user's data in a single transaction
+func (r *accountDeletionRepository) Purge(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Groups the user owned pass to another member first
+		if err := removeUserFromGroups(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Where("sender_id = ? OR recipient_id = ?", userID, userID).Delete(&models.GroupNudge{}).Error; err != nil {
+			return fmt.Errorf("failed to purge group nudges: %w", err)
+		}
+
+		for _, model := range userOwnedModels {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to purge %T: %w", model, err)
+			}
+		}
+
+		// Payments are kept for accounting and app store records but can no
+		// longer be tied back to the user
+		if err := tx.Model(&models.Payment{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"user_id":        "deleted-" + utils.GenerateID(),
+				"payment_method": "",
+				"updated_at":     time.Now().UTC(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to anonymise payments: %w", err)
+		}
+
+		// The audit trail stays but without client details
+		if err := tx.Model(&models.SecurityEvent{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"ip_address": "",
+				"user_agent": "",
+				"location":   "",
+			}).Error; err != nil {
+			return fmt.Errorf("failed to scrub security events: %w", err)
+		}
+		// Profile changes are recorded with their old and new values
+		if err := tx.Model(&models.SecurityEvent{}).
+			Where("user_id = ? AND event_type = ?", userID, types.EventProfileUpdated).
+			Update("details", types.JSONMap{}).Error; err != nil {
+			return fmt.Errorf("failed to scrub security events: %w", err)
+		}
+
+		// The row itself is kept, soft deleted, so audit events still reference it
+		result := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"email":                 fmt.Sprintf("deleted-%s@deleted.invalid", userID),
+				"name":                  "Deleted user",
+				"password_hash":         "",
+				"salt":                  "",
+				"is_active":             false,
+				"last_login_ip":         "",
+				"deletion_scheduled_at": nil,
+				"updated_at":            time.Now().UTC(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to scrub user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		if err := tx.Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type achievementRepository struct {
+	db *gorm.DB
+}
+
+// NewAchievementRepository creates a new achievement repository
+func NewAchievementRepository(db *gorm.DB) domain.AchievementRepository {
+	return &achievementRepository{db: db}
+}
+
+// The criteria are kept as a JSON string in the achievements table
+func achievementToModel(achievement domain.Achievement) (models.Achievement, error) {
+	criteria, err := json.Marshal(achievement.Criteria)
+	if err != nil {
+		return models.Achievement{}, err
+	}
+	return models.Achievement{
+		ID:          achievement.ID,
+		Name:        achievement.Name,
+		Description: achievement.Description,
+		IconName:    achievement.IconName,
+		BadgeColor:  achievement.BadgeColor,
+		Points:      achievement.Points,
+		Criteria:    string(criteria),
+		IsActive:    achievement.IsActive,
+		SortOrder:   achievement.SortOrder,
+	}, nil
+}
+
+func achievementFromModel(dbAchievement models.Achievement) (domain.Achievement, error) {
+	achievement := domain.Achievement{
+		ID:          dbAchievement.ID,
+		Name:        dbAchievement.Name,
+		Description: dbAchievement.Description,
+		IconName:    dbAchievement.IconName,
+		BadgeColor:  dbAchievement.BadgeColor,
+		Points:      dbAchievement.Points,
+		IsActive:    dbAchievement.IsActive,
+		SortOrder:   dbAchievement.SortOrder,
+		CreatedAt:   dbAchievement.CreatedAt,
+		UpdatedAt:   dbAchievement.UpdatedAt,
+	}
+	if dbAchievement.Criteria != "" {
+		if err := json.Unmarshal([]byte(dbAchievement.Criteria), &achievement.Criteria); err != nil {
+			return domain.Achievement{}, err
+		}
+	}
+	return achievement, nil
+}
+
+func (r *achievementRepository) Create(ctx context.Context, achievement *domain.Achievement) error {
+	dbAchievement, err := achievementToModel(*achievement)
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&dbAchievement).Error; err != nil {
+		return err
+	}
+	*achievement, err = achievementFromModel(dbAchievement)
+	return err
+}
+
+func (r *achievementRepository) Update(ctx context.Context, achievement *domain.Achievement) error {
+	dbAchievement, err := achievementToModel(*achievement)
+	if err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&models.Achievement{ID: achievement.ID}).
+		Select("name", "description", "icon_name", "badge_color", "points", "criteria", "is_active", "sort_order").
+		Updates(&dbAchievement)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (r *achievementRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Achievement{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (r *achievementRepository) GetByID(ctx context.Context, id string) (*domain.Achievement, error) {
+	var dbAchievement models.Achievement
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&dbAchievement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	achievement, err := achievementFromModel(dbAchievement)
+	if err != nil {
+		return nil, err
+	}
+	return &achievement, nil
+}
+
+func (r *achievementRepository) List(ctx context.Context, activeOnly bool) ([]domain.Achievement, error) {
+	query := r.db.WithContext(ctx).Order("sort_order, created_at")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var dbAchievements []models.Achievement
+	if err := query.Find(&dbAchievements).Error; err != nil {
+		return nil, err
+	}
+
+	achievements := make([]domain.Achievement, 0, len(dbAchievements))
+	for _, dbAchievement := range dbAchievements {
+		achievement, err := achievementFromModel(dbAchievement)
+		if err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, achievement)
+	}
+	return achievements, nil
+}
+
+func (r *achievementRepository) Award(ctx context.Context, userID, achievementID string, earnedAt time.Time) (bool, error) {
+	userAchievement := models.UserAchievement{
+		ID:            utils.GenerateID(),
+		UserID:        userID,
+		AchievementID: achievementID,
+		EarnedAt:      earnedAt,
+	}
+	// The unique index on user and achievement keeps awards idempotent, also
+	// when two events of the same user are evaluated at once
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "achievement_id"}},
+			DoNothing: true,
+		}).
+		Omit(clause.Associations).
+		Create(&userAchievement)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *achievementRepository) GetUserAchievements(ctx context.Context, userID string) ([]domain.UserAchievement, error) {
+	var dbUserAchievements []models.UserAchievement
+	err := r.db.WithContext(ctx).
+		InnerJoins("Achievement").
+		Where("user_achievements.user_id = ?", userID).
+		Order("user_achievements.earned_at DESC").
+		Find(&dbUserAchievements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	userAchievements := make([]domain.UserAchievement, 0, len(dbUserAchievements))
+	for _, dbUserAchievement := range dbUserAchievements {
+		achievement, err := achievementFromModel(dbUserAchievement.Achievement)
+		if err != nil {
+			return nil, err
+		}
+		userAchievements = append(userAchievements, domain.UserAchievement{
+			Achievement: achievement,
+			EarnedAt:    dbUserAchievement.EarnedAt,
+		})
+	}
+	return userAchievements, nil
+}
+
+func (r *achievementRepository) CompletedChallengeTypes(ctx context.Context, userID string) ([]string, error) {
+	var types []string
+	err := r.db.WithContext(ctx).Model(&models.UserChallenge{}).
+		Joins("JOIN challenges ON challenges.id = user_challenges.challenge_id").
+		Where("user_challenges.user_id = ? AND user_challenges.status = ?", userID, models.StatusCompleted).
+		Distinct("challenges.type").
+		Pluck("challenges.type", &types).Error
+	return types, err
+}
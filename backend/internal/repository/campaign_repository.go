@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type campaignRepository struct {
+	db *gorm.DB
+}
+
+// NewCampaignRepository creates a new push notification campaign repository
+func NewCampaignRepository(db *gorm.DB) domain.CampaignRepository {
+	return &campaignRepository{db: db}
+}
+
+func (r *campaignRepository) Create(ctx context.Context, campaign *domain.Campaign) error {
+	var dbCampaign models.Campaign
+	if err := utils.TypeConverter(campaign, &dbCampaign); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&dbCampaign).Error; err != nil {
+		return err
+	}
+	return utils.TypeConverter(dbCampaign, campaign)
+}
+
+func (r *campaignRepository) GetByID(ctx context.Context, id string) (*domain.Campaign, error) {
+	var dbCampaign models.Campaign
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&dbCampaign).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var campaign domain.Campaign
+	if err := utils.TypeConverter(dbCampaign, &campaign); err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *campaignRepository) List(ctx context.Context, status string, limit, offset int) ([]domain.Campaign, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Campaign{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dbCampaigns []models.Campaign
+	if err := query.Order("scheduled_at DESC").Limit(limit).Offset(offset).Find(&dbCampaigns).Error; err != nil {
+		return nil, 0, err
+	}
+
+	campaigns := []domain.Campaign{}
+	if err := utils.TypeConverter(dbCampaigns, &campaigns); err != nil {
+		return nil, 0, err
+	}
+	return campaigns, total, nil
+}
+
+func (r *campaignRepository) GetByStatus(ctx context.Context, status string) ([]domain.Campaign, error) {
+	var dbCampaigns []models.Campaign
+	if err := r.db.WithContext(ctx).Where("status = ?", status).Order("scheduled_at").Find(&dbCampaigns).Error; err != nil {
+		return nil, err
+	}
+
+	var campaigns []domain.Campaign
+	if err := utils.TypeConverter(dbCampaigns, &campaigns); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+func (r *campaignRepository) UpdateStatus(ctx context.Context, id, from, to string, updates map[string]any) error {
+	values := map[string]any{"status": to}
+	for column, value := range updates {
+		values[column] = value
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.Campaign{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+func (r *campaignRepository) Update(ctx context.Context, id string, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&models.Campaign{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *campaignRepository) AudienceUserIDs(ctx context.Context, segment domain.CampaignSegment) ([]string, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{}).
+		Joins("JOIN user_profiles ON user_profiles.user_id = users.id").
+		Where("users.is_active = ? AND users.role = ? AND users.deletion_scheduled_at IS NULL", true, "user")
+
+	if segment.PlanType != "" {
+		query = query.Where("users.plan_type = ?", segment.PlanType)
+	}
+	if segment.Language != "" {
+		query = query.Where("user_profiles.notification_language = ?", segment.Language)
+	}
+	if segment.InactiveDays > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -segment.InactiveDays)
+		query = query.Where("COALESCE(users.last_login_at, users.created_at) < ?", cutoff)
+	}
+
+	var userIDs []string
+	if err := query.Pluck("users.id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
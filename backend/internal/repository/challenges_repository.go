@@ -331,43 +331,30 @@ func (r *ChallengeStatsRepositoryImpl) GetUserStats(userID string) (domain.Chall
 	if err != nil {
 		return domain.ChallengeStats{}, err
 	}
+
+	err = r.db.Model(&models.PointsEntry{}).
+		Select("COALESCE(SUM(points), 0)").
+		Where("user_id = ?", userID).
+		Scan(&stats.TotalPoints).Error
+	if err != nil {
+		return domain.ChallengeStats{}, err
+	}
 	return stats, nil
 }
 
-// UpdateUserStats updates user statistics
-func (r *ChallengeStatsRepositoryImpl) UpdateUserStats(userId string, points int) error {
+// UpdateUserStats updates user statistics, the points of a challenge go to the
+// points ledger
+func (r *ChallengeStatsRepositoryImpl) UpdateUserStats(userId string) error {
 
 	stats, err := r.GetUserStats(userId)
 	if err != nil {
 		return err
 	}
-	stats.TotalPoints += points
 	stats.TotalChallenges += 1
 
-	if err := r.db.Model(stats).Where("user_id = ?", stats.UserID).Updates(stats).Error; err != nil {
+	if err := r.db.Model(stats).Where("user_id = ?", stats.UserID).Omit("total_points").Updates(stats).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
-
-// GetLeaderboard retrieves the leaderboard
-func (r *ChallengeStatsRepositoryImpl) GetLeaderboard(limit int) ([]domain.ChallengeStats, error) {
-	var dbstats []models.ChallengeStats
-	var stats []domain.ChallengeStats
-	query := r.db.Preload("User").Order("total_points DESC, current_streak DESC")
-
-	if limit > 0 {
-		query = query.Limit(limit)
-	}
-
-	if err := query.Find(&dbstats).Error; err != nil {
-		return nil, err
-	}
-	err := utils.TypeConverter(dbstats, &stats)
-	if err != nil {
-		return nil, err
-	}
-	return stats, nil
-
-}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository creates a new email verification token repository
+func NewEmailVerificationRepository(db *gorm.DB) domain.EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+// Create stores a new verification token
+func (r *emailVerificationRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	var dbToken models.EmailVerificationToken
+	if err := utils.TypeConverter(token, &dbToken); err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Create(&dbToken).Error; err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+	return nil
+}
+
+// GetByToken retrieves a verification token by its hashed value
+func (r *emailVerificationRepository) GetByToken(ctx context.Context, token string) (*domain.EmailVerificationToken, error) {
+	var dbToken models.EmailVerificationToken
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&dbToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+
+	var verificationToken domain.EmailVerificationToken
+	if err := utils.TypeConverter(dbToken, &verificationToken); err != nil {
+		return nil, err
+	}
+	return &verificationToken, nil
+}
+
+// MarkUsed flags a token as consumed. It fails if the token was already used.
+func (r *emailVerificationRepository) MarkUsed(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark email verification token as used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidToken
+	}
+	return nil
+}
+
+// GetLatestByUserID returns the most recently issued token for a user
+func (r *emailVerificationRepository) GetLatestByUserID(ctx context.Context, userID string) (*domain.EmailVerificationToken, error) {
+	var dbToken models.EmailVerificationToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&dbToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest email verification token: %w", err)
+	}
+
+	var verificationToken domain.EmailVerificationToken
+	if err := utils.TypeConverter(dbToken, &verificationToken); err != nil {
+		return nil, err
+	}
+	return &verificationToken, nil
+}
+
+// CountSince counts the tokens issued to a user since the given time
+func (r *emailVerificationRepository) CountSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count email verification tokens: %w", err)
+	}
+	return count, nil
+}
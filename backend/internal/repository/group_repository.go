@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeMembersJoin keeps the memberships of active users, members whose
+// account was suspended are left out until it is restored
+const activeMembersJoin = "JOIN users ON users.id = group_members.user_id AND users.is_active = ? AND users.deleted_at IS NULL"
+
+type groupRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupRepository creates a new accountability group repository
+func NewGroupRepository(db *gorm.DB) domain.GroupRepository {
+	return &groupRepository{db: db}
+}
+
+// groupRow is a group with the number of its active members
+type groupRow struct {
+	models.Group
+	MemberCount int
+}
+
+func groupFromRow(row groupRow) domain.Group {
+	return domain.Group{
+		ID:          row.ID,
+		Name:        row.Name,
+		Description: row.Description,
+		InviteCode:  row.InviteCode,
+		OwnerID:     row.OwnerID,
+		MemberCount: row.MemberCount,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// groups selects groups with their member count
+func (r *groupRepository) groups(ctx context.Context) *gorm.DB {
+	memberCount := r.db.Model(&models.GroupMember{}).
+		Select("COUNT(*)").
+		Joins(activeMembersJoin, true).
+		Where("group_members.group_id = groups.id")
+	return r.db.WithContext(ctx).Model(&models.Group{}).
+		Select("groups.*, (?) AS member_count", memberCount)
+}
+
+func (r *groupRepository) first(query *gorm.DB) (*domain.Group, error) {
+	var rows []groupRow
+	if err := query.Limit(1).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, domain.ErrResourceNotFound
+	}
+	group := groupFromRow(rows[0])
+	return &group, nil
+}
+
+func (r *groupRepository) Create(ctx context.Context, group *domain.Group) error {
+	dbGroup := models.Group{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		InviteCode:  group.InviteCode,
+		OwnerID:     group.OwnerID,
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&dbGroup).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.GroupMember{
+			ID:       utils.GenerateID(),
+			GroupID:  dbGroup.ID,
+			UserID:   dbGroup.OwnerID,
+			Role:     domain.GroupRoleOwner,
+			JoinedAt: dbGroup.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	*group = groupFromRow(groupRow{Group: dbGroup, MemberCount: 1})
+	return nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id string) (*domain.Group, error) {
+	return r.first(r.groups(ctx).Where("groups.id = ?", id))
+}
+
+func (r *groupRepository) GetByInviteCode(ctx context.Context, code string) (*domain.Group, error) {
+	return r.first(r.groups(ctx).Where("groups.invite_code = ?", code))
+}
+
+func (r *groupRepository) ListByUser(ctx context.Context, userID string) ([]domain.Group, error) {
+	var rows []groupRow
+	err := r.groups(ctx).
+		Joins("JOIN group_members ON group_members.group_id = groups.id").
+		Where("group_members.user_id = ?", userID).
+		Order("group_members.joined_at").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	groups := make([]domain.Group, len(rows))
+	for i, row := range rows {
+		groups[i] = groupFromRow(row)
+	}
+	return groups, nil
+}
+
+func (r *groupRepository) List(ctx context.Context, search string, limit, offset int) ([]domain.Group, int64, error) {
+	search = strings.ToLower(strings.TrimSpace(search))
+	matching := func(db *gorm.DB) *gorm.DB {
+		if search == "" {
+			return db
+		}
+		return db.Where("LOWER(groups.name) LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Group{}).Scopes(matching).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []groupRow
+	err := r.groups(ctx).Scopes(matching).
+		Order("groups.created_at DESC, groups.id").Limit(limit).Offset(offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	groups := make([]domain.Group, len(rows))
+	for i, row := range rows {
+		groups[i] = groupFromRow(row)
+	}
+	return groups, total, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, id string, updates map[string]any) error {
+	result := r.db.WithContext(ctx).Model(&models.Group{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteGroup(tx, id)
+	})
+}
+
+// deleteGroup removes a group with its members and nudges
+func deleteGroup(tx *gorm.DB, id string) error {
+	if err := tx.Where("group_id = ?", id).Delete(&models.GroupNudge{}).Error; err != nil {
+		return fmt.Errorf("failed to delete group nudges: %w", err)
+	}
+	if err := tx.Where("group_id = ?", id).Delete(&models.GroupMember{}).Error; err != nil {
+		return fmt.Errorf("failed to delete group members: %w", err)
+	}
+	result := tx.Where("id = ?", id).Delete(&models.Group{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete group: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (r *groupRepository) AddMember(ctx context.Context, member domain.GroupMember, maxMembers int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the group lets one join at a time count the members
+		var group models.Group
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", member.GroupID).First(&group).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrResourceNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get group: %w", err)
+		}
+
+		var existing int64
+		if err := tx.Model(&models.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", member.GroupID, member.UserID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return domain.ErrAlreadyGroupMember
+		}
+
+		var members int64
+		if err := tx.Model(&models.GroupMember{}).
+			Joins(activeMembersJoin, true).
+			Where("group_members.group_id = ?", member.GroupID).
+			Count(&members).Error; err != nil {
+			return err
+		}
+		if members >= int64(maxMembers) {
+			return domain.ErrGroupFull
+		}
+
+		return tx.Create(&models.GroupMember{
+			ID:       utils.GenerateID(),
+			GroupID:  member.GroupID,
+			UserID:   member.UserID,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt,
+		}).Error
+	})
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return removeGroupMember(tx, groupID, userID)
+	})
+}
+
+// removeGroupMember takes a user out of a group, handing a group its owner
+// leaves to the active member who joined first and deleting it once no
+// active member is left
+func removeGroupMember(tx *gorm.DB, groupID, userID string) error {
+	result := tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&models.GroupMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove group member: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+
+	var group models.Group
+	if err := tx.Where("id = ?", groupID).First(&group).Error; err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+	if group.OwnerID != userID {
+		return nil
+	}
+
+	var next []models.GroupMember
+	err := tx.Joins(activeMembersJoin, true).
+		Where("group_members.group_id = ?", groupID).
+		Order("group_members.joined_at, group_members.id").
+		Limit(1).Find(&next).Error
+	if err != nil {
+		return fmt.Errorf("failed to get group members: %w", err)
+	}
+	if len(next) == 0 {
+		return deleteGroup(tx, groupID)
+	}
+
+	if err := tx.Model(&models.GroupMember{}).Where("id = ?", next[0].ID).Update("role", domain.GroupRoleOwner).Error; err != nil {
+		return fmt.Errorf("failed to promote group member: %w", err)
+	}
+	if err := tx.Model(&models.Group{}).Where("id = ?", groupID).Update("owner_id", next[0].UserID).Error; err != nil {
+		return fmt.Errorf("failed to change group owner: %w", err)
+	}
+	return nil
+}
+
+// removeUserFromGroups takes a user out of every group
+func removeUserFromGroups(tx *gorm.DB, userID string) error {
+	var groupIDs []string
+	if err := tx.Model(&models.GroupMember{}).Where("user_id = ?", userID).Pluck("group_id", &groupIDs).Error; err != nil {
+		return fmt.Errorf("failed to list group memberships: %w", err)
+	}
+	for _, groupID := range groupIDs {
+		if err := removeGroupMember(tx, groupID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *groupRepository) GetMember(ctx context.Context, groupID, userID string) (*domain.GroupMember, error) {
+	var rows []models.GroupMember
+	err := r.db.WithContext(ctx).
+		Joins(activeMembersJoin, true).
+		Where("group_members.group_id = ? AND group_members.user_id = ?", groupID, userID).
+		Limit(1).Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group member: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, domain.ErrResourceNotFound
+	}
+	return groupMemberFromModel(rows[0]), nil
+}
+
+func groupMemberFromModel(row models.GroupMember) *domain.GroupMember {
+	return &domain.GroupMember{
+		GroupID:  row.GroupID,
+		UserID:   row.UserID,
+		Role:     row.Role,
+		JoinedAt: row.JoinedAt,
+	}
+}
+
+func (r *groupRepository) ListMembers(ctx context.Context, groupID string) ([]domain.GroupMember, error) {
+	var rows []models.GroupMember
+	err := r.db.WithContext(ctx).
+		Joins(activeMembersJoin, true).
+		Where("group_members.group_id = ?", groupID).
+		Order("group_members.joined_at, group_members.id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+
+	members := make([]domain.GroupMember, len(rows))
+	for i, row := range rows {
+		members[i] = *groupMemberFromModel(row)
+	}
+	return members, nil
+}
+
+func (r *groupRepository) CountUserGroups(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GroupMember{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *groupRepository) AddNudge(ctx context.Context, nudge domain.GroupNudge, since time.Time) error {
+	if nudge.ID == "" {
+		nudge.ID = utils.GenerateID()
+	}
+	if nudge.CreatedAt.IsZero() {
+		nudge.CreatedAt = time.Now().UTC()
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the sender's membership lets one nudge of theirs at a time
+		// count the nudges already sent
+		var sender models.GroupMember
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ? AND user_id = ?", nudge.GroupID, nudge.SenderID).
+			First(&sender).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrResourceNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get group member: %w", err)
+		}
+
+		var sent int64
+		if err := tx.Model(&models.GroupNudge{}).
+			Where("group_id = ? AND sender_id = ? AND recipient_id = ? AND created_at >= ?", nudge.GroupID, nudge.SenderID, nudge.RecipientID, since).
+			Count(&sent).Error; err != nil {
+			return err
+		}
+		if sent > 0 {
+			return domain.ErrRateLimitExceeded
+		}
+
+		return tx.Create(&models.GroupNudge{
+			ID:          nudge.ID,
+			GroupID:     nudge.GroupID,
+			SenderID:    nudge.SenderID,
+			RecipientID: nudge.RecipientID,
+			CreatedAt:   nudge.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *groupRepository) NudgesBySender(ctx context.Context, groupID string) (map[string]int64, error) {
+	var rows []struct {
+		SenderID string
+		Nudges   int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.GroupNudge{}).
+		Select("sender_id, COUNT(*) AS nudges").
+		Where("group_id = ?", groupID).
+		Group("sender_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	nudges := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		nudges[row.SenderID] = row.Nudges
+	}
+	return nudges, nil
+}
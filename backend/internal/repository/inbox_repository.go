@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// inboxBatchSize is how many inbox rows are inserted per statement
+const inboxBatchSize = 500
+
+type inboxRepository struct {
+	db *gorm.DB
+}
+
+// NewInboxRepository creates a new in-app notification inbox repository
+func NewInboxRepository(db *gorm.DB) domain.InboxRepository {
+	return &inboxRepository{db: db}
+}
+
+func (r *inboxRepository) CreateForUsers(ctx context.Context, userIDs []string, notification domain.InboxNotification) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var template models.InboxNotification
+	if err := utils.TypeConverter(notification, &template); err != nil {
+		return err
+	}
+
+	createdAt := time.Now().UTC()
+	rows := make([]models.InboxNotification, len(userIDs))
+	for i, userID := range userIDs {
+		rows[i] = template
+		rows[i].ID = utils.GenerateID()
+		rows[i].UserID = userID
+		rows[i].ReadAt = nil
+		rows[i].CreatedAt = createdAt
+	}
+	return r.db.WithContext(ctx).CreateInBatches(rows, inboxBatchSize).Error
+}
+
+func (r *inboxRepository) List(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]domain.InboxNotification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.InboxNotification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.InboxNotification
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	notifications := []domain.InboxNotification{}
+	if err := utils.TypeConverter(rows, &notifications); err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *inboxRepository) CountUnread(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.InboxNotification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *inboxRepository) MarkRead(ctx context.Context, userID, id string) error {
+	var row models.InboxNotification
+	err := r.db.WithContext(ctx).Select("id", "read_at").Where("id = ? AND user_id = ?", id, userID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrResourceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if row.ReadAt != nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.InboxNotification{}).
+		Where("id = ?", id).
+		Update("read_at", time.Now().UTC()).Error
+}
+
+func (r *inboxRepository) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.InboxNotification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now().UTC())
+	return result.RowsAffected, result.Error
+}
+
+func (r *inboxRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before.UTC()).Delete(&models.InboxNotification{})
+	return result.RowsAffected, result.Error
+}
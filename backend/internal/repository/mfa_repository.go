@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+// NewMFARepository creates a new two-factor enrollment repository
+func NewMFARepository(db *gorm.DB) domain.MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// GetByUserID returns the enrollment of a user, or ErrMFANotEnabled if there is none
+func (r *mfaRepository) GetByUserID(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	var dbMFA models.UserMFA
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&dbMFA).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrMFANotEnabled
+		}
+		return nil, fmt.Errorf("failed to get mfa enrollment: %w", err)
+	}
+
+	var mfa domain.UserMFA
+	if err := utils.TypeConverter(dbMFA, &mfa); err != nil {
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// Save replaces any existing enrollment of the user
+func (r *mfaRepository) Save(ctx context.Context, mfa *domain.UserMFA) error {
+	var dbMFA models.UserMFA
+	if err := utils.TypeConverter(mfa, &dbMFA); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", mfa.UserID).Delete(&models.UserMFA{}).Error; err != nil {
+			return fmt.Errorf("failed to replace mfa enrollment: %w", err)
+		}
+		if err := tx.Create(&dbMFA).Error; err != nil {
+			return fmt.Errorf("failed to save mfa enrollment: %w", err)
+		}
+		return nil
+	})
+}
+
+// Delete removes the enrollment of a user
+func (r *mfaRepository) Delete(ctx context.Context, userID string) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserMFA{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete mfa enrollment: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrMFANotEnabled
+	}
+	return nil
+}
+
+// UpdateLastUsedStep moves the replay marker forward, never backwards
+func (r *mfaRepository) UpdateLastUsedStep(ctx context.Context, userID string, step int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update mfa step: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode removes a recovery code so it can only be used once
+func (r *mfaRepository) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dbMFA models.UserMFA
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND enabled = ?", userID, true).
+			First(&dbMFA).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrInvalidMFACode
+			}
+			return fmt.Errorf("failed to get mfa enrollment: %w", err)
+		}
+
+		index := slices.Index(dbMFA.RecoveryCodes, codeHash)
+		if index < 0 {
+			return domain.ErrInvalidMFACode
+		}
+		remaining := types.Tags(slices.Delete(slices.Clone(dbMFA.RecoveryCodes), index, index+1))
+
+		err = tx.Model(&models.UserMFA{}).
+			Where("id = ?", dbMFA.ID).
+			Update("recovery_codes", remaining).Error
+		if err != nil {
+			return fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		return nil
+	})
+}
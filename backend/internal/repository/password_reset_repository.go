@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository creates a new password reset token repository
+func NewPasswordResetRepository(db *gorm.DB) domain.PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create stores a new password reset token
+func (r *passwordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	var dbToken models.PasswordResetToken
+	if err := utils.TypeConverter(token, &dbToken); err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Create(&dbToken).Error; err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+// GetByToken retrieves a reset token by its hashed value
+func (r *passwordResetRepository) GetByToken(ctx context.Context, token string) (*domain.PasswordResetToken, error) {
+	var dbToken models.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&dbToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	var resetToken domain.PasswordResetToken
+	if err := utils.TypeConverter(dbToken, &resetToken); err != nil {
+		return nil, err
+	}
+	return &resetToken, nil
+}
+
+// MarkUsed flags a token as consumed. It fails if the token was already used.
+func (r *passwordResetRepository) MarkUsed(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark password reset token as used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidToken
+	}
+	return nil
+}
+
+// InvalidateByUserID marks every outstanding token of a user as used
+func (r *passwordResetRepository) InvalidateByUserID(ctx context.Context, userID string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used = ?", userID, false).
+		Update("used", true).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	return nil
+}
+
+// CountSince counts the tokens issued to a user since the given time
+func (r *passwordResetRepository) CountSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count password reset tokens: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type pointsRepository struct {
+	db *gorm.DB
+}
+
+// NewPointsRepository creates a new points ledger repository
+func NewPointsRepository(db *gorm.DB) domain.PointsRepository {
+	return &pointsRepository{db: db}
+}
+
+func (r *pointsRepository) Add(ctx context.Context, entries ...domain.PointsEntry) (int64, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	rows := make([]models.PointsEntry, len(entries))
+	for i, entry := range entries {
+		if entry.ID == "" {
+			entry.ID = utils.GenerateID()
+		}
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now().UTC()
+		}
+		if err := utils.TypeConverter(entry, &rows[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	// The unique index on the source makes awards idempotent, also when the
+	// same event is handled twice at once or a backfill is run again
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "user_id"}, {Name: "source_type"}, {Name: "source_id"}, {Name: "reversal_of"},
+			},
+			DoNothing: true,
+		}).
+		Create(&rows)
+	return result.RowsAffected, result.Error
+}
+
+func (r *pointsRepository) first(query *gorm.DB) (*domain.PointsEntry, error) {
+	var row models.PointsEntry
+	err := query.First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry domain.PointsEntry
+	if err := utils.TypeConverter(row, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *pointsRepository) GetByID(ctx context.Context, id string) (*domain.PointsEntry, error) {
+	return r.first(r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *pointsRepository) GetAward(ctx context.Context, userID, sourceType, sourceID string) (*domain.PointsEntry, error) {
+	return r.first(r.db.WithContext(ctx).
+		Where("user_id = ? AND source_type = ? AND source_id = ? AND reversal_of = ''", userID, sourceType, sourceID))
+}
+
+func (r *pointsRepository) List(ctx context.Context, userID string, limit, offset int) ([]domain.PointsEntry, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.PointsEntry{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.PointsEntry
+	if err := query.Order("created_at DESC, id").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []domain.PointsEntry{}
+	if err := utils.TypeConverter(rows, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *pointsRepository) TotalsBySource(ctx context.Context, userID string) (map[string]int, error) {
+	var rows []struct {
+		SourceType string
+		Points     int
+	}
+	err := r.db.WithContext(ctx).Model(&models.PointsEntry{}).
+		Select("source_type, SUM(points) AS points").
+		Where("user_id = ?", userID).
+		Group("source_type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int, len(rows))
+	for _, row := range rows {
+		totals[row.SourceType] = row.Points
+	}
+	return totals, nil
+}
+
+func (r *pointsRepository) UserTotal(ctx context.Context, userID string, since time.Time) (int, error) {
+	query := r.db.WithContext(ctx).Model(&models.PointsEntry{}).Where("user_id = ?", userID)
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+
+	var total int
+	err := query.Select("COALESCE(SUM(points), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *pointsRepository) RankedTotals(ctx context.Context, since time.Time) (map[string]int, error) {
+	query := r.db.WithContext(ctx).Model(&models.PointsEntry{}).
+		Select("points_ledger.user_id, SUM(points_ledger.points) AS points").
+		Joins("JOIN users ON users.id = points_ledger.user_id AND users.is_active = ? AND users.deleted_at IS NULL", true).
+		Joins("LEFT JOIN user_profiles ON user_profiles.user_id = points_ledger.user_id").
+		Where("COALESCE(user_profiles.hide_from_leaderboard, ?) = ?", false, false)
+	if !since.IsZero() {
+		query = query.Where("points_ledger.created_at >= ?", since)
+	}
+
+	var rows []struct {
+		UserID string
+		Points int
+	}
+	err := query.Group("points_ledger.user_id").Having("SUM(points_ledger.points) > 0").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int, len(rows))
+	for _, row := range rows {
+		totals[row.UserID] = row.Points
+	}
+	return totals, nil
+}
+
+// historicalAward is a row points were earned on before the ledger existed,
+// UpdatedAt stands in for a missing EarnedAt
+type historicalAward struct {
+	UserID    string
+	SourceID  string
+	Points    int
+	EarnedAt  *time.Time
+	UpdatedAt time.Time
+}
+
+func historicalEntries(sourceType string, awards []historicalAward) []domain.PointsEntry {
+	entries := make([]domain.PointsEntry, len(awards))
+	for i, award := range awards {
+		entries[i] = domain.PointsEntry{
+			UserID:     award.UserID,
+			Points:     award.Points,
+			SourceType: sourceType,
+			SourceID:   award.SourceID,
+			CreatedAt:  award.UpdatedAt.UTC(),
+		}
+		if award.EarnedAt != nil {
+			entries[i].CreatedAt = award.EarnedAt.UTC()
+		}
+	}
+	return entries
+}
+
+func (r *pointsRepository) PuzzleAwards(ctx context.Context, limit, offset int) ([]domain.PointsEntry, error) {
+	var awards []historicalAward
+	err := r.db.WithContext(ctx).Model(&models.UserPuzzleProgress{}).
+		Select("user_id, puzzle_id AS source_id, points_earned AS points, completed_at AS earned_at, updated_at").
+		Where("points_earned > 0").
+		Order("id").Limit(limit).Offset(offset).
+		Scan(&awards).Error
+	if err != nil {
+		return nil, err
+	}
+	return historicalEntries(domain.PointsSourcePuzzle, awards), nil
+}
+
+func (r *pointsRepository) ChallengeAwards(ctx context.Context, limit, offset int) ([]domain.PointsEntry, error) {
+	var awards []historicalAward
+	err := r.db.WithContext(ctx).Model(&models.UserChallenge{}).
+		Select("user_challenges.user_id, user_challenges.id AS source_id, challenges.points, user_challenges.completed_at AS earned_at, user_challenges.updated_at").
+		Joins("JOIN challenges ON challenges.id = user_challenges.challenge_id").
+		Where("user_challenges.status = ? AND challenges.points > 0", models.StatusCompleted).
+		Order("user_challenges.id").Limit(limit).Offset(offset).
+		Scan(&awards).Error
+	if err != nil {
+		return nil, err
+	}
+	return historicalEntries(domain.PointsSourceChallenge, awards), nil
+}
+
+func (r *pointsRepository) AchievementAwards(ctx context.Context, limit, offset int) ([]domain.PointsEntry, error) {
+	var awards []historicalAward
+	err := r.db.WithContext(ctx).Model(&models.UserAchievement{}).
+		Select("user_achievements.user_id, user_achievements.achievement_id AS source_id, achievements.points, user_achievements.earned_at, user_achievements.updated_at").
+		Joins("JOIN achievements ON achievements.id = user_achievements.achievement_id").
+		Where("achievements.points > 0").
+		Order("user_achievements.id").Limit(limit).Offset(offset).
+		Scan(&awards).Error
+	if err != nil {
+		return nil, err
+	}
+	return historicalEntries(domain.PointsSourceAchievement, awards), nil
+}
@@ -1,82 +1,302 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type puzzleRepository struct {
-	puzzlesData *domain.PuzzleData
-	jsonPath    string
+	db *gorm.DB
+}
+
+// NewPuzzleRepository creates a repository for the puzzle bank
+func NewPuzzleRepository(db *gorm.DB) domain.PuzzleRepository {
+	return &puzzleRepository{db: db}
 }
 
-func NewPuzzleRepository(jsonPath string) domain.PuzzleRepository {
-	return &puzzleRepository{
-		puzzlesData: &domain.PuzzleData{},
-		jsonPath:    jsonPath,
+// The options are kept as a JSON string in the puzzles table
+func puzzleToModel(puzzle domain.Puzzle) (models.Puzzle, error) {
+	options, err := json.Marshal(puzzle.Options)
+	if err != nil {
+		return models.Puzzle{}, err
 	}
+	return models.Puzzle{
+		ID:            puzzle.ID,
+		Title:         puzzle.Title,
+		Question:      puzzle.Question,
+		Options:       string(options),
+		CorrectAnswer: puzzle.CorrectAnswer,
+		Difficulty:    puzzle.Difficulty,
+		Category:      puzzle.Category,
+		Points:        puzzle.Points,
+		Explanation:   puzzle.Explanation,
+		Version:       puzzle.Version,
+		RetiredAt:     puzzle.RetiredAt,
+		CreatedAt:     puzzle.CreatedAt,
+	}, nil
 }
 
-func (r *puzzleRepository) loadPuzzlesFromJSON() error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(r.jsonPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+func puzzleFromModel(dbPuzzle models.Puzzle) (domain.Puzzle, error) {
+	puzzle := domain.Puzzle{
+		ID:            dbPuzzle.ID,
+		Title:         dbPuzzle.Title,
+		Question:      dbPuzzle.Question,
+		CorrectAnswer: dbPuzzle.CorrectAnswer,
+		Difficulty:    dbPuzzle.Difficulty,
+		Category:      dbPuzzle.Category,
+		Points:        dbPuzzle.Points,
+		Explanation:   dbPuzzle.Explanation,
+		Version:       dbPuzzle.Version,
+		RetiredAt:     dbPuzzle.RetiredAt,
+		CreatedAt:     dbPuzzle.CreatedAt,
+		UpdatedAt:     dbPuzzle.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(dbPuzzle.Options), &puzzle.Options); err != nil {
+		return domain.Puzzle{}, err
 	}
+	return puzzle, nil
+}
 
-	// Read existing file
-	data, err := os.ReadFile(r.jsonPath)
+func puzzlesFromModels(dbPuzzles []models.Puzzle) ([]domain.Puzzle, error) {
+	puzzles := make([]domain.Puzzle, 0, len(dbPuzzles))
+	for _, dbPuzzle := range dbPuzzles {
+		puzzle, err := puzzleFromModel(dbPuzzle)
+		if err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	return puzzles, nil
+}
+
+// snapshot copies the current content of a puzzle into its versions
+func (r *puzzleRepository) snapshot(tx *gorm.DB, dbPuzzle models.Puzzle) error {
+	return tx.Create(&models.PuzzleVersion{
+		ID:            utils.GenerateID(),
+		PuzzleID:      dbPuzzle.ID,
+		Version:       dbPuzzle.Version,
+		Title:         dbPuzzle.Title,
+		Question:      dbPuzzle.Question,
+		Options:       dbPuzzle.Options,
+		CorrectAnswer: dbPuzzle.CorrectAnswer,
+		Difficulty:    dbPuzzle.Difficulty,
+		Category:      dbPuzzle.Category,
+		Points:        dbPuzzle.Points,
+		Explanation:   dbPuzzle.Explanation,
+	}).Error
+}
+
+func (r *puzzleRepository) GetAllPuzzles() ([]domain.Puzzle, error) {
+	var dbPuzzles []models.Puzzle
+	err := r.db.Where("retired_at IS NULL").Order("created_at, id").Find(&dbPuzzles).Error
 	if err != nil {
-		return fmt.Errorf("failed to read puzzle file: %w", err)
+		return nil, fmt.Errorf("failed to get puzzles: %w", err)
 	}
+	return puzzlesFromModels(dbPuzzles)
+}
 
-	err = json.Unmarshal(data, r.puzzlesData)
+func (r *puzzleRepository) GetPuzzleByID(id string) (*domain.Puzzle, error) {
+	var dbPuzzle models.Puzzle
+	err := r.db.Where("id = ?", id).First(&dbPuzzle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrResourceNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal puzzle data: %w", err)
+		return nil, err
 	}
 
-	return nil
+	puzzle, err := puzzleFromModel(dbPuzzle)
+	if err != nil {
+		return nil, err
+	}
+	return &puzzle, nil
 }
 
-func (r *puzzleRepository) GetAllPuzzles() ([]domain.Puzzle, error) {
-	if r.puzzlesData == nil || len(r.puzzlesData.Puzzles) == 0 {
-		if err := r.loadPuzzlesFromJSON(); err != nil {
-			return nil, err
+func (r *puzzleRepository) GetRandomPuzzle() (*domain.Puzzle, error) {
+	var dbPuzzles []models.Puzzle
+	err := r.db.Where("retired_at IS NULL").Order("RANDOM()").Limit(1).Find(&dbPuzzles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get random puzzle: %w", err)
+	}
+	if len(dbPuzzles) == 0 {
+		return nil, fmt.Errorf("no puzzles available")
+	}
+
+	puzzle, err := puzzleFromModel(dbPuzzles[0])
+	if err != nil {
+		return nil, err
+	}
+	return &puzzle, nil
+}
+
+func (r *puzzleRepository) Create(ctx context.Context, puzzle *domain.Puzzle) error {
+	puzzle.Version = 1
+	dbPuzzle, err := puzzleToModel(*puzzle)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&dbPuzzle).Error; err != nil {
+			return err
 		}
+		return r.snapshot(tx, dbPuzzle)
+	})
+	if err != nil {
+		return err
 	}
-	return r.puzzlesData.Puzzles, nil
+	*puzzle, err = puzzleFromModel(dbPuzzle)
+	return err
 }
 
-func (r *puzzleRepository) GetPuzzleByID(id string) (*domain.Puzzle, error) {
-	puzzles, err := r.GetAllPuzzles()
+func (r *puzzleRepository) Update(ctx context.Context, puzzle *domain.Puzzle) error {
+	dbPuzzle, err := puzzleToModel(*puzzle)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for _, puzzle := range puzzles {
-		if puzzle.ID == id {
-			return &puzzle, nil
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Puzzle
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", puzzle.ID).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrResourceNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		dbPuzzle.Version = current.Version + 1
+		err = tx.Model(&current).
+			Select("title", "question", "options", "correct_answer", "difficulty", "category", "points", "explanation", "version", "updated_at").
+			Updates(&dbPuzzle).Error
+		if err != nil {
+			return err
 		}
+		return r.snapshot(tx, dbPuzzle)
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil, fmt.Errorf("puzzle with ID %s not found", id)
+	updated, err := r.GetPuzzleByID(puzzle.ID)
+	if err != nil {
+		return err
+	}
+	*puzzle = *updated
+	return nil
 }
 
-func (r *puzzleRepository) GetRandomPuzzle() (*domain.Puzzle, error) {
-	puzzles, err := r.GetAllPuzzles()
+func (r *puzzleRepository) SetRetired(ctx context.Context, id string, retired bool) error {
+	var retiredAt *time.Time
+	if retired {
+		now := time.Now()
+		retiredAt = &now
+	}
+
+	query := r.db.WithContext(ctx).Model(&models.Puzzle{}).Where("id = ?", id)
+	// Retiring again keeps the time it was first retired
+	if retired {
+		query = query.Where("retired_at IS NULL")
+	}
+	result := query.Update("retired_at", retiredAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&models.Puzzle{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return domain.ErrResourceNotFound
+		}
+	}
+	return nil
+}
+
+func (r *puzzleRepository) List(ctx context.Context, includeRetired bool) ([]domain.Puzzle, error) {
+	query := r.db.WithContext(ctx).Order("created_at, id")
+	if !includeRetired {
+		query = query.Where("retired_at IS NULL")
+	}
+
+	var dbPuzzles []models.Puzzle
+	if err := query.Find(&dbPuzzles).Error; err != nil {
+		return nil, err
+	}
+	return puzzlesFromModels(dbPuzzles)
+}
+
+func (r *puzzleRepository) ListVersions(ctx context.Context, id string) ([]domain.PuzzleVersion, error) {
+	var dbVersions []models.PuzzleVersion
+	err := r.db.WithContext(ctx).Where("puzzle_id = ?", id).Order("version DESC").Find(&dbVersions).Error
 	if err != nil {
 		return nil, err
 	}
+	if len(dbVersions) == 0 {
+		return nil, domain.ErrResourceNotFound
+	}
 
-	if len(puzzles) == 0 {
-		return nil, fmt.Errorf("no puzzles available")
+	versions := make([]domain.PuzzleVersion, 0, len(dbVersions))
+	for _, dbVersion := range dbVersions {
+		version := domain.PuzzleVersion{
+			PuzzleID:      dbVersion.PuzzleID,
+			Version:       dbVersion.Version,
+			Title:         dbVersion.Title,
+			Question:      dbVersion.Question,
+			CorrectAnswer: dbVersion.CorrectAnswer,
+			Difficulty:    dbVersion.Difficulty,
+			Category:      dbVersion.Category,
+			Points:        dbVersion.Points,
+			Explanation:   dbVersion.Explanation,
+			CreatedAt:     dbVersion.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(dbVersion.Options), &version.Options); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
 	}
+	return versions, nil
+}
+
+func (r *puzzleRepository) Import(ctx context.Context, puzzles []domain.Puzzle) (*domain.PuzzleImportResult, error) {
+	result := &domain.PuzzleImportResult{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, puzzle := range puzzles {
+			if puzzle.Version < 1 {
+				puzzle.Version = 1
+			}
+			dbPuzzle, err := puzzleToModel(puzzle)
+			if err != nil {
+				return err
+			}
 
-	// Simple random selection based on current time
-	index := int(time.Now().UnixNano()) % len(puzzles)
-	return &puzzles[index], nil
+			created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbPuzzle)
+			if created.Error != nil {
+				return created.Error
+			}
+			if created.RowsAffected == 0 {
+				result.Skipped++
+				continue
+			}
+			if err := r.snapshot(tx, dbPuzzle); err != nil {
+				return err
+			}
+			result.Imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
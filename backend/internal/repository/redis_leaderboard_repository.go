@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	leaderboardKeyPrefix = "leaderboard:"
+	// leaderboardRetention keeps the board of a period after it ended
+	leaderboardRetention = 30 * 24 * time.Hour
+	// leaderboardReplaceBatch is how many members are added per ZADD on a rebuild
+	leaderboardReplaceBatch = 1000
+)
+
+// redisLeaderboardRepository keeps a sorted set per period, scored by points.
+// The boards of weeks and months are new keys every period, so they reset on
+// their own and expire a while after the period ends.
+type redisLeaderboardRepository struct {
+	client *redis.Client
+}
+
+// NewRedisLeaderboardRepository creates a new Redis leaderboard repository
+func NewRedisLeaderboardRepository(client *redis.Client) domain.LeaderboardRepository {
+	return &redisLeaderboardRepository{client: client}
+}
+
+// leaderboardKey is leaderboard:all_time, or the window and the first day of
+// the period such as leaderboard:weekly:2025-08-04
+func leaderboardKey(period domain.LeaderboardPeriod) string {
+	if period.Start.IsZero() {
+		return leaderboardKeyPrefix + period.Window
+	}
+	return leaderboardKeyPrefix + period.Window + ":" + period.Start.Format("2006-01-02")
+}
+
+// expireLeaderboard sets the expiry of the board of a period that ends
+func expireLeaderboard(ctx context.Context, pipe redis.Pipeliner, key string, period domain.LeaderboardPeriod) {
+	if !period.End.IsZero() {
+		pipe.ExpireAt(ctx, key, period.End.Add(leaderboardRetention))
+	}
+}
+
+func (r *redisLeaderboardRepository) AddPoints(ctx context.Context, periods []domain.LeaderboardPeriod, userID string, points int) error {
+	pipe := r.client.TxPipeline()
+	for _, period := range periods {
+		key := leaderboardKey(period)
+		pipe.ZIncrBy(ctx, key, float64(points), userID)
+		expireLeaderboard(ctx, pipe, key, period)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add leaderboard points: %w", err)
+	}
+	return nil
+}
+
+func (r *redisLeaderboardRepository) SetPoints(ctx context.Context, period domain.LeaderboardPeriod, userID string, points int) error {
+	key := leaderboardKey(period)
+	pipe := r.client.TxPipeline()
+	if points > 0 {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(points), Member: userID})
+		expireLeaderboard(ctx, pipe, key, period)
+	} else {
+		pipe.ZRem(ctx, key, userID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set leaderboard points: %w", err)
+	}
+	return nil
+}
+
+// Replace fills a temporary key and renames it over the board, so readers
+// never see a half built board
+func (r *redisLeaderboardRepository) Replace(ctx context.Context, period domain.LeaderboardPeriod, points map[string]int) error {
+	key := leaderboardKey(period)
+	if len(points) == 0 {
+		if err := r.client.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to clear leaderboard: %w", err)
+		}
+		return nil
+	}
+
+	tmpKey := key + ":rebuild"
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, tmpKey)
+	members := make([]redis.Z, 0, min(len(points), leaderboardReplaceBatch))
+	for userID, userPoints := range points {
+		members = append(members, redis.Z{Score: float64(userPoints), Member: userID})
+		if len(members) == leaderboardReplaceBatch {
+			pipe.ZAdd(ctx, tmpKey, members...)
+			members = members[:0]
+		}
+	}
+	if len(members) > 0 {
+		pipe.ZAdd(ctx, tmpKey, members...)
+	}
+	pipe.Rename(ctx, tmpKey, key)
+	expireLeaderboard(ctx, pipe, key, period)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to replace leaderboard: %w", err)
+	}
+	return nil
+}
+
+func (r *redisLeaderboardRepository) Remove(ctx context.Context, periods []domain.LeaderboardPeriod, userID string) error {
+	pipe := r.client.Pipeline()
+	for _, period := range periods {
+		pipe.ZRem(ctx, leaderboardKey(period), userID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove from leaderboard: %w", err)
+	}
+	return nil
+}
+
+func (r *redisLeaderboardRepository) Top(ctx context.Context, period domain.LeaderboardPeriod, limit int) ([]domain.LeaderboardScore, error) {
+	members, err := r.client.ZRevRangeWithScores(ctx, leaderboardKey(period), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	scores := make([]domain.LeaderboardScore, 0, len(members))
+	for i, member := range members {
+		userID, _ := member.Member.(string)
+		scores = append(scores, domain.LeaderboardScore{
+			UserID: userID,
+			Points: int(member.Score),
+			Rank:   i + 1,
+		})
+	}
+	return scores, nil
+}
+
+func (r *redisLeaderboardRepository) Rank(ctx context.Context, period domain.LeaderboardPeriod, userID string) (*domain.LeaderboardScore, error) {
+	key := leaderboardKey(period)
+	pipe := r.client.Pipeline()
+	rank := pipe.ZRevRank(ctx, key, userID)
+	score := pipe.ZScore(ctx, key, userID)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get leaderboard rank: %w", err)
+	}
+	if errors.Is(rank.Err(), redis.Nil) {
+		return nil, nil
+	}
+
+	return &domain.LeaderboardScore{
+		UserID: userID,
+		Points: int(score.Val()),
+		Rank:   int(rank.Val()) + 1,
+	}, nil
+}
+
+func (r *redisLeaderboardRepository) Scores(ctx context.Context, period domain.LeaderboardPeriod, userIDs []string) (map[string]int, error) {
+	scores := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return scores, nil
+	}
+
+	// ZMSCORE has no score for members off the board, they come back as zero
+	points, err := r.client.ZMScore(ctx, leaderboardKey(period), userIDs...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard scores: %w", err)
+	}
+	for i, userID := range userIDs {
+		if i < len(points) && points[i] != 0 {
+			scores[userID] = int(points[i])
+		}
+	}
+	return scores, nil
+}
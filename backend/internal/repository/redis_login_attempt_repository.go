@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key patterns and window for failed login tracking
+const (
+	loginFailIPPrefix    = "login_fail:ip:"
+	loginFailEmailPrefix = "login_fail:email:"
+	loginFailureWindow   = 15 * time.Minute
+)
+
+// redisLoginAttemptRepository implements LoginAttemptRepository using fixed
+// windows that start with the first failure and expire after loginFailureWindow
+type redisLoginAttemptRepository struct {
+	client *redis.Client
+}
+
+// NewRedisLoginAttemptRepository creates a new Redis login attempt repository
+func NewRedisLoginAttemptRepository(client *redis.Client) domain.LoginAttemptRepository {
+	return &redisLoginAttemptRepository{client: client}
+}
+
+// RecordFailure increments the failure counters of the IP and the email
+func (r *redisLoginAttemptRepository) RecordFailure(ctx context.Context, ipAddress, email string) (int64, int64, error) {
+	ipKey := loginFailIPPrefix + ipAddress
+	emailKey := loginFailEmailPrefix + normalizeLoginEmail(email)
+
+	pipe := r.client.TxPipeline()
+	ipCount := pipe.Incr(ctx, ipKey)
+	emailCount := pipe.Incr(ctx, emailKey)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	// The window starts with the first failure
+	expirePipe := r.client.Pipeline()
+	if ipCount.Val() == 1 {
+		expirePipe.Expire(ctx, ipKey, loginFailureWindow)
+	}
+	if emailCount.Val() == 1 {
+		expirePipe.Expire(ctx, emailKey, loginFailureWindow)
+	}
+	if expirePipe.Len() > 0 {
+		if _, err := expirePipe.Exec(ctx); err != nil {
+			return 0, 0, fmt.Errorf("failed to set login failure window: %w", err)
+		}
+	}
+
+	return ipCount.Val(), emailCount.Val(), nil
+}
+
+// GetFailures returns the current failure counters of the IP and the email
+func (r *redisLoginAttemptRepository) GetFailures(ctx context.Context, ipAddress, email string) (int64, int64, error) {
+	values, err := r.client.MGet(ctx, loginFailIPPrefix+ipAddress, loginFailEmailPrefix+normalizeLoginEmail(email)).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get login failures: %w", err)
+	}
+
+	return parseRedisCount(values[0]), parseRedisCount(values[1]), nil
+}
+
+// ResetEmail clears the failure window of an email after a successful login or an unlock
+func (r *redisLoginAttemptRepository) ResetEmail(ctx context.Context, email string) error {
+	if err := r.client.Del(ctx, loginFailEmailPrefix+normalizeLoginEmail(email)).Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func parseRedisCount(value any) int64 {
+	str, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	count, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key patterns for pending two-factor challenges
+const (
+	mfaChallengePrefix         = "mfa_challenge:"
+	mfaChallengeAttemptsPrefix = "mfa_challenge_attempts:"
+)
+
+// mfaChallengeAttemptsTTL outlives any challenge, the counter is deleted with it
+const mfaChallengeAttemptsTTL = 15 * time.Minute
+
+// redisMFAChallengeRepository implements MFAChallengeRepository, challenges
+// expire on their own through the key TTL
+type redisMFAChallengeRepository struct {
+	client *redis.Client
+}
+
+// NewRedisMFAChallengeRepository creates a new Redis two-factor challenge repository
+func NewRedisMFAChallengeRepository(client *redis.Client) domain.MFAChallengeRepository {
+	return &redisMFAChallengeRepository{client: client}
+}
+
+// Create stores a challenge until its ExpiresAt
+func (r *redisMFAChallengeRepository) Create(ctx context.Context, tokenHash string, challenge *domain.MFAChallenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("mfa challenge is already expired")
+	}
+
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mfa challenge: %w", err)
+	}
+
+	if err := r.client.Set(ctx, mfaChallengePrefix+tokenHash, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store mfa challenge: %w", err)
+	}
+	return nil
+}
+
+// Get returns a pending challenge, or ErrInvalidToken if it expired or never existed
+func (r *redisMFAChallengeRepository) Get(ctx context.Context, tokenHash string) (*domain.MFAChallenge, error) {
+	pipe := r.client.Pipeline()
+	dataCmd := pipe.Get(ctx, mfaChallengePrefix+tokenHash)
+	attemptsCmd := pipe.Get(ctx, mfaChallengeAttemptsPrefix+tokenHash)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	data, err := dataCmd.Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	var challenge domain.MFAChallenge
+	if err := json.Unmarshal([]byte(data), &challenge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mfa challenge: %w", err)
+	}
+	challenge.Attempts = int(parseRedisCount(attemptsCmd.Val()))
+	return &challenge, nil
+}
+
+// IncrementAttempts counts an attempt against the challenge. INCR and the
+// expiry run in one transaction, the counter lives as long as the challenge.
+func (r *redisMFAChallengeRepository) IncrementAttempts(ctx context.Context, tokenHash string) (int, error) {
+	key := mfaChallengeAttemptsPrefix + tokenHash
+	pipe := r.client.TxPipeline()
+	incrCmd := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, mfaChallengeAttemptsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+	return int(incrCmd.Val()), nil
+}
+
+// Delete removes a challenge once it was used or exhausted
+func (r *redisMFAChallengeRepository) Delete(ctx context.Context, tokenHash string) error {
+	if err := r.client.Del(ctx, mfaChallengePrefix+tokenHash, mfaChallengeAttemptsPrefix+tokenHash).Err(); err != nil {
+		return fmt.Errorf("failed to delete mfa challenge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// oauthNoncePrefix is the Redis key pattern for issued OAuth nonces
+const oauthNoncePrefix = "oauth_nonce:"
+
+// redisOAuthNonceRepository implements OAuthNonceRepository, nonces expire on
+// their own through the key TTL
+type redisOAuthNonceRepository struct {
+	client *redis.Client
+}
+
+// NewRedisOAuthNonceRepository creates a new Redis OAuth nonce repository
+func NewRedisOAuthNonceRepository(client *redis.Client) domain.OAuthNonceRepository {
+	return &redisOAuthNonceRepository{client: client}
+}
+
+// Create stores a nonce until expiresAt
+func (r *redisOAuthNonceRepository) Create(ctx context.Context, nonceHash string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("oauth nonce is already expired")
+	}
+
+	if err := r.client.Set(ctx, oauthNoncePrefix+nonceHash, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store oauth nonce: %w", err)
+	}
+	return nil
+}
+
+// Consume deletes the nonce, only the request that actually deleted it may use it
+func (r *redisOAuthNonceRepository) Consume(ctx context.Context, nonceHash string) error {
+	deleted, err := r.client.Del(ctx, oauthNoncePrefix+nonceHash).Result()
+	if err != nil {
+		return fmt.Errorf("failed to consume oauth nonce: %w", err)
+	}
+	if deleted == 0 {
+		return domain.ErrInvalidToken
+	}
+	return nil
+}
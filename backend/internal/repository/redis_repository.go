@@ -18,7 +18,8 @@ type RedisSessionRepository struct {
 	client *redis.Client
 }
 
-func NewRedisSessionRepository(config utils.DBSettings) (*RedisSessionRepository, error) {
+// NewRedisClient connects to redis, the client is shared by the redis backed repositories
+func NewRedisClient(config utils.DBSettings) (*redis.Client, error) {
 	redisConnAddr := fmt.Sprintf("%s:%s", config.Host, config.Port)
 	options := &redis.Options{
 		Username:  config.UserName,
@@ -33,11 +34,11 @@ func NewRedisSessionRepository(config utils.DBSettings) (*RedisSessionRepository
 		logger.Log.WithError(err).Fatal("redis could not connect")
 		return nil, err
 	}
-	return newRedisSessionRepository(client), nil
+	return client, nil
 }
 
 // NewRedisSessionRepository creates a new Redis session repository
-func newRedisSessionRepository(client *redis.Client) *RedisSessionRepository {
+func NewRedisSessionRepository(client *redis.Client) *RedisSessionRepository {
 	return &RedisSessionRepository{
 		client: client,
 	}
@@ -49,8 +50,28 @@ const (
 	tokenKeyPrefix     = "token:"
 	userSessionsPrefix = "user_sessions:"
 	expiredSessionsKey = "expired_sessions"
+	refreshKeyPrefix   = "refresh:"
+	rotatedKeyPrefix   = "rotated_refresh:"
 )
 
+// maxSessionUpdateRetries is how many times Update retries when the session
+// changes while it is being written
+const maxSessionUpdateRetries = 3
+
+// touchSessionScript sets the last seen time and address of a stored session
+// in place, keeping its TTL
+var touchSessionScript = redis.NewScript(`
+local data = redis.call("GET", KEYS[1])
+if not data then
+	return 0
+end
+local session = cjson.decode(data)
+session.last_seen_at = ARGV[1]
+session.ip_address = ARGV[2]
+redis.call("SET", KEYS[1], cjson.encode(session), "KEEPTTL")
+return 1
+`)
+
 // Create stores a new session in Redis
 func (r *RedisSessionRepository) Create(ctx context.Context, session *domain.Session) error {
 	sessionKey := sessionKeyPrefix + session.ID
@@ -78,6 +99,11 @@ func (r *RedisSessionRepository) Create(ctx context.Context, session *domain.Ses
 	// Store token -> session ID mapping
 	pipe.Set(ctx, tokenKey, session.ID, ttl)
 
+	// Store refresh token -> session ID mapping
+	if session.RefreshToken != "" {
+		pipe.Set(ctx, refreshKeyPrefix+session.RefreshToken, session.ID, ttl)
+	}
+
 	// Add to user's session set
 	pipe.SAdd(ctx, userSessionsKey, session.ID)
 	pipe.Expire(ctx, userSessionsKey, ttl)
@@ -168,59 +194,135 @@ func (r *RedisSessionRepository) GetByUserID(ctx context.Context, userID string)
 	return sessions, nil
 }
 
-// Update updates an existing session
-func (r *RedisSessionRepository) Update(ctx context.Context, session *domain.Session) error {
-	session.UpdatedAt = time.Now()
+// RotateRefreshToken consumes a refresh token and replaces it with a new one.
+// The old token is remembered until the session expires so that a replay of it
+// can be detected and reported as domain.ErrRefreshTokenReused.
+func (r *RedisSessionRepository) RotateRefreshToken(ctx context.Context, refreshToken, newRefreshToken string, expiresAt time.Time) (*domain.Session, error) {
+	// GETDEL makes the token single-use even under concurrent refreshes
+	sessionID, err := r.client.GetDel(ctx, refreshKeyPrefix+refreshToken).Result()
+	if err == redis.Nil {
+		rotatedSessionID, err := r.client.Get(ctx, rotatedKeyPrefix+refreshToken).Result()
+		if err == redis.Nil {
+			return nil, domain.ErrInvalidToken
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check rotated refresh token: %w", err)
+		}
 
-	sessionKey := sessionKeyPrefix + session.ID
-	tokenKey := tokenKeyPrefix + session.Token
-	userSessionsKey := userSessionsPrefix + session.UserID
+		session, err := r.GetByID(ctx, rotatedSessionID)
+		if err != nil {
+			// Session already revoked, nothing left to tie the replay to
+			return &domain.Session{ID: rotatedSessionID}, domain.ErrRefreshTokenReused
+		}
+		return session, domain.ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
 
-	// Check if session exists
-	exists, err := r.client.Exists(ctx, sessionKey).Result()
+	session, err := r.GetByID(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to check session existence: %w", err)
+		return nil, domain.ErrSessionNotFound
 	}
-	if exists == 0 {
-		return fmt.Errorf("session not found")
+	if !session.IsActive {
+		return session, domain.ErrSessionInactive
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return session, domain.ErrSessionExpired
 	}
 
-	// Serialize session
+	session.RefreshToken = newRefreshToken
+	session.ExpiresAt = expiresAt
+	session.UpdatedAt = time.Now()
+
 	sessionData, err := json.Marshal(session)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session: %w", err)
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	// Calculate TTL
-	ttl := time.Until(session.ExpiresAt)
+	ttl := time.Until(expiresAt)
 	if ttl <= 0 {
-		return fmt.Errorf("session is already expired")
+		return nil, fmt.Errorf("session is already expired")
 	}
 
-	// Use pipeline for atomic operations
 	pipe := r.client.Pipeline()
+	pipe.Set(ctx, sessionKeyPrefix+session.ID, sessionData, ttl)
+	pipe.Set(ctx, tokenKeyPrefix+session.Token, session.ID, ttl)
+	pipe.Set(ctx, refreshKeyPrefix+newRefreshToken, session.ID, ttl)
+	pipe.Set(ctx, rotatedKeyPrefix+refreshToken, session.ID, ttl)
+	pipe.SAdd(ctx, userSessionsPrefix+session.UserID, session.ID)
+	pipe.Expire(ctx, userSessionsPrefix+session.UserID, ttl)
+	pipe.ZAdd(ctx, expiredSessionsKey, redis.Z{
+		Score:  float64(expiresAt.Unix()),
+		Member: session.ID,
+	})
 
-	// Update session data
-	pipe.Set(ctx, sessionKey, sessionData, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
 
-	// Update token mapping
-	pipe.Set(ctx, tokenKey, session.ID, ttl)
+	return session, nil
+}
 
-	// Update user sessions set
-	pipe.SAdd(ctx, userSessionsKey, session.ID)
-	pipe.Expire(ctx, userSessionsKey, ttl)
+// Update writes back the changes to a session made since it was read. It runs
+// in a transaction on the stored session, so a session that was revoked in
+// the meantime stays revoked, and the expiry set by RotateRefreshToken is kept.
+func (r *RedisSessionRepository) Update(ctx context.Context, session *domain.Session) error {
+	sessionKey := sessionKeyPrefix + session.ID
 
-	// Update expired sessions sorted set
-	pipe.ZAdd(ctx, expiredSessionsKey, redis.Z{
-		Score:  float64(session.ExpiresAt.Unix()),
-		Member: session.ID,
-	})
+	update := func(tx *redis.Tx) error {
+		sessionData, err := tx.Get(ctx, sessionKey).Result()
+		if err == redis.Nil {
+			return domain.ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
 
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to update session: %w", err)
+		var current domain.Session
+		if err := json.Unmarshal([]byte(sessionData), &current); err != nil {
+			return fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+		if !current.IsActive && session.IsActive {
+			return domain.ErrSessionInactive
+		}
+
+		session.UserID = current.UserID
+		session.ExpiresAt = current.ExpiresAt
+		session.CreatedAt = current.CreatedAt
+		session.UpdatedAt = time.Now()
+		updated, err := json.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("failed to marshal session: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, sessionKey, updated, redis.KeepTTL)
+			return nil
+		})
+		return err
 	}
 
+	for range maxSessionUpdateRetries {
+		err := r.client.Watch(ctx, update, sessionKey)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to update session: %w", redis.TxFailedErr)
+}
+
+// UpdateLastSeen sets when and from where a session was last used, leaving the
+// rest of it as stored. A session that no longer exists is not recreated.
+func (r *RedisSessionRepository) UpdateLastSeen(ctx context.Context, sessionID string, lastSeenAt time.Time, ipAddress string) error {
+	updated, err := touchSessionScript.Run(ctx, r.client, []string{sessionKeyPrefix + sessionID},
+		lastSeenAt.Format(time.RFC3339Nano), ipAddress).Int()
+	if err != nil {
+		return fmt.Errorf("failed to update session last seen time: %w", err)
+	}
+	if updated == 0 {
+		return domain.ErrSessionNotFound
+	}
 	return nil
 }
 
@@ -251,6 +353,7 @@ func (r *RedisSessionRepository) Delete(ctx context.Context, id string) error {
 	// Delete token mapping
 	tokenKey := tokenKeyPrefix + session.Token
 	pipe.Del(ctx, tokenKey)
+	pipe.Del(ctx, refreshKeyPrefix+session.RefreshToken)
 
 	// Remove from user sessions set
 	userSessionsKey := userSessionsPrefix + session.UserID
@@ -319,6 +422,7 @@ func (r *RedisSessionRepository) DeleteByUserID(ctx context.Context, userID stri
 		// Delete token mapping
 		tokenKey := tokenKeyPrefix + session.Token
 		deletePipe.Del(ctx, tokenKey)
+		deletePipe.Del(ctx, refreshKeyPrefix+session.RefreshToken)
 
 		// Remove from expired sessions sorted set
 		deletePipe.ZRem(ctx, expiredSessionsKey, sessionIDs[i])
@@ -392,6 +496,7 @@ func (r *RedisSessionRepository) DeleteExpired(ctx context.Context) error {
 		// Delete token mapping
 		tokenKey := tokenKeyPrefix + session.Token
 		deletePipe.Del(ctx, tokenKey)
+		deletePipe.Del(ctx, refreshKeyPrefix+session.RefreshToken)
 
 		// Remove from user sessions set
 		userSessionsKey := userSessionsPrefix + session.UserID
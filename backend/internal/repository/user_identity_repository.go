@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository creates a new external identity repository
+func NewUserIdentityRepository(db *gorm.DB) domain.UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+// Create links a provider subject to a user
+func (r *userIdentityRepository) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	var dbIdentity models.UserIdentity
+	if err := utils.TypeConverter(identity, &dbIdentity); err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Create(&dbIdentity).Error; err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			return domain.ErrConflict
+		}
+		return fmt.Errorf("failed to create user identity: %w", err)
+	}
+	return nil
+}
+
+// GetByProviderSubject finds the identity of a provider subject
+func (r *userIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	var dbIdentity models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&dbIdentity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	var identity domain.UserIdentity
+	if err := utils.TypeConverter(dbIdentity, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// GetByUserID lists the providers linked to a user
+func (r *userIdentityRepository) GetByUserID(ctx context.Context, userID string) ([]domain.UserIdentity, error) {
+	var dbIdentities []models.UserIdentity
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&dbIdentities).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user identities: %w", err)
+	}
+
+	identities := []domain.UserIdentity{}
+	if err := utils.TypeConverter(dbIdentities, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// UpdateLastLogin records a sign-in through the identity
+func (r *userIdentityRepository) UpdateLastLogin(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.UserIdentity{}).
+		Where("id = ?", id).
+		Update("last_login_at", time.Now().UTC()).Error
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+	return nil
+}
+
+// DeleteByUserID unlinks every provider of a user
+func (r *userIdentityRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&models.UserIdentity{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete user identities: %w", err)
+	}
+	return nil
+}
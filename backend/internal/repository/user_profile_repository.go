@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/infrastructure/db/models"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type userProfileRepository struct {
+	db *gorm.DB
+}
+
+// NewUserProfileRepository creates a new user profile repository
+func NewUserProfileRepository(db *gorm.DB) domain.UserProfileRepository {
+	return &userProfileRepository{db: db}
+}
+
+// Create stores a new profile
+func (r *userProfileRepository) Create(ctx context.Context, profile *domain.UserProfile) error {
+	var dbProfile models.UserProfile
+	if profile == nil {
+		return errors.New("profile cannot be nil")
+	}
+	if err := utils.TypeConverter(profile, &dbProfile); err != nil {
+		return err
+	}
+	if dbProfile.ID == "" {
+		dbProfile.ID = utils.GenerateID()
+	}
+
+	if err := r.db.WithContext(ctx).Create(&dbProfile).Error; err != nil {
+		return fmt.Errorf("failed to create user profile: %w", err)
+	}
+	profile.ID = dbProfile.ID
+	return nil
+}
+
+// GetByID retrieves a profile by its ID
+func (r *userProfileRepository) GetByID(ctx context.Context, id string) (*domain.UserProfile, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+	return r.first(ctx, "id = ?", id)
+}
+
+// GetByUserID retrieves the profile of a user
+func (r *userProfileRepository) GetByUserID(ctx context.Context, userID string) (*domain.UserProfile, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+	return r.first(ctx, "user_id = ?", userID)
+}
+
+func (r *userProfileRepository) first(ctx context.Context, query string, args ...any) (*domain.UserProfile, error) {
+	var dbProfile models.UserProfile
+	var profile domain.UserProfile
+
+	err := r.db.WithContext(ctx).Where(query, args...).First(&dbProfile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	if err := utils.TypeConverter(dbProfile, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Update saves every editable field of the profile, including cleared ones
+func (r *userProfileRepository) Update(ctx context.Context, profile *domain.UserProfile) error {
+	if profile == nil {
+		return errors.New("profile cannot be nil")
+	}
+	if profile.ID == "" {
+		return errors.New("profile ID cannot be empty")
+	}
+
+	updates := map[string]any{
+		"phone_number":  profile.PhoneNumber,
+		"date_of_birth": profile.DateOfBirth,
+		"location":      profile.Location,
+		"bio":           profile.Bio,
+		"avatar_url":    profile.AvatarURL,
+	}
+	for column, value := range notificationPreferenceColumns(profile.NotificationPreferences) {
+		updates[column] = value
+	}
+	return r.UpdatePartial(ctx, profile.ID, updates)
+}
+
+// UpdatePartial updates the given columns of a profile
+func (r *userProfileRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+	updates["updated_at"] = time.Now().UTC()
+
+	result := r.db.WithContext(ctx).
+		Model(&models.UserProfile{}).
+		Where("id = ?", id).
+		Updates(updates)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user profile: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// Delete removes a profile
+func (r *userProfileRepository) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("id cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).Delete(&models.UserProfile{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete user profile: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateAvatar sets the avatar of a user
+func (r *userProfileRepository) UpdateAvatar(ctx context.Context, userID, avatarURL string) error {
+	return r.updateByUserID(ctx, userID, map[string]any{"avatar_url": avatarURL})
+}
+
+// UpdateNotificationPreferences replaces the notification preferences of a user
+func (r *userProfileRepository) UpdateNotificationPreferences(ctx context.Context, userID string, prefs types.NotificationsPref) error {
+	return r.updateByUserID(ctx, userID, notificationPreferenceColumns(prefs))
+}
+
+func (r *userProfileRepository) updateByUserID(ctx context.Context, userID string, updates map[string]any) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+	updates["updated_at"] = time.Now().UTC()
+
+	result := r.db.WithContext(ctx).
+		Model(&models.UserProfile{}).
+		Where("user_id = ?", userID).
+		Updates(updates)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user profile: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// Count returns the number of profiles
+func (r *userProfileRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.UserProfile{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count user profiles: %w", err)
+	}
+	return count, nil
+}
+
+// Exists reports whether the user has a profile
+func (r *userProfileRepository) Exists(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserProfile{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check user profile: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *userProfileRepository) GetPublicProfiles(ctx context.Context, userIDs []string) ([]domain.PublicProfile, error) {
+	profiles := []domain.PublicProfile{}
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Select("users.id AS user_id, users.name, COALESCE(user_profiles.avatar_url, '') AS avatar_url, COALESCE(user_profiles.hide_from_leaderboard, false) AS hide_from_leaderboard").
+		Joins("LEFT JOIN user_profiles ON user_profiles.user_id = users.id").
+		Where("users.id IN ? AND users.is_active = ?", userIDs, true).
+		Scan(&profiles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// notificationPreferenceColumns maps preferences to the embedded profile
+// columns, a struct update would skip the ones switched off
+func notificationPreferenceColumns(prefs types.NotificationsPref) map[string]any {
+	return map[string]any{
+		"notification_morning_prompt":             prefs.MorningPrompt,
+		"notification_evening_reflection":         prefs.EveningReflection,
+		"notification_challenge":                  prefs.Challenge,
+		"notification_language":                   prefs.Language,
+		"notification_reminders_morning_reminder": prefs.Reminders.MorningReminder,
+		"notification_reminders_evening_reminder": prefs.Reminders.EveningReminder,
+		"notification_quiet_hours_start":          prefs.QuietHours.Start,
+		"notification_quiet_hours_end":            prefs.QuietHours.End,
+		"notification_caps_daily":                 prefs.Caps.Daily,
+		"notification_caps_reminders":             prefs.Caps.Reminders,
+		"notification_caps_challenges":            prefs.Caps.Challenges,
+		"notification_caps_campaigns":             prefs.Caps.Campaigns,
+	}
+}
@@ -29,7 +29,7 @@ func NewUserRepository(db *gorm.DB, secRepo domain.SecurityEventRepository) doma
 }
 
 // Create creates a new user in the database
-func (r *userRepository) Create(ctx context.Context, user *domain.User, userPrefs types.NotificationsPref) error {
+func (r *userRepository) Create(ctx context.Context, user *domain.User, userPrefs types.NotificationsPref, timezone string) error {
 	var dbUser models.User
 	var dbnotificationprefs types.NotificationsPref
 	if user == nil {
@@ -67,6 +67,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User, userPref
 			ID:                      utils.GenerateID(),
 			UserID:                  dbUser.ID,
 			NotificationPreferences: dbnotificationprefs,
+			Timezone:                timezone,
 			CreatedAt:               time.Now().UTC(),
 			UpdatedAt:               time.Now().UTC(),
 		}
@@ -252,13 +253,54 @@ func (r *userRepository) UpdatePassword(ctx context.Context, userID, passwordHas
 			Where("user_id = ?", userID).
 			Update("is_active", false)
 
-		// Log security event
-		r.secEventRepository.LogSecurityEvent(ctx, userID, types.EventPasswordChange, "", "", nil)
-
 		return nil
 	})
 }
 
+// ClearPassword removes the user's password so it can no longer be used to log in
+func (r *userRepository) ClearPassword(ctx context.Context, userID string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"password_hash": "",
+			"salt":          "",
+			"updated_at":    time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to clear password: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified flags the user's email address as verified
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"is_email_verified": true,
+			"updated_at":        time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark email verified: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
 // UpdateLastLogin updates user's last login information
 func (r *userRepository) UpdateLastLogin(ctx context.Context, userID string) error {
 	if userID == "" {
@@ -288,43 +330,180 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, userID string) err
 	return nil
 }
 
-// IncrementFailedLogin increments failed login count
-func (r *userRepository) IncrementFailedLogin(ctx context.Context, userID string) error {
+// IncrementFailedLogin increments the persisted failed login count and returns the new value
+func (r *userRepository) IncrementFailedLogin(ctx context.Context, userID string) (int, error) {
 	if userID == "" {
-		return errors.New("userID cannot be empty")
+		return 0, errors.New("userID cannot be empty")
 	}
 
 	now := time.Now().UTC()
+	var user models.User
 
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var user models.User
-		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
-			return fmt.Errorf("failed to get user: %w", err)
-		}
-
-		user.FailedLoginCount++
-		user.LastFailedLogin = &now
-		user.UpdatedAt = now
-
-		// Lock account after 5 failed attempts for 1 hour
-		if user.FailedLoginCount >= 5 {
-			lockUntil := now.Add(time.Hour)
-			user.AccountLockedUntil = &lockUntil
-
-			// Log account locked event
-			r.secEventRepository.LogSecurityEvent(ctx, userID, types.EventAccountLocked, "", "",
-				map[string]interface{}{
-					"failed_attempts": user.FailedLoginCount,
-					"locked_until":    lockUntil,
-				})
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND deleted_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"failed_login_count": gorm.Expr("failed_login_count + 1"),
+				"last_failed_login":  &now,
+				"updated_at":         now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to increment failed login: %w", result.Error)
 		}
-
-		if err := tx.Save(&user).Error; err != nil {
-			return fmt.Errorf("failed to increment failed login: %w", err)
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
 		}
 
-		return nil
+		return tx.Select("failed_login_count").Where("id = ?", userID).First(&user).Error
 	})
+	if err != nil {
+		return 0, err
+	}
+
+	return user.FailedLoginCount, nil
+}
+
+// LockAccount locks the account until the given time
+func (r *userRepository) LockAccount(ctx context.Context, userID string, until time.Time) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"account_locked_until": until.UTC(),
+			"updated_at":           time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to lock account: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UnlockAccount lifts a lockout and resets the failed login count
+func (r *userRepository) UnlockAccount(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"failed_login_count":   0,
+			"last_failed_login":    nil,
+			"account_locked_until": nil,
+			"updated_at":           time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to unlock account: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateName changes the display name of a user
+func (r *userRepository) UpdateName(ctx context.Context, userID, name string) error {
+	if userID == "" || name == "" {
+		return errors.New("userID and name cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"name":       name,
+			"updated_at": time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update name: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ScheduleDeletion marks the user for deletion at the given time
+func (r *userRepository) ScheduleDeletion(ctx context.Context, userID string, at time.Time) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"deletion_scheduled_at": at.UTC(),
+			"updated_at":            time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CancelDeletion clears a pending deletion
+func (r *userRepository) CancelDeletion(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"deletion_scheduled_at": nil,
+			"updated_at":            time.Now().UTC(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetDueForDeletion returns the IDs of users whose deletion is scheduled before the given time
+func (r *userRepository) GetDueForDeletion(ctx context.Context, before time.Time) ([]string, error) {
+	var ids []string
+
+	err := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ? AND deleted_at IS NULL", before.UTC()).
+		Pluck("id", &ids).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users due for deletion: %w", err)
+	}
+
+	return ids, nil
 }
 
 func (r *userRepository) CreateAdminUser(ctx context.Context, user *domain.User, role string) error {
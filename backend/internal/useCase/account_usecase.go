@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+// defaultAccountDeletionGracePeriod applies when the server settings leave it unset
+const defaultAccountDeletionGracePeriod = time.Hour * 24 * 30
+
+type accountUseCase struct {
+	userRepo     domain.UserRepository
+	sessionRepo  domain.SessionRepository
+	secEventRepo domain.SecurityEventRepository
+	deletionRepo domain.AccountDeletionRepository
+	profileRepo  domain.UserProfileRepository
+	blobStore    domain.BlobStore
+	fmcService   *fire_base.FCMNotificationService
+	gracePeriod  time.Duration
+}
+
+func NewAccountUseCase(
+	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	secEventRepo domain.SecurityEventRepository,
+	deletionRepo domain.AccountDeletionRepository,
+	profileRepo domain.UserProfileRepository,
+	blobStore domain.BlobStore,
+	fmcService *fire_base.FCMNotificationService,
+	config utils.ServerSettings,
+) domain.AccountUseCase {
+	gracePeriod := config.AccountDeletionGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultAccountDeletionGracePeriod
+	}
+	return &accountUseCase{
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		secEventRepo: secEventRepo,
+		deletionRepo: deletionRepo,
+		profileRepo:  profileRepo,
+		blobStore:    blobStore,
+		fmcService:   fmcService,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+func (a *accountUseCase) DeleteAccount(ctx context.Context, req dto.DeleteAccountRequest) (*dto.AccountDeletionResponse, error) {
+	user, err := a.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Accounts created through an identity provider have no password to confirm
+	if user.PasswordHash != "" && !utils.VerifyPassword(req.Password, user.Salt, user.PasswordHash, utils.DefaultPasswordConfig) {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	deleteAt := time.Now().Add(a.gracePeriod).UTC()
+	if user.DeletionScheduledAt != nil {
+		deleteAt = *user.DeletionScheduledAt
+	} else if err := a.userRepo.ScheduleDeletion(ctx, user.ID, deleteAt); err != nil {
+		return nil, err
+	}
+
+	// The user has to log in again to cancel, which also proves it is them
+	if err := a.sessionRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		logger.Log.WithError(err).Error("Could not revoke sessions of deleted account")
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventDeletionScheduled, req.IPAddress, req.UserAgent, types.JSONMap{
+		"deletion_scheduled_at": deleteAt,
+	})
+
+	return &dto.AccountDeletionResponse{DeletionScheduledAt: deleteAt}, nil
+}
+
+func (a *accountUseCase) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	userIDs, err := a.userRepo.GetDueForDeletion(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, userID := range userIDs {
+		if err := a.purge(ctx, userID); err != nil {
+			logger.Log.WithError(err).WithField("user_id", userID).Error("Could not purge deleted account")
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, errors.Join(errs...)
+}
+
+func (a *accountUseCase) purge(ctx context.Context, userID string) error {
+	if err := a.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if a.fmcService != nil {
+		if err := a.fmcService.RemoveUserReminders(ctx, userID); err != nil {
+			return err
+		}
+		if err := a.fmcService.DeleteUserPreferences(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if profile, err := a.profileRepo.GetByUserID(ctx, userID); err == nil {
+		deleteAvatarBlobs(ctx, a.blobStore, profile.AvatarURL)
+	}
+	if err := a.deletionRepo.Purge(ctx, userID); err != nil {
+		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, userID, types.EventAccountDeleted, "", "", types.JSONMap{
+		"reason": "self_service",
+	})
+	return nil
+}
@@ -0,0 +1,442 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+)
+
+// Journal and challenge streaks are counted over the last year
+const achievementStreakYears = 1
+
+var challengeTypes = []string{
+	domain.ChallengeMorningPrayer,
+	domain.ChallengeScriptureMemorization,
+	domain.ChallengeActsOfService,
+	domain.ChallengeManhoodChallenge,
+}
+
+// metricSource is the kind of event that can move a metric
+func metricSource(metric string) string {
+	switch metric {
+	case domain.MetricJournalEntries, domain.MetricJournalStreak:
+		return domain.AchievementSourceJournal
+	case domain.MetricPuzzlesCompleted, domain.MetricPuzzlesCorrect, domain.MetricPuzzleStreak:
+		return domain.AchievementSourcePuzzle
+	case domain.MetricChallengesCompleted, domain.MetricChallengeStreak, domain.MetricChallengeTypesCompleted:
+		return domain.AchievementSourceChallenge
+	}
+	return ""
+}
+
+// criteriaTarget is the value the metric of criteria has to reach
+func criteriaTarget(criteria domain.AchievementCriteria) int {
+	if criteria.Threshold == 0 && criteria.Metric == domain.MetricChallengeTypesCompleted {
+		if len(criteria.Types) > 0 {
+			return len(criteria.Types)
+		}
+		return len(challengeTypes)
+	}
+	return criteria.Threshold
+}
+
+func validateAchievementCriteria(criteria domain.AchievementCriteria) error {
+	var types []string
+	switch criteria.Metric {
+	case domain.MetricJournalEntries:
+		types = utils.GetJournalEntryTypes()
+	case domain.MetricChallengeTypesCompleted:
+		types = challengeTypes
+	default:
+		if metricSource(criteria.Metric) == "" {
+			return fmt.Errorf("%w: unknown metric %q", domain.ErrInvalidAchievementCriteria, criteria.Metric)
+		}
+	}
+
+	for _, t := range criteria.Types {
+		if !slices.Contains(types, t) {
+			return fmt.Errorf("%w: %q is not a type of %s", domain.ErrInvalidAchievementCriteria, t, criteria.Metric)
+		}
+	}
+
+	target := criteriaTarget(criteria)
+	if target < 1 {
+		return fmt.Errorf("%w: threshold must be at least 1", domain.ErrInvalidAchievementCriteria)
+	}
+	if criteria.Metric == domain.MetricChallengeTypesCompleted {
+		if len(criteria.Types) > 0 {
+			types = criteria.Types
+		}
+		if target > len(types) {
+			return fmt.Errorf("%w: threshold is above the %d challenge types", domain.ErrInvalidAchievementCriteria, len(types))
+		}
+	}
+	return nil
+}
+
+// longestDailyStreak is the longest run of consecutive days among times
+func longestDailyStreak(times []time.Time) int {
+	days := make(map[string]bool)
+	for _, t := range times {
+		days[t.UTC().Format("2006-01-02")] = true
+	}
+
+	longest := 0
+	for day := range days {
+		date, _ := time.Parse("2006-01-02", day)
+		// Only count runs from their first day
+		if days[date.AddDate(0, 0, -1).Format("2006-01-02")] {
+			continue
+		}
+		streak := 1
+		for days[date.AddDate(0, 0, streak).Format("2006-01-02")] {
+			streak++
+		}
+		longest = max(longest, streak)
+	}
+	return longest
+}
+
+type achievementUseCase struct {
+	achievementRepo   domain.AchievementRepository
+	journalRepo       domain.JournalRepository
+	userPuzzleRepo    domain.UserPuzzleRepository
+	userChallengeRepo domain.UserChallengeRepository
+	inbox             domain.InboxUseCase
+	points            domain.PointsUseCase
+}
+
+func NewAchievementUseCase(
+	achievementRepo domain.AchievementRepository,
+	journalRepo domain.JournalRepository,
+	userPuzzleRepo domain.UserPuzzleRepository,
+	userChallengeRepo domain.UserChallengeRepository,
+	inbox domain.InboxUseCase,
+	points domain.PointsUseCase,
+) domain.AchievementUseCase {
+	return &achievementUseCase{
+		achievementRepo:   achievementRepo,
+		journalRepo:       journalRepo,
+		userPuzzleRepo:    userPuzzleRepo,
+		userChallengeRepo: userChallengeRepo,
+		inbox:             inbox,
+		points:            points,
+	}
+}
+
+// achievementMetrics measures the metrics of a user, loading each of them once
+type achievementMetrics struct {
+	uc     *achievementUseCase
+	userID string
+	values map[string]int
+
+	puzzleProgress      []domain.UserPuzzleProgress
+	puzzlesLoaded       bool
+	completedChallenges []domain.UserChallenge
+	challengesLoaded    bool
+}
+
+func (a *achievementUseCase) newMetrics(userID string) *achievementMetrics {
+	return &achievementMetrics{uc: a, userID: userID, values: make(map[string]int)}
+}
+
+func (m *achievementMetrics) value(ctx context.Context, criteria domain.AchievementCriteria) (int, error) {
+	types := slices.Clone(criteria.Types)
+	sort.Strings(types)
+	key := criteria.Metric + ":" + strings.Join(types, ",")
+	if value, ok := m.values[key]; ok {
+		return value, nil
+	}
+
+	value, err := m.measure(ctx, criteria.Metric, types)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %s: %w", criteria.Metric, err)
+	}
+	m.values[key] = value
+	return value, nil
+}
+
+func (m *achievementMetrics) measure(ctx context.Context, metric string, types []string) (int, error) {
+	switch metric {
+	case domain.MetricJournalEntries:
+		if len(types) == 0 {
+			count, err := m.uc.journalRepo.Count(ctx, m.userID)
+			return int(count), err
+		}
+		total := 0
+		for _, entryType := range types {
+			count, err := m.uc.journalRepo.CountByType(ctx, m.userID, entryType)
+			if err != nil {
+				return 0, err
+			}
+			total += int(count)
+		}
+		return total, nil
+
+	case domain.MetricJournalStreak:
+		since := time.Now().AddDate(-achievementStreakYears, 0, 0).Format("2006-01-02")
+		entries, err := m.uc.journalRepo.GetEntriesByUserIDAndDateRange(ctx, m.userID, since)
+		if err != nil {
+			return 0, err
+		}
+		days := make([]time.Time, 0, len(entries))
+		for _, entry := range entries {
+			days = append(days, entry.CreatedAt)
+		}
+		return longestDailyStreak(days), nil
+
+	case domain.MetricPuzzlesCompleted, domain.MetricPuzzlesCorrect, domain.MetricPuzzleStreak:
+		if err := m.loadPuzzles(); err != nil {
+			return 0, err
+		}
+		count := 0
+		var days []time.Time
+		for _, progress := range m.puzzleProgress {
+			correct := progress.IsCorrect != nil && *progress.IsCorrect
+			switch {
+			case metric == domain.MetricPuzzlesCompleted && progress.IsCompleted,
+				metric == domain.MetricPuzzlesCorrect && correct:
+				count++
+			case metric == domain.MetricPuzzleStreak && correct && progress.CompletedAt != nil:
+				days = append(days, *progress.CompletedAt)
+			}
+		}
+		if metric == domain.MetricPuzzleStreak {
+			return longestDailyStreak(days), nil
+		}
+		return count, nil
+
+	case domain.MetricChallengesCompleted:
+		if err := m.loadChallenges(); err != nil {
+			return 0, err
+		}
+		return len(m.completedChallenges), nil
+
+	case domain.MetricChallengeStreak:
+		if err := m.loadChallenges(); err != nil {
+			return 0, err
+		}
+		since := time.Now().AddDate(-achievementStreakYears, 0, 0)
+		var days []time.Time
+		for _, challenge := range m.completedChallenges {
+			if challenge.CompletedAt != nil && challenge.CompletedAt.After(since) {
+				days = append(days, *challenge.CompletedAt)
+			}
+		}
+		return longestDailyStreak(days), nil
+
+	case domain.MetricChallengeTypesCompleted:
+		completed, err := m.uc.achievementRepo.CompletedChallengeTypes(ctx, m.userID)
+		if err != nil {
+			return 0, err
+		}
+		if len(types) == 0 {
+			return len(completed), nil
+		}
+		count := 0
+		for _, challengeType := range types {
+			if slices.Contains(completed, challengeType) {
+				count++
+			}
+		}
+		return count, nil
+	}
+	return 0, fmt.Errorf("%w: unknown metric %q", domain.ErrInvalidAchievementCriteria, metric)
+}
+
+func (m *achievementMetrics) loadPuzzles() error {
+	if m.puzzlesLoaded {
+		return nil
+	}
+	progress, err := m.uc.userPuzzleRepo.GetUserPuzzleProgressByUserID(m.userID)
+	if err != nil {
+		return err
+	}
+	m.puzzleProgress, m.puzzlesLoaded = progress, true
+	return nil
+}
+
+func (m *achievementMetrics) loadChallenges() error {
+	if m.challengesLoaded {
+		return nil
+	}
+	challenges, err := m.uc.userChallengeRepo.GetCompletedChallenges(m.userID, 0)
+	if err != nil {
+		return err
+	}
+	m.completedChallenges, m.challengesLoaded = challenges, true
+	return nil
+}
+
+// earnedAchievements maps the achievements a user earned to when
+func (a *achievementUseCase) earnedAchievements(ctx context.Context, userID string) (map[string]time.Time, error) {
+	userAchievements, err := a.achievementRepo.GetUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	earned := make(map[string]time.Time, len(userAchievements))
+	for _, userAchievement := range userAchievements {
+		earned[userAchievement.Achievement.ID] = userAchievement.EarnedAt
+	}
+	return earned, nil
+}
+
+// EvaluateAchievements only measures the metrics source can move, so a
+// journal entry never reloads the puzzle history
+func (a *achievementUseCase) EvaluateAchievements(ctx context.Context, userID, source string) ([]domain.Achievement, error) {
+	achievements, err := a.achievementRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	earned, err := a.earnedAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := a.newMetrics(userID)
+	awarded := []domain.Achievement{}
+	now := time.Now().UTC()
+	for _, achievement := range achievements {
+		if _, ok := earned[achievement.ID]; ok || metricSource(achievement.Criteria.Metric) != source {
+			continue
+		}
+
+		progress, err := metrics.value(ctx, achievement.Criteria)
+		if err != nil {
+			return awarded, err
+		}
+		if progress < criteriaTarget(achievement.Criteria) {
+			continue
+		}
+
+		isNew, err := a.achievementRepo.Award(ctx, userID, achievement.ID, now)
+		if err != nil {
+			return awarded, err
+		}
+		if isNew {
+			awarded = append(awarded, achievement)
+			reason := fmt.Sprintf("%s: %s", reasonAchievementEarned, achievement.Name)
+			if err := a.points.Award(ctx, userID, domain.PointsSourceAchievement, achievement.ID, achievement.Points, reason); err != nil {
+				logger.Log.WithError(err).WithField("achievement_id", achievement.ID).Error("Could not award achievement points")
+			}
+			a.addToInbox(ctx, userID, achievement)
+		}
+	}
+	return awarded, nil
+}
+
+func (a *achievementUseCase) addToInbox(ctx context.Context, userID string, achievement domain.Achievement) {
+	notification := domain.InboxNotification{
+		Category: domain.InboxAchievement,
+		Title:    "Achievement unlocked",
+		Body:     achievement.Name,
+		Data:     map[string]string{"type": "achievement", "achievement_id": achievement.ID},
+	}
+	if err := a.inbox.AddToInbox(ctx, []string{userID}, notification); err != nil {
+		logger.Log.WithError(err).WithField("achievement_id", achievement.ID).Error("Could not add achievement to the inbox")
+	}
+}
+
+// ListAchievements shows every active achievement with the progress of the
+// user towards it
+func (a *achievementUseCase) ListAchievements(ctx context.Context, userID string) ([]domain.AchievementProgress, error) {
+	achievements, err := a.achievementRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	earned, err := a.earnedAchievements(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := a.newMetrics(userID)
+	list := make([]domain.AchievementProgress, 0, len(achievements))
+	for _, achievement := range achievements {
+		target := criteriaTarget(achievement.Criteria)
+		entry := domain.AchievementProgress{Achievement: achievement, Target: target}
+		if earnedAt, ok := earned[achievement.ID]; ok {
+			entry.Earned, entry.EarnedAt, entry.Progress = true, &earnedAt, target
+		} else {
+			progress, err := metrics.value(ctx, achievement.Criteria)
+			if err != nil {
+				return nil, err
+			}
+			entry.Progress = min(progress, target)
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+func (a *achievementUseCase) GetUserAchievements(ctx context.Context, userID string) ([]domain.UserAchievement, error) {
+	return a.achievementRepo.GetUserAchievements(ctx, userID)
+}
+
+func (a *achievementUseCase) ListAllAchievements(ctx context.Context) ([]domain.Achievement, error) {
+	return a.achievementRepo.List(ctx, false)
+}
+
+func (a *achievementUseCase) GetAchievement(ctx context.Context, id string) (*domain.Achievement, error) {
+	return a.achievementRepo.GetByID(ctx, id)
+}
+
+func achievementFromRequest(req dto.AchievementRequest) (domain.Achievement, error) {
+	criteria := domain.AchievementCriteria{
+		Metric:    req.Criteria.Metric,
+		Threshold: req.Criteria.Threshold,
+		Types:     req.Criteria.Types,
+	}
+	if err := validateAchievementCriteria(criteria); err != nil {
+		return domain.Achievement{}, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return domain.Achievement{
+		Name:        req.Name,
+		Description: req.Description,
+		IconName:    req.IconName,
+		BadgeColor:  req.BadgeColor,
+		Points:      req.Points,
+		Criteria:    criteria,
+		IsActive:    isActive,
+		SortOrder:   req.SortOrder,
+	}, nil
+}
+
+func (a *achievementUseCase) CreateAchievement(ctx context.Context, req dto.AchievementRequest) (*domain.Achievement, error) {
+	achievement, err := achievementFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	achievement.ID = utils.GenerateID()
+	if err := a.achievementRepo.Create(ctx, &achievement); err != nil {
+		return nil, err
+	}
+	return &achievement, nil
+}
+
+func (a *achievementUseCase) UpdateAchievement(ctx context.Context, id string, req dto.AchievementRequest) (*domain.Achievement, error) {
+	achievement, err := achievementFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	achievement.ID = id
+	if err := a.achievementRepo.Update(ctx, &achievement); err != nil {
+		return nil, err
+	}
+	return a.achievementRepo.GetByID(ctx, id)
+}
+
+func (a *achievementUseCase) DeleteAchievement(ctx context.Context, id string) error {
+	return a.achievementRepo.Delete(ctx, id)
+}
@@ -13,26 +13,75 @@ import (
 )
 
 type adminUserUseCase struct {
-	adminRepo    domain.AdminUserRepository
-	userRepo     domain.UserRepository
-	emailService domain.EmailService
-	config       utils.ServerSettings
+	adminRepo        domain.AdminUserRepository
+	userRepo         domain.UserRepository
+	sessionRepo      domain.SessionRepository
+	secEventRepo     domain.SecurityEventRepository
+	loginAttemptRepo domain.LoginAttemptRepository
+	emailService     domain.EmailService
+	config           utils.ServerSettings
 }
 
 func NewAdminUserUseCase(
 	adminRepo domain.AdminUserRepository,
 	userRepo domain.UserRepository,
+	sessionRepo domain.SessionRepository,
+	secEventRepo domain.SecurityEventRepository,
+	loginAttemptRepo domain.LoginAttemptRepository,
 	emailService domain.EmailService,
 ) domain.AdminUserUseCase {
 	return &adminUserUseCase{
-		adminRepo:    adminRepo,
-		userRepo:     userRepo,
-		emailService: emailService,
+		adminRepo:        adminRepo,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		secEventRepo:     secEventRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		emailService:     emailService,
 	}
 }
 func (r *adminUserUseCase) GetUserByID(ctx context.Context, userID string) (*domain.User, error) {
 	return r.userRepo.GetByID(ctx, userID)
 }
+func (uc *adminUserUseCase) GetUserSessions(ctx context.Context, userID string) ([]dto.SessionResponse, error) {
+	if _, err := uc.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return listActiveSessions(ctx, uc.sessionRepo, userID, "")
+}
+
+func (uc *adminUserUseCase) RevokeUserSession(ctx context.Context, req dto.RevokeSessionRequest) error {
+	return revokeSession(ctx, uc.sessionRepo, uc.secEventRepo, req)
+}
+
+func (uc *adminUserUseCase) RevokeAllUserSessions(ctx context.Context, req dto.LogoutAllRequest) error {
+	if _, err := uc.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return err
+	}
+	return revokeAllSessions(ctx, uc.sessionRepo, uc.secEventRepo, req)
+}
+
+// UnlockUser lifts a lockout and clears the failure counters before the lock expires
+func (uc *adminUserUseCase) UnlockUser(ctx context.Context, req dto.UnlockUserRequest) error {
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.UnlockAccount(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := uc.loginAttemptRepo.ResetEmail(ctx, user.Email); err != nil {
+		logger.Log.WithError(err).Error("Could not reset login failure window")
+	}
+
+	uc.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountUnlocked, req.IPAddress, req.UserAgent, types.JSONMap{
+		"reason":      "admin_unlock",
+		"unlocked_by": req.UnlockedBy,
+	})
+	return nil
+}
+
 func (uc *adminUserUseCase) GetAllUsers(
 	ctx context.Context,
 	filter dto.UserListFilter,
@@ -2,14 +2,16 @@ package usecase
 
 import (
 	"context"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/internal/handlers/dto"
 	"yefe_app/v1/pkg/logger"
 	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/tokens"
 	"yefe_app/v1/pkg/types"
 	"yefe_app/v1/pkg/utils"
 
@@ -18,13 +20,22 @@ import (
 )
 
 type authUseCase struct {
-	userRepo     domain.UserRepository
-	sessionRepo  domain.SessionRepository
-	secEventRepo domain.SecurityEventRepository
-	//emailService    EmailService
-	passwordChecker types.PasswordChecker
-	jwtSecret       string
-	fmcService      *fire_base.FCMNotificationService
+	userRepo         domain.UserRepository
+	sessionRepo      domain.SessionRepository
+	secEventRepo     domain.SecurityEventRepository
+	verificationRepo domain.EmailVerificationRepository
+	resetRepo        domain.PasswordResetRepository
+	emailService     domain.EmailService
+	passwordChecker  types.PasswordChecker
+	jwtKeys          *tokens.KeySet
+	fmcService       *fire_base.FCMNotificationService
+	config           utils.ServerSettings
+	mfaRepo          domain.MFARepository
+	mfaChallengeRepo domain.MFAChallengeRepository
+	identityRepo     domain.UserIdentityRepository
+	oauthNonceRepo   domain.OAuthNonceRepository
+	oidcVerifiers    map[string]domain.IDTokenVerifier
+	loginGuard       *loginGuard
 }
 
 var (
@@ -33,22 +44,60 @@ var (
 	timeLayout = "15:04"
 )
 
+const (
+	// accessTokenTTL is the lifetime of an issued JWT
+	accessTokenTTL = time.Hour * 24
+	// refreshTokenTTL is how long a session can be kept alive through refreshes
+	refreshTokenTTL = time.Hour * 24 * 30
+
+	// verificationTokenTTL is how long an email verification link stays valid
+	verificationTokenTTL = time.Hour * 24
+	// verificationResendInterval is the minimum wait between two verification emails
+	verificationResendInterval = time.Minute * 2
+	// maxVerificationEmailsPerDay caps the verification emails sent to one user in 24 hours
+	maxVerificationEmailsPerDay = 5
+
+	// passwordResetTokenTTL is how long a password reset link stays valid
+	passwordResetTokenTTL = time.Hour
+	// maxPasswordResetsPerHour caps the reset emails sent to one user in an hour
+	maxPasswordResetsPerHour = 3
+)
+
 func NewAuthUseCase(
 	userRepo domain.UserRepository,
 	sessionRepo domain.SessionRepository,
 	secEventRepo domain.SecurityEventRepository,
-	jwtSecret string,
+	verificationRepo domain.EmailVerificationRepository,
+	resetRepo domain.PasswordResetRepository,
+	loginAttemptRepo domain.LoginAttemptRepository,
+	mfaRepo domain.MFARepository,
+	mfaChallengeRepo domain.MFAChallengeRepository,
+	identityRepo domain.UserIdentityRepository,
+	oauthNonceRepo domain.OAuthNonceRepository,
+	oidcVerifiers map[string]domain.IDTokenVerifier,
+	emailService domain.EmailService,
+	jwtKeys *tokens.KeySet,
 	fmcService *fire_base.FCMNotificationService,
+	config utils.ServerSettings,
 
 ) domain.AuthUseCase {
 	return &authUseCase{
-		userRepo:     userRepo,
-		sessionRepo:  sessionRepo,
-		secEventRepo: secEventRepo,
-		//	emailService:    emailService,
-		passwordChecker: utils.NewBasicPasswordChecker(),
-		jwtSecret:       jwtSecret,
-		fmcService:      fmcService,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		secEventRepo:     secEventRepo,
+		verificationRepo: verificationRepo,
+		resetRepo:        resetRepo,
+		emailService:     emailService,
+		passwordChecker:  utils.NewBasicPasswordChecker(),
+		jwtKeys:          jwtKeys,
+		fmcService:       fmcService,
+		config:           config,
+		mfaRepo:          mfaRepo,
+		mfaChallengeRepo: mfaChallengeRepo,
+		identityRepo:     identityRepo,
+		oauthNonceRepo:   oauthNonceRepo,
+		oidcVerifiers:    oidcVerifiers,
+		loginGuard:       newLoginGuard(userRepo, loginAttemptRepo, secEventRepo),
 	}
 }
 
@@ -59,6 +108,15 @@ func (a *authUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*d
 		return nil, domain.ErrWeakPassword
 	}
 
+	timezone, err := utils.NormalizeTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	prefs := notificationsPrefFromRequest(req.Prefs)
+	if err := prefs.QuietHours.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidQuietHours, err)
+	}
+
 	// Check if email exists
 	if _, err := a.userRepo.GetByEmail(ctx, req.Email); err == nil {
 		return nil, domain.ErrEmailAlreadyExists
@@ -81,46 +139,119 @@ func (a *authUseCase) Register(ctx context.Context, req dto.RegisterRequest) (*d
 
 	user.DowngradeToFree()
 
-	prefs := req.Prefs
-
-	reminders := types.ReminderRequest{
-		MorningReminder: types.ReminderStr(prefs.Reminders.MorningReminder),
-		EveningReminder: types.ReminderStr(prefs.Reminders.EveningReminder),
-	}
-
-	userPrefs := &types.NotificationsPref{
-		MorningPrompt:     prefs.MorningPrompt,
-		EveningReflection: prefs.EveningReflection,
-		Challenge:         prefs.Challenge,
-		Language:          prefs.Language,
-		Reminders:         reminders,
-	}
-
-	if err := a.userRepo.Create(ctx, user, *userPrefs); err != nil {
+	if err := a.userRepo.Create(ctx, user, prefs, timezone); err != nil {
 		logger.Log.WithError(err).Error("error creating user")
 		return nil, err
 	}
 
-	// Send email verification
-	//go a.sendEmailVerification(user)
-
 	// Log security event
 	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountCreated, req.IPAddress, req.UserAgent, types.JSONMap{
 		"message": "User created",
 	})
 
+	a.scheduleReminders(ctx, user.ID, prefs, timezone)
+
+	// Send email verification, the account is usable even if this fails
+	if err := a.sendEmailVerification(ctx, user); err != nil {
+		logger.Log.WithError(err).Error("Could not send verification email")
+	}
+
 	return user, nil
 }
 
-func (a *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+// scheduleReminders starts the daily reminders of a new account. They reach
+// its inbox before the user accepts push notifications on a device.
+func (a *authUseCase) scheduleReminders(ctx context.Context, userID string, prefs types.NotificationsPref, timezone string) {
+	if a.fmcService == nil {
+		return
+	}
+	if err := a.fmcService.SyncUserReminders(ctx, userID, prefs, timezone); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not schedule reminders")
+	}
+}
+
+func (a *authUseCase) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error {
+	token, err := a.verificationRepo.GetByToken(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return domain.ErrInvalidToken
+	}
+
+	if token.Used || time.Now().After(token.ExpiresAt) {
+		return domain.ErrInvalidToken
+	}
+
+	if err := a.verificationRepo.MarkUsed(ctx, token.ID); err != nil {
+		return err
+	}
+
+	if err := a.userRepo.MarkEmailVerified(ctx, token.UserID); err != nil {
+		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, token.UserID, types.EventEmailVerified, req.IPAddress, req.UserAgent, nil)
+	return nil
+}
+
+func (a *authUseCase) ResendVerificationEmail(ctx context.Context, user *domain.User) error {
+	if user.IsEmailVerified {
+		return domain.ErrEmailVerified
+	}
+
+	// Throttle resends per user
+	latest, err := a.verificationRepo.GetLatestByUserID(ctx, user.ID)
+	if err == nil && time.Since(latest.CreatedAt) < verificationResendInterval {
+		return domain.ErrRateLimitExceeded
+	}
+
+	sent, err := a.verificationRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour*24))
+	if err != nil {
+		return err
+	}
+	if sent >= maxVerificationEmailsPerDay {
+		return domain.ErrRateLimitExceeded
+	}
+
+	return a.sendEmailVerification(ctx, user)
+}
 
-	// Find user by email or username
-	var user *domain.User
-	var err error
+// sendEmailVerification issues a new verification token and emails its link to the user
+func (a *authUseCase) sendEmailVerification(ctx context.Context, user *domain.User) error {
+	rawToken := utils.GenerateSecureToken()
+	token := &domain.EmailVerificationToken{
+		ID:        utils.GenerateID(),
+		UserID:    user.ID,
+		Token:     utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+		CreatedAt: time.Now(),
+	}
 
-	user, err = a.userRepo.GetByEmail(ctx, req.Email)
+	if err := a.verificationRepo.Create(ctx, token); err != nil {
+		return err
+	}
 
+	verificationLink := fmt.Sprintf("%s/v1/auth/verify-email?token=%s", a.config.PublicURL(), url.QueryEscape(rawToken))
+	err := a.emailService.SendEmailVerification(ctx, dto.EmailVerificationEmailData{
+		Name:             user.Name,
+		Email:            user.Email,
+		VerificationLink: verificationLink,
+		ExpiresAt:        token.ExpiresAt,
+	})
 	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventEmailVerification, "", "", nil)
+	return nil
+}
+
+func (a *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	if err := a.loginGuard.allow(ctx, req.IPAddress, req.Email); err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		a.loginGuard.recordFailure(ctx, nil, req.IPAddress, req.UserAgent, req.Email)
 		return nil, domain.ErrInvalidCredentials
 	}
 
@@ -131,50 +262,70 @@ func (a *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 
 	// Check if account is active
 	if !user.IsActive {
-		logger.Log.WithError(err).Error("Account inactive")
+		logger.Log.Error("Account inactive")
 		return nil, domain.ErrAccountInactive
 	}
 
 	// Verify password
 	if !utils.VerifyPassword(req.Password, user.Salt, user.PasswordHash, utils.DefaultPasswordConfig) {
-		user.FailedLoginCount++
-		user.LastFailedLogin = &time.Time{}
-		*user.LastFailedLogin = time.Now()
-
-		// Lock account after 5 failed attempts TODO this does not make any sense
-		if user.FailedLoginCount >= 5 {
-			lockUntil := time.Now().Add(time.Hour * 1)
-			user.AccountLockedUntil = &lockUntil
-			a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountLocked, req.IPAddress, req.UserAgent, nil)
-		}
-
-		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventLoginFailed, req.IPAddress, req.UserAgent, nil)
-		logger.Log.WithError(err).Error("Invalid password or email")
+		a.loginGuard.recordFailure(ctx, user, req.IPAddress, req.UserAgent, req.Email)
+		logger.Log.Error("Invalid password or email")
 		return nil, domain.ErrInvalidCredentials
 	}
 
-	// Reset failed login count on successful login
-	user.FailedLoginCount = 0
-	user.LastFailedLogin = nil
-	user.AccountLockedUntil = nil
-	user.LastLoginAt = &time.Time{}
-	*user.LastLoginAt = time.Now()
-	user.LastLoginIP = req.IPAddress
-	//a.userRepo.Update(ctx, user)
-
-	// Create session
-	session := &domain.Session{
-		ID:           uuid.New().String(),
-		UserID:       user.ID,
-		Token:        utils.GenerateSecureToken(),
-		RefreshToken: utils.GenerateSecureToken(),
-		ExpiresAt:    time.Now().Add(time.Hour * 24), // 24 hours
-		IPAddress:    req.IPAddress,
-		UserAgent:    req.UserAgent,
-		IsActive:     true,
-		CreatedAt:    time.Now(),
+	return a.completeLogin(ctx, user, req.IPAddress, req.UserAgent, req.DeviceName)
+}
+
+// completeLogin runs once the first factor checked out. It either starts a
+// two-factor challenge or creates the session. The login only counts as a
+// success once there is a session, so the failure window of the email is not
+// cleared before the second factor.
+func (a *authUseCase) completeLogin(ctx context.Context, user *domain.User, ipAddress, userAgent, deviceName string) (*dto.LoginResponse, error) {
+	deviceLabel := strings.TrimSpace(deviceName)
+	if deviceLabel == "" {
+		deviceLabel = utils.DeviceLabelFromUserAgent(userAgent)
 	}
 
+	mfa, err := a.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrMFANotEnabled) {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return a.startMFAChallenge(ctx, user, &domain.MFAChallenge{
+			UserID:      user.ID,
+			IPAddress:   ipAddress,
+			UserAgent:   userAgent,
+			DeviceLabel: deviceLabel,
+		})
+	}
+
+	response, err := a.createSession(ctx, user, &domain.Session{
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		DeviceLabel: deviceLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.loginGuard.recordSuccess(ctx, user, ipAddress, userAgent)
+
+	// Admins get a session but AdminOnly rejects it until two-factor is set up
+	response.MFAEnrollmentRequired = user.IsAdmin()
+	return response, nil
+}
+
+// createSession fills in and stores a new session for the user, the caller
+// provides the client details, and returns the tokens for it
+func (a *authUseCase) createSession(ctx context.Context, user *domain.User, session *domain.Session) (*dto.LoginResponse, error) {
+	session.ID = uuid.New().String()
+	session.UserID = user.ID
+	session.Token = utils.GenerateSecureToken()
+	session.RefreshToken = utils.GenerateSecureToken()
+	session.ExpiresAt = time.Now().Add(refreshTokenTTL)
+	session.IsActive = true
+	session.CreatedAt = time.Now()
+	session.LastSeenAt = time.Now()
+
 	if err := a.sessionRepo.Create(ctx, session); err != nil {
 		return nil, err
 	}
@@ -185,16 +336,74 @@ func (a *authUseCase) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 		return nil, err
 	}
 
-	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventLogin, req.IPAddress, req.UserAgent, nil)
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventLogin, session.IPAddress, session.UserAgent, types.JSONMap{
+		"session_id":   session.ID,
+		"mfa_verified": session.MFAVerified,
+	})
 
 	err = a.userRepo.UpdateLastLogin(ctx, user.ID)
 	if err != nil {
 		logger.Log.WithError(err).Error("Could not update last login")
 	}
+	response := &dto.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
+	}
+
+	// Logging back in during the grace period restores a deleted account
+	if user.DeletionScheduledAt != nil {
+		if err := a.userRepo.CancelDeletion(ctx, user.ID); err != nil {
+			logger.Log.WithError(err).Error("Could not cancel account deletion")
+		} else {
+			a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventDeletionCancelled, session.IPAddress, session.UserAgent, types.JSONMap{
+				"session_id": session.ID,
+			})
+			response.DeletionCancelled = true
+		}
+	}
+	return response, nil
+}
+
+func (a *authUseCase) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (*dto.LoginResponse, error) {
+	session, err := a.sessionRepo.RotateRefreshToken(ctx, req.RefreshToken, utils.GenerateSecureToken(), time.Now().Add(refreshTokenTTL))
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		// A rotated token being presented again means it leaked, revoke the whole session
+		if delErr := a.sessionRepo.Delete(ctx, session.ID); delErr != nil {
+			logger.Log.WithError(delErr).Error("Could not revoke session after refresh token reuse")
+		}
+		a.secEventRepo.LogSecurityEvent(ctx, session.UserID, types.EventRefreshTokenReuse, req.IPAddress, req.UserAgent, types.JSONMap{
+			"session_id": session.ID,
+			"action":     "session_revoked",
+		})
+		return nil, domain.ErrRefreshTokenReused
+	}
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not rotate refresh token")
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := a.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	if !user.IsActive {
+		return nil, domain.ErrAccountInactive
+	}
+
+	accessToken, err := a.generateJWT(user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventTokenRefreshed, req.IPAddress, req.UserAgent, types.JSONMap{
+		"session_id": session.ID,
+	})
+
 	return &dto.LoginResponse{
 		AccessToken:  accessToken,
 		RefreshToken: session.RefreshToken,
-		ExpiresIn:    int64(time.Hour * 24 / time.Second),
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
 	}, nil
 }
 
@@ -234,73 +443,201 @@ func (a *authUseCase) Logout(ctx context.Context, req dto.LogoutRequest) error {
 	return nil
 }
 
-func (a *authUseCase) AcceptNotificaions(ctx context.Context, fcmToken string, user *domain.User) error {
+func (a *authUseCase) LogoutAll(ctx context.Context, req dto.LogoutAllRequest) error {
+	return revokeAllSessions(ctx, a.sessionRepo, a.secEventRepo, req)
+}
+
+func (a *authUseCase) ListSessions(ctx context.Context, userID, currentSessionID string) ([]dto.SessionResponse, error) {
+	return listActiveSessions(ctx, a.sessionRepo, userID, currentSessionID)
+}
 
-	preferences := fire_base.FCMUserPreferences{
-		FCMToken:    fcmToken,
-		MorningTime: user.Profile.NotificationPreferences.Reminders.MorningReminder.String(),
-		EveningTime: user.Profile.NotificationPreferences.Reminders.EveningReminder.String(),
-		Timezone:    "America/New_York",
+func (a *authUseCase) RevokeSession(ctx context.Context, req dto.RevokeSessionRequest) error {
+	return revokeSession(ctx, a.sessionRepo, a.secEventRepo, req)
+}
+
+// ForgotPassword emails a single-use reset link. Unknown emails are ignored,
+// and an email that could not be sent is only logged, so the endpoint cannot be
+// used to discover registered accounts.
+func (a *authUseCase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	user, err := a.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return err
 	}
-	if err := a.fmcService.UpdateUserPreferences(ctx, user.ID, preferences); err != nil {
-		logger.Log.WithError(err).Error("Failed to update user preferences")
+
+	if !user.IsActive {
+		return nil
 	}
 
-	morningTime := strings.Split(preferences.MorningTime, ":")
-	evnT, err := fire_base.ConvertTo24Hour(preferences.EveningTime)
+	sent, err := a.resetRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
 	if err != nil {
 		return err
 	}
+	if sent >= maxPasswordResetsPerHour {
+		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordReset, req.IPAddress, req.UserAgent, types.JSONMap{
+			"step":   "requested",
+			"result": "throttled",
+		})
+		return nil
+	}
+
+	// Only the most recent link should work
+	if err := a.resetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	rawToken := utils.GenerateSecureToken()
+	token := &domain.PasswordResetToken{
+		ID:        utils.GenerateID(),
+		UserID:    user.ID,
+		Token:     utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := a.resetRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	// The link opens the form served by GET /v1/auth/reset-password
+	resetLink := fmt.Sprintf("%s/v1/auth/reset-password?token=%s", a.config.PublicURL(), url.QueryEscape(rawToken))
+	err = a.emailService.SendPasswordReset(ctx, dto.PasswordResetEmailData{
+		Name:      user.Name,
+		Email:     user.Email,
+		ResetLink: resetLink,
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		logger.Log.WithError(err).WithField("user_id", user.ID).Error("Failed to send password reset email")
+		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordReset, req.IPAddress, req.UserAgent, types.JSONMap{
+			"step":   "requested",
+			"result": "email_failed",
+		})
+		return nil
+	}
 
-	eveningTime := strings.Split(evnT, ":")
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordReset, req.IPAddress, req.UserAgent, types.JSONMap{
+		"step": "requested",
+	})
+	return nil
+}
 
-	morningHour := morningTime[0]
-	morningMinute := morningTime[1]
+func (a *authUseCase) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	token, err := a.resetRepo.GetByToken(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return domain.ErrInvalidToken
+	}
 
-	eveningHour := eveningTime[0]
-	eveningMinute := eveningTime[1]
+	if token.Used || time.Now().After(token.ExpiresAt) {
+		a.secEventRepo.LogSecurityEvent(ctx, token.UserID, types.EventPasswordReset, req.IPAddress, req.UserAgent, types.JSONMap{
+			"step":   "completed",
+			"result": "invalid_token",
+		})
+		return domain.ErrInvalidToken
+	}
 
-	morningCron := fmt.Sprintf("0 %s %s * * *", morningMinute, morningHour)
-	eveningCron := fmt.Sprintf("0 %s %s * * *", eveningMinute, eveningHour)
-	if err := a.fmcService.AddRecurringNotification(
-		utils.GenerateID()+utils.GenerateSecureToken(),
-		preferences.UserID,
-		"Daily Motivation",
-		"Here's your daily dose of motivation!",
-		morningCron,
-		map[string]string{"type": "daily"}); err != nil {
-		logger.Log.WithError(err).Error("Failed to add recurring notification")
+	if !a.passwordChecker.IsStrong(req.NewPassword) {
+		return domain.ErrWeakPassword
+	}
+
+	user, err := a.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
 		return err
 	}
-	if err := a.fmcService.AddRecurringNotification(
-		utils.GenerateID()+utils.GenerateSecureToken(),
-		preferences.UserID,
-		"Daily Motivation",
-		"Here's your daily dose of motivation!",
-		eveningCron,
-		map[string]string{"type": "daily"},
-	); err != nil {
-		logger.Log.WithError(err).Error("Failed to add recurring notification")
+
+	// Consume the token before touching the password so it cannot be replayed
+	if err := a.resetRepo.MarkUsed(ctx, token.ID); err != nil {
+		return err
+	}
+
+	if err := a.setPassword(ctx, user.ID, req.NewPassword); err != nil {
+		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordReset, req.IPAddress, req.UserAgent, types.JSONMap{
+		"step": "completed",
+	})
+
+	// Resetting the password also lifts a lockout
+	if user.AccountLockedUntil != nil && time.Now().Before(*user.AccountLockedUntil) {
+		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountUnlocked, req.IPAddress, req.UserAgent, types.JSONMap{
+			"reason": "password_reset",
+		})
+	}
+
+	return nil
+}
+
+func (a *authUseCase) ChangePassword(ctx context.Context, req dto.ChangePasswordRequest) error {
+	user, err := a.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !utils.VerifyPassword(req.CurrentPassword, user.Salt, user.PasswordHash, utils.DefaultPasswordConfig) {
+		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordChange, req.IPAddress, req.UserAgent, types.JSONMap{
+			"result": "invalid_current_password",
+		})
+		return domain.ErrInvalidCredentials
+	}
+
+	if !a.passwordChecker.IsStrong(req.NewPassword) {
+		return domain.ErrWeakPassword
+	}
+
+	if err := a.setPassword(ctx, user.ID, req.NewPassword); err != nil {
 		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventPasswordChange, req.IPAddress, req.UserAgent, types.JSONMap{
+		"result": "success",
+	})
+	return nil
+}
+
+// setPassword stores a new password hash and revokes every session of the user
+func (a *authUseCase) setPassword(ctx context.Context, userID, password string) error {
+	salt := utils.GenerateSalt(utils.DefaultPasswordConfig.SaltLength)
+	passwordHash := utils.HashPassword(password, salt, utils.DefaultPasswordConfig)
+
+	if err := a.userRepo.UpdatePassword(ctx, userID, passwordHash, salt); err != nil {
+		return err
+	}
 
+	if err := a.resetRepo.InvalidateByUserID(ctx, userID); err != nil {
+		logger.Log.WithError(err).Error("Could not invalidate password reset tokens")
+	}
+
+	if err := a.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		logger.Log.WithError(err).Error("Could not revoke sessions after password update")
+		return err
 	}
 	return nil
+}
 
+// AcceptNotificaions registers the device of the token and schedules the
+// user's reminders on it
+func (a *authUseCase) AcceptNotificaions(ctx context.Context, req dto.AcceptNotificationRequest, user *domain.User) error {
+	device := fire_base.DeviceToken{
+		Token:      req.FcmToken,
+		Platform:   req.Platform,
+		AppVersion: req.AppVersion,
+	}
+	if _, err := a.fmcService.RegisterDevice(ctx, user.ID, device, user.Profile.NotificationPreferences, user.Profile.Timezone); err != nil {
+		logger.Log.WithError(err).Error("Failed to register notification device")
+		return err
+	}
+	return nil
 }
 
 func (a *authUseCase) generateJWT(userID, sessionID string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":    userID,
 		"session_id": sessionID,
-		"exp":        time.Now().Add(time.Hour * 24).Unix(),
+		"exp":        time.Now().Add(accessTokenTTL).Unix(),
 		"iat":        time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret, err := hex.DecodeString(a.jwtSecret)
-	if err != nil {
-		return "", fmt.Errorf("invalid JWT secret encoding: %w", err)
-	}
-
-	return token.SignedString(secret)
+	return a.jwtKeys.Sign(claims)
 }
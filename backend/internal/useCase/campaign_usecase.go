@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	// maxCampaignDataSize keeps the data well within the 4KB FCM payload limit
+	maxCampaignDataSize = 2048
+	campaignJobPrefix   = "campaign-"
+)
+
+// reservedDataKeys may not be used in the data of an FCM message
+var reservedDataKeys = []string{"from", "notification", "message_type"}
+
+type campaignUseCase struct {
+	campaignRepo domain.CampaignRepository
+	inbox        domain.InboxUseCase
+	fmcService   *fire_base.FCMNotificationService
+}
+
+func NewCampaignUseCase(campaignRepo domain.CampaignRepository, inbox domain.InboxUseCase, fmcService *fire_base.FCMNotificationService) domain.CampaignUseCase {
+	return &campaignUseCase{campaignRepo: campaignRepo, inbox: inbox, fmcService: fmcService}
+}
+
+// campaignJobID is the scheduler job of a campaign, and its name in the
+// notification delivery stats
+func campaignJobID(id string) string {
+	return campaignJobPrefix + id
+}
+
+// CreateCampaign stores a campaign and schedules it, for now when it has no
+// scheduled time
+func (c *campaignUseCase) CreateCampaign(ctx context.Context, req dto.CreateCampaignRequest) (*domain.Campaign, error) {
+	if c.fmcService == nil {
+		return nil, errNotificationsDisabled
+	}
+	if err := validateCampaignData(req.Data); err != nil {
+		return nil, err
+	}
+
+	campaign := &domain.Campaign{
+		ID:          utils.GenerateID(),
+		Title:       req.Title,
+		Body:        req.Body,
+		Data:        req.Data,
+		Segment:     toCampaignSegment(req.Segment),
+		Status:      domain.CampaignScheduled,
+		ScheduledAt: campaignRunAt(req.ScheduledAt),
+		CreatedBy:   req.CreatedBy,
+	}
+	if err := c.campaignRepo.Create(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	if err := c.schedule(ctx, campaign); err != nil {
+		c.fail(ctx, campaign.ID, err)
+		return nil, err
+	}
+	return c.campaignRepo.GetByID(ctx, campaign.ID)
+}
+
+// PreviewCampaign shows the notification and how many users and devices it
+// would reach, without storing anything
+func (c *campaignUseCase) PreviewCampaign(ctx context.Context, req dto.CreateCampaignRequest) (*dto.CampaignPreviewResponse, error) {
+	if err := validateCampaignData(req.Data); err != nil {
+		return nil, err
+	}
+
+	userIDs, err := c.campaignRepo.AudienceUserIDs(ctx, toCampaignSegment(req.Segment))
+	if err != nil {
+		return nil, err
+	}
+	preview := &dto.CampaignPreviewResponse{
+		Title:       req.Title,
+		Body:        req.Body,
+		Data:        campaignData("<campaign_id>", req.Data),
+		ScheduledAt: campaignRunAt(req.ScheduledAt),
+		Users:       len(userIDs),
+	}
+	if c.fmcService != nil {
+		tokens, err := c.fmcService.GetActiveDeviceTokens(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		preview.Devices = len(tokens)
+	}
+	return preview, nil
+}
+
+func (c *campaignUseCase) GetCampaign(ctx context.Context, id string) (*domain.Campaign, error) {
+	return c.campaignRepo.GetByID(ctx, id)
+}
+
+func (c *campaignUseCase) ListCampaigns(ctx context.Context, filter dto.CampaignListFilter) (*domain.CampaignList, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	campaigns, total, err := c.campaignRepo.List(ctx, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CampaignList{Campaigns: campaigns, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// CancelCampaign stops a campaign that has not been sent yet
+func (c *campaignUseCase) CancelCampaign(ctx context.Context, id string) (*domain.Campaign, error) {
+	if _, err := c.campaignRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	err := c.campaignRepo.UpdateStatus(ctx, id, domain.CampaignScheduled, domain.CampaignCancelled, nil)
+	if err == domain.ErrConflict {
+		return nil, domain.ErrCampaignNotScheduled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.fmcService != nil {
+		if err := c.fmcService.RemoveScheduledNotification(campaignJobID(id)); err != nil {
+			logger.Log.WithError(err).WithField("campaign_id", id).Warn("Cancelled campaign had no scheduled job")
+		}
+	}
+	return c.campaignRepo.GetByID(ctx, id)
+}
+
+// GetCampaignStats counts the deliveries of a campaign
+func (c *campaignUseCase) GetCampaignStats(ctx context.Context, id string) (*dto.NotificationDeliveryStats, error) {
+	if _, err := c.campaignRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	stats := dto.NotificationDeliveryStats{Key: campaignJobID(id)}
+	if c.fmcService == nil {
+		return &stats, nil
+	}
+	found, err := c.fmcService.GetDeliveryStats(ctx, fire_base.DeliveryStatsFilter{Campaign: campaignJobID(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) > 0 {
+		stats = toDeliveryStats(found[0])
+	}
+	return &stats, nil
+}
+
+// RestoreCampaigns schedules the waiting campaigns again, those that were due
+// while the server was down are sent right away
+func (c *campaignUseCase) RestoreCampaigns(ctx context.Context) (int, error) {
+	if c.fmcService == nil {
+		return 0, nil
+	}
+	campaigns, err := c.campaignRepo.GetByStatus(ctx, domain.CampaignScheduled)
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for i := range campaigns {
+		if err := c.schedule(ctx, &campaigns[i]); err != nil {
+			logger.Log.WithError(err).WithField("campaign_id", campaigns[i].ID).Error("Could not restore campaign")
+			c.fail(ctx, campaigns[i].ID, err)
+			continue
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// schedule hands a campaign to the notification service, which sends it at
+// the scheduled time to the devices in its segment at that time. Once sent, the
+// campaign goes to the inbox of every user in the segment, with a device or not.
+func (c *campaignUseCase) schedule(ctx context.Context, campaign *domain.Campaign) error {
+	runAt := campaign.ScheduledAt
+	if runAt.Before(time.Now()) {
+		runAt = time.Now()
+	}
+
+	id := campaign.ID
+	segment := campaign.Segment
+	var userIDs []string
+	resolveTokens := func(ctx context.Context) ([]string, error) {
+		var err error
+		if userIDs, err = c.campaignRepo.AudienceUserIDs(ctx, segment); err != nil {
+			return nil, err
+		}
+		tokens, err := c.fmcService.GetActiveDeviceTokens(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.campaignRepo.Update(ctx, id, map[string]any{"recipients": len(tokens)}); err != nil {
+			logger.Log.WithError(err).WithField("campaign_id", id).Error("Could not record campaign recipients")
+		}
+		return tokens, nil
+	}
+
+	data := campaignData(id, campaign.Data)
+	inboxNotification := domain.InboxNotification{Category: domain.InboxCampaign, Title: campaign.Title, Body: campaign.Body, Data: data}
+	onDone := func(ctx context.Context, sendErr error) {
+		ctx = context.WithoutCancel(ctx)
+		if sendErr == nil {
+			if err := c.inbox.AddToInbox(ctx, userIDs, inboxNotification); err != nil {
+				logger.Log.WithError(err).WithField("campaign_id", id).Error("Could not add campaign to the inbox")
+			}
+		}
+
+		updates := map[string]any{"sent_at": time.Now().UTC()}
+		status := domain.CampaignSent
+		if sendErr != nil {
+			status = domain.CampaignFailed
+			updates["error"] = sendErr.Error()
+		}
+		// A campaign cancelled while it was being sent stays cancelled
+		if err := c.campaignRepo.UpdateStatus(ctx, id, domain.CampaignScheduled, status, updates); err != nil && err != domain.ErrConflict {
+			logger.Log.WithError(err).WithField("campaign_id", id).Error("Could not record campaign delivery")
+		}
+	}
+
+	return c.fmcService.ScheduleBulkNotification(campaignJobID(id), campaign.Title, campaign.Body, resolveTokens, runAt, data, onDone)
+}
+
+// fail records that a campaign could not be scheduled
+func (c *campaignUseCase) fail(ctx context.Context, id string, cause error) {
+	err := c.campaignRepo.UpdateStatus(ctx, id, domain.CampaignScheduled, domain.CampaignFailed, map[string]any{"error": cause.Error()})
+	if err != nil {
+		logger.Log.WithError(err).WithField("campaign_id", id).Error("Could not mark campaign as failed")
+	}
+}
+
+// campaignRunAt is when a campaign is sent, now when it is not scheduled
+func campaignRunAt(scheduledAt *time.Time) time.Time {
+	if scheduledAt == nil || scheduledAt.Before(time.Now()) {
+		return time.Now().UTC()
+	}
+	return scheduledAt.UTC()
+}
+
+// campaignData adds the campaign ID to the data sent to the app, so it can
+// report which campaign was opened
+func campaignData(id string, data map[string]string) map[string]string {
+	withID := make(map[string]string, len(data)+1)
+	for key, value := range data {
+		withID[key] = value
+	}
+	withID["campaign_id"] = id
+	return withID
+}
+
+func validateCampaignData(data map[string]string) error {
+	size := 0
+	for key, value := range data {
+		lower := strings.ToLower(key)
+		if key == "" || key == "campaign_id" || strings.HasPrefix(lower, "google.") || strings.HasPrefix(lower, "gcm.") {
+			return fmt.Errorf("%w: the key %q is reserved", domain.ErrInvalidCampaignData, key)
+		}
+		for _, reserved := range reservedDataKeys {
+			if lower == reserved {
+				return fmt.Errorf("%w: the key %q is reserved", domain.ErrInvalidCampaignData, key)
+			}
+		}
+		size += len(key) + len(value)
+	}
+	if size > maxCampaignDataSize {
+		return fmt.Errorf("%w: data is larger than %d bytes", domain.ErrInvalidCampaignData, maxCampaignDataSize)
+	}
+	return nil
+}
+
+func toCampaignSegment(segment dto.CampaignSegmentRequest) domain.CampaignSegment {
+	return domain.CampaignSegment{
+		PlanType:     segment.PlanType,
+		Language:     segment.Language,
+		InactiveDays: segment.InactiveDays,
+	}
+}
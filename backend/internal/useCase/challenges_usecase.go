@@ -1,11 +1,13 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
 	"yefe_app/v1/pkg/utils"
 )
 
@@ -14,6 +16,8 @@ type ChallengeUseCaseImpl struct {
 	challengeRepo      domain.ChallengeRepository
 	userChallengeRepo  domain.UserChallengeRepository
 	challengeStatsRepo domain.ChallengeStatsRepository
+	achievements       domain.AchievementUseCase
+	points             domain.PointsUseCase
 }
 
 // NewChallengeUseCase creates a new instance of ChallengeUseCaseImpl
@@ -21,11 +25,15 @@ func NewChallengeUseCase(
 	challengeRepo domain.ChallengeRepository,
 	userChallengeRepo domain.UserChallengeRepository,
 	challengeStatsRepo domain.ChallengeStatsRepository,
+	achievements domain.AchievementUseCase,
+	points domain.PointsUseCase,
 ) domain.ChallengeUseCase {
 	return &ChallengeUseCaseImpl{
 		challengeRepo:      challengeRepo,
 		userChallengeRepo:  userChallengeRepo,
 		challengeStatsRepo: challengeStatsRepo,
+		achievements:       achievements,
+		points:             points,
 	}
 }
 
@@ -151,13 +159,17 @@ func (c *ChallengeUseCaseImpl) CompleteChallenge(userID, challengeID string) err
 	}
 
 	// Update user stats
-	if err := c.challengeStatsRepo.UpdateUserStats(userID, challenge.Points); err != nil {
+	if err := c.challengeStatsRepo.UpdateUserStats(userID); err != nil {
 		return fmt.Errorf("error updating user stats: %w", err)
 	}
 
-	return nil
-}
+	if err := c.points.Award(context.Background(), userID, domain.PointsSourceChallenge, userChallenge.ID, challenge.Points, reasonChallengeCompleted); err != nil {
+		return fmt.Errorf("error awarding challenge points: %w", err)
+	}
 
-func (c *ChallengeUseCaseImpl) GetLeaderboard(limit int) ([]domain.ChallengeStats, error) {
-	return c.challengeStatsRepo.GetLeaderboard(limit)
+	if _, err := c.achievements.EvaluateAchievements(context.Background(), userID, domain.AchievementSourceChallenge); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not evaluate achievements")
+	}
+
+	return nil
 }
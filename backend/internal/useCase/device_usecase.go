@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/services/fire_base"
+)
+
+// errNotificationsDisabled is returned when the server runs without FCM
+var errNotificationsDisabled = errors.New("push notifications are not configured")
+
+type deviceUseCase struct {
+	profileRepo domain.UserProfileRepository
+	fmcService  *fire_base.FCMNotificationService
+}
+
+func NewDeviceUseCase(profileRepo domain.UserProfileRepository, fmcService *fire_base.FCMNotificationService) domain.DeviceUseCase {
+	return &deviceUseCase{profileRepo: profileRepo, fmcService: fmcService}
+}
+
+// RegisterDevice adds a device to the ones the user's notifications go to, and
+// schedules their reminders if it is the first one
+func (d *deviceUseCase) RegisterDevice(ctx context.Context, req dto.RegisterDeviceRequest) (*dto.DeviceResponse, error) {
+	if d.fmcService == nil {
+		return nil, errNotificationsDisabled
+	}
+	profile, err := d.profileRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := d.fmcService.RegisterDevice(ctx, req.UserID, fire_base.DeviceToken{
+		Token:      req.Token,
+		Platform:   req.Platform,
+		AppVersion: req.AppVersion,
+	}, profile.NotificationPreferences, profile.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	response := toDeviceResponse(*device)
+	return &response, nil
+}
+
+// UnregisterDevice stops notifications to a device, as on logout
+func (d *deviceUseCase) UnregisterDevice(ctx context.Context, req dto.UnregisterDeviceRequest) error {
+	if d.fmcService == nil {
+		return domain.ErrResourceNotFound
+	}
+	return d.fmcService.UnregisterDevice(ctx, req.UserID, req.Token)
+}
+
+func (d *deviceUseCase) ListDevices(ctx context.Context, userID string) ([]dto.DeviceResponse, error) {
+	response := []dto.DeviceResponse{}
+	if d.fmcService == nil {
+		return response, nil
+	}
+	devices, err := d.fmcService.GetUserDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		response = append(response, toDeviceResponse(device))
+	}
+	return response, nil
+}
+
+func toDeviceResponse(device fire_base.DeviceToken) dto.DeviceResponse {
+	return dto.DeviceResponse{
+		ID:         device.ID,
+		Platform:   device.Platform,
+		AppVersion: device.AppVersion,
+		IsActive:   device.IsActive,
+		LastSeenAt: device.LastSeenAt,
+		CreatedAt:  device.CreatedAt,
+	}
+}
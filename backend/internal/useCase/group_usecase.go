@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	// Groups are kept small so members know each other
+	maxGroupMembers  = 12
+	maxGroupsPerUser = 5
+
+	inviteCodeLength = 8
+	// inviteCodeAlphabet leaves out letters and digits that are easily
+	// confused, its 32 characters divide a byte evenly
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// nudgeInterval is how long a member waits to encourage the same member again
+	nudgeInterval = 24 * time.Hour
+	// groupAvatarSize is the avatar thumbnail shown on member lists
+	groupAvatarSize = 64
+
+	campaignGroupEncourage = "group_encourage"
+)
+
+// newInviteCode returns a random code such as K7QMX2RD
+func newInviteCode() string {
+	raw := make([]byte, inviteCodeLength)
+	rand.Read(raw)
+	code := make([]byte, inviteCodeLength)
+	for i, b := range raw {
+		code[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(code)
+}
+
+// normalizeInviteCode upper cases a code and drops spaces and dashes, so codes
+// read out or pasted with formatting still match
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+type groupUseCase struct {
+	groupRepo   domain.GroupRepository
+	statsRepo   domain.ChallengeStatsRepository
+	journal     domain.JournalUseCase
+	profileRepo domain.UserProfileRepository
+	leaderboard domain.LeaderboardUseCase
+	blobStore   domain.BlobStore
+	fmcService  *fire_base.FCMNotificationService
+	urlTTL      time.Duration
+}
+
+func NewGroupUseCase(
+	groupRepo domain.GroupRepository,
+	statsRepo domain.ChallengeStatsRepository,
+	journal domain.JournalUseCase,
+	profileRepo domain.UserProfileRepository,
+	leaderboard domain.LeaderboardUseCase,
+	blobStore domain.BlobStore,
+	fmcService *fire_base.FCMNotificationService,
+	storageConfig utils.StorageConfig,
+) domain.GroupUseCase {
+	urlTTL := storageConfig.SignedURLTTL
+	if urlTTL <= 0 {
+		urlTTL = defaultSignedURLTTL
+	}
+	return &groupUseCase{
+		groupRepo:   groupRepo,
+		statsRepo:   statsRepo,
+		journal:     journal,
+		profileRepo: profileRepo,
+		leaderboard: leaderboard,
+		blobStore:   blobStore,
+		fmcService:  fmcService,
+		urlTTL:      urlTTL,
+	}
+}
+
+// owner checks that a user owns a group, groups of others are not found
+func (g *groupUseCase) owner(ctx context.Context, userID, groupID string) error {
+	member, err := g.groupRepo.GetMember(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role != domain.GroupRoleOwner {
+		return domain.ErrGroupOwnerOnly
+	}
+	return nil
+}
+
+// checkGroupLimit keeps a user from being in too many groups
+func (g *groupUseCase) checkGroupLimit(ctx context.Context, userID string) error {
+	groups, err := g.groupRepo.CountUserGroups(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if groups >= maxGroupsPerUser {
+		return fmt.Errorf("%w: at most %d", domain.ErrTooManyGroups, maxGroupsPerUser)
+	}
+	return nil
+}
+
+func (g *groupUseCase) CreateGroup(ctx context.Context, req dto.CreateGroupRequest) (*domain.Group, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is empty", domain.ErrInvalidRequest)
+	}
+	if err := g.checkGroupLimit(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	group := &domain.Group{
+		ID:          utils.GenerateID(),
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		InviteCode:  newInviteCode(),
+		OwnerID:     req.UserID,
+	}
+	if err := g.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (g *groupUseCase) UpdateGroup(ctx context.Context, req dto.UpdateGroupRequest) (*domain.Group, error) {
+	if err := g.owner(ctx, req.UserID, req.GroupID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: name is empty", domain.ErrInvalidRequest)
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if len(updates) > 0 {
+		if err := g.groupRepo.Update(ctx, req.GroupID, updates); err != nil {
+			return nil, err
+		}
+	}
+	return g.groupRepo.GetByID(ctx, req.GroupID)
+}
+
+func (g *groupUseCase) DeleteGroup(ctx context.Context, userID, groupID string) error {
+	if err := g.owner(ctx, userID, groupID); err != nil {
+		return err
+	}
+	return g.groupRepo.Delete(ctx, groupID)
+}
+
+func (g *groupUseCase) ListGroups(ctx context.Context, userID string) ([]domain.Group, error) {
+	return g.groupRepo.ListByUser(ctx, userID)
+}
+
+func (g *groupUseCase) GetGroup(ctx context.Context, userID, groupID string) (*domain.GroupDetails, error) {
+	if _, err := g.groupRepo.GetMember(ctx, groupID, userID); err != nil {
+		return nil, err
+	}
+	group, err := g.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	members, profiles, err := g.members(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &domain.GroupDetails{Group: *group, Members: []domain.GroupMemberProgress{}}
+	for _, member := range members {
+		profile, ok := profiles[member.UserID]
+		if !ok {
+			continue
+		}
+		stats, err := g.statsRepo.GetUserStats(member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		currentJournal, longestJournal := g.journal.GetStreaks(ctx, member.UserID)
+
+		details.Members = append(details.Members, domain.GroupMemberProgress{
+			UserID:          member.UserID,
+			Name:            profile.Name,
+			AvatarURL:       avatarThumbnailURL(ctx, g.blobStore, profile.AvatarURL, groupAvatarSize, g.urlTTL),
+			Role:            member.Role,
+			JoinedAt:        member.JoinedAt,
+			ChallengeStreak: domain.Streak{Current: stats.CurrentStreak, Longest: stats.LongestStreak},
+			JournalStreak:   domain.Streak{Current: currentJournal, Longest: longestJournal},
+			IsCurrentUser:   member.UserID == userID,
+		})
+	}
+	return details, nil
+}
+
+// members lists the members of a group with their public profiles by user
+func (g *groupUseCase) members(ctx context.Context, groupID string) ([]domain.GroupMember, map[string]domain.PublicProfile, error) {
+	members, err := g.groupRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	userIDs := make([]string, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	profiles, err := g.profileRepo.GetPublicProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byUser := make(map[string]domain.PublicProfile, len(profiles))
+	for _, profile := range profiles {
+		byUser[profile.UserID] = profile
+	}
+	return members, byUser, nil
+}
+
+func (g *groupUseCase) JoinGroup(ctx context.Context, userID, inviteCode string) (*domain.Group, error) {
+	group, err := g.groupRepo.GetByInviteCode(ctx, normalizeInviteCode(inviteCode))
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkGroupLimit(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	err = g.groupRepo.AddMember(ctx, domain.GroupMember{
+		GroupID:  group.ID,
+		UserID:   userID,
+		Role:     domain.GroupRoleMember,
+		JoinedAt: time.Now().UTC(),
+	}, maxGroupMembers)
+	if err != nil {
+		return nil, err
+	}
+	return g.groupRepo.GetByID(ctx, group.ID)
+}
+
+func (g *groupUseCase) LeaveGroup(ctx context.Context, userID, groupID string) error {
+	return g.groupRepo.RemoveMember(ctx, groupID, userID)
+}
+
+func (g *groupUseCase) RemoveMember(ctx context.Context, userID, groupID, memberID string) error {
+	if memberID == userID {
+		return fmt.Errorf("%w: leave the group instead", domain.ErrInvalidRequest)
+	}
+	if err := g.owner(ctx, userID, groupID); err != nil {
+		return err
+	}
+	return g.groupRepo.RemoveMember(ctx, groupID, memberID)
+}
+
+func (g *groupUseCase) ResetInviteCode(ctx context.Context, userID, groupID string) (*domain.Group, error) {
+	if err := g.owner(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	if err := g.groupRepo.Update(ctx, groupID, map[string]any{"invite_code": newInviteCode()}); err != nil {
+		return nil, err
+	}
+	return g.groupRepo.GetByID(ctx, groupID)
+}
+
+func (g *groupUseCase) Encourage(ctx context.Context, userID, groupID, memberID string) error {
+	if memberID == userID {
+		return fmt.Errorf("%w: cannot encourage yourself", domain.ErrInvalidRequest)
+	}
+	if _, err := g.groupRepo.GetMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+	if _, err := g.groupRepo.GetMember(ctx, groupID, memberID); err != nil {
+		return err
+	}
+
+	group, err := g.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	profiles, err := g.profileRepo.GetPublicProfiles(ctx, []string{userID})
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	// The repository counts and adds the nudge in one transaction, so parallel
+	// requests cannot both pass the limit
+	now := time.Now().UTC()
+	err = g.groupRepo.AddNudge(ctx, domain.GroupNudge{
+		ID:          utils.GenerateID(),
+		GroupID:     groupID,
+		SenderID:    userID,
+		RecipientID: memberID,
+		CreatedAt:   now,
+	}, now.Add(-nudgeInterval))
+	if err != nil {
+		return err
+	}
+
+	// The nudge lands in the inbox also when no device can be reached
+	if g.fmcService != nil {
+		title := fmt.Sprintf("%s is cheering you on", profiles[0].Name)
+		body := fmt.Sprintf("Keep your streak going with %s today.", group.Name)
+		data := map[string]string{"group_id": groupID}
+		if err := g.fmcService.SendToUser(ctx, campaignGroupEncourage, memberID, title, body, data); err != nil {
+			logger.Log.WithError(err).WithField("user_id", memberID).Warn("Could not push group encouragement")
+		}
+	}
+	return nil
+}
+
+func (g *groupUseCase) GetLeaderboard(ctx context.Context, userID, groupID, window string) (*domain.Leaderboard, error) {
+	if _, err := g.groupRepo.GetMember(ctx, groupID, userID); err != nil {
+		return nil, err
+	}
+	members, err := g.groupRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	return g.leaderboard.GetMembersLeaderboard(ctx, userID, window, userIDs)
+}
+
+func (g *groupUseCase) AdminListGroups(ctx context.Context, filter dto.GroupListFilter) (*domain.GroupList, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	groups, total, err := g.groupRepo.List(ctx, filter.Search, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.GroupList{Groups: groups, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+func (g *groupUseCase) AdminGetGroup(ctx context.Context, groupID string) (*domain.GroupModeration, error) {
+	group, err := g.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	members, profiles, err := g.members(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	nudges, err := g.groupRepo.NudgesBySender(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	moderation := &domain.GroupModeration{Group: *group, Members: []domain.GroupMemberSummary{}}
+	for _, member := range members {
+		moderation.Members = append(moderation.Members, domain.GroupMemberSummary{
+			GroupMember: member,
+			Name:        profiles[member.UserID].Name,
+			NudgesSent:  nudges[member.UserID],
+		})
+	}
+	for _, sent := range nudges {
+		moderation.NudgesSent += sent
+	}
+	return moderation, nil
+}
+
+func (g *groupUseCase) AdminDeleteGroup(ctx context.Context, groupID string) error {
+	return g.groupRepo.Delete(ctx, groupID)
+}
+
+func (g *groupUseCase) AdminRemoveMember(ctx context.Context, groupID, memberID string) error {
+	return g.groupRepo.RemoveMember(ctx, groupID, memberID)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+)
+
+// inboxRetention is how long notifications stay in the inbox
+const inboxRetention = 90 * 24 * time.Hour
+
+type inboxUseCase struct {
+	inboxRepo domain.InboxRepository
+}
+
+func NewInboxUseCase(inboxRepo domain.InboxRepository) domain.InboxUseCase {
+	return &inboxUseCase{inboxRepo: inboxRepo}
+}
+
+func (i *inboxUseCase) AddToInbox(ctx context.Context, userIDs []string, notification domain.InboxNotification) error {
+	return i.inboxRepo.CreateForUsers(ctx, userIDs, notification)
+}
+
+// ListNotifications lists the inbox of a user, latest first, with the number
+// of unread notifications for the bell icon
+func (i *inboxUseCase) ListNotifications(ctx context.Context, filter dto.InboxFilter) (*domain.InboxList, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	notifications, total, err := i.inboxRepo.List(ctx, filter.UserID, filter.UnreadOnly, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := i.inboxRepo.CountUnread(ctx, filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.InboxList{
+		Notifications: notifications,
+		Total:         total,
+		Unread:        unread,
+		Limit:         filter.Limit,
+		Offset:        filter.Offset,
+	}, nil
+}
+
+func (i *inboxUseCase) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	return i.inboxRepo.CountUnread(ctx, userID)
+}
+
+func (i *inboxUseCase) MarkRead(ctx context.Context, userID, id string) error {
+	return i.inboxRepo.MarkRead(ctx, userID, id)
+}
+
+func (i *inboxUseCase) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	return i.inboxRepo.MarkAllRead(ctx, userID)
+}
+
+func (i *inboxUseCase) PurgeOldNotifications(ctx context.Context) (int64, error) {
+	return i.inboxRepo.DeleteOlderThan(ctx, time.Now().Add(-inboxRetention))
+}
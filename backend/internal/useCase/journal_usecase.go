@@ -13,16 +13,23 @@ import (
 	"yefe_app/v1/pkg/utils"
 )
 
+// journalEntryPoints is what the first entry of a type each day earns
+const journalEntryPoints = 5
+
 type journalUseCase struct {
-	journalRepo domain.JournalRepository
-	userRepo    domain.UserRepository
+	journalRepo  domain.JournalRepository
+	userRepo     domain.UserRepository
+	achievements domain.AchievementUseCase
+	points       domain.PointsUseCase
 }
 
 // NewJournalUseCase creates a new journal use case
-func NewJournalUseCase(journalRepo domain.JournalRepository, userRepo domain.UserRepository) domain.JournalUseCase {
+func NewJournalUseCase(journalRepo domain.JournalRepository, userRepo domain.UserRepository, achievements domain.AchievementUseCase, points domain.PointsUseCase) domain.JournalUseCase {
 	return &journalUseCase{
-		journalRepo: journalRepo,
-		userRepo:    userRepo,
+		journalRepo:  journalRepo,
+		userRepo:     userRepo,
+		achievements: achievements,
+		points:       points,
 	}
 }
 
@@ -44,6 +51,10 @@ func (uc *journalUseCase) CreateEntry(ctx context.Context, userID string, req dt
 		return nil, domain.ErrEmptyContent
 	}
 
+	// Only the first entry of a type each day earns points
+	_, err := uc.journalRepo.GetTodayEntry(ctx, userID, req.Type)
+	firstToday := err != nil
+
 	// Create entry
 	entry := &domain.JournalEntry{
 		ID:        utils.GenerateID(),
@@ -60,6 +71,16 @@ func (uc *journalUseCase) CreateEntry(ctx context.Context, userID string, req dt
 		return nil, fmt.Errorf("failed to create journal entry: %w", err)
 	}
 
+	if firstToday {
+		if err := uc.points.Award(ctx, userID, domain.PointsSourceJournal, entry.ID, journalEntryPoints, reasonJournalEntry); err != nil {
+			logger.Log.WithError(err).WithField("user_id", userID).Error("Could not award journal points")
+		}
+	}
+
+	if _, err := uc.achievements.EvaluateAchievements(ctx, userID, domain.AchievementSourceJournal); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not evaluate achievements")
+	}
+
 	if err := utils.TypeConverter(entry, &res); err != nil {
 
 		return nil, fmt.Errorf("failed to create journal entry: %w", err)
@@ -207,6 +228,10 @@ func (uc *journalUseCase) DeleteEntry(ctx context.Context, userID, entryID strin
 		return fmt.Errorf("failed to delete journal entry: %w", err)
 	}
 
+	if err := uc.points.ReverseSource(ctx, userID, domain.PointsSourceJournal, entryID, reasonJournalDeleted); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not reverse journal points")
+	}
+
 	return nil
 }
 
@@ -347,6 +372,10 @@ func (uc *journalUseCase) calculateTagsUsage(entries []*domain.JournalEntry) map
 	return tagsUsage
 }
 
+func (uc *journalUseCase) GetStreaks(ctx context.Context, userID string) (int, int) {
+	return uc.calculateStreaks(ctx, userID)
+}
+
 func (uc *journalUseCase) calculateStreaks(ctx context.Context, userID string) (int, int) {
 	// Get entries for the last 60 days to accurately calculate streaks
 	sixtyDaysAgo := time.Now().AddDate(0, 0, -60).Format("2006-01-02")
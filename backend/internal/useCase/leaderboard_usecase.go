@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+	// leaderboardAvatarSize is the avatar thumbnail shown on leaderboards
+	leaderboardAvatarSize = 64
+)
+
+var leaderboardWindows = []string{domain.LeaderboardWeekly, domain.LeaderboardMonthly, domain.LeaderboardAllTime}
+
+// leaderboardPeriod is the period of window that t falls in
+func leaderboardPeriod(window string, t time.Time) domain.LeaderboardPeriod {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch window {
+	case domain.LeaderboardWeekly:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return domain.LeaderboardPeriod{Window: window, Start: start, End: start.AddDate(0, 0, 7)}
+	case domain.LeaderboardMonthly:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return domain.LeaderboardPeriod{Window: window, Start: start, End: start.AddDate(0, 1, 0)}
+	}
+	return domain.LeaderboardPeriod{Window: domain.LeaderboardAllTime}
+}
+
+// leaderboardPeriods are the periods of every window that t falls in
+func leaderboardPeriods(t time.Time) []domain.LeaderboardPeriod {
+	periods := make([]domain.LeaderboardPeriod, len(leaderboardWindows))
+	for i, window := range leaderboardWindows {
+		periods[i] = leaderboardPeriod(window, t)
+	}
+	return periods
+}
+
+type leaderboardUseCase struct {
+	leaderboardRepo domain.LeaderboardRepository
+	pointsRepo      domain.PointsRepository
+	profileRepo     domain.UserProfileRepository
+	blobStore       domain.BlobStore
+	urlTTL          time.Duration
+}
+
+func NewLeaderboardUseCase(
+	leaderboardRepo domain.LeaderboardRepository,
+	pointsRepo domain.PointsRepository,
+	profileRepo domain.UserProfileRepository,
+	blobStore domain.BlobStore,
+	storageConfig utils.StorageConfig,
+) domain.LeaderboardUseCase {
+	urlTTL := storageConfig.SignedURLTTL
+	if urlTTL <= 0 {
+		urlTTL = defaultSignedURLTTL
+	}
+	return &leaderboardUseCase{
+		leaderboardRepo: leaderboardRepo,
+		pointsRepo:      pointsRepo,
+		profileRepo:     profileRepo,
+		blobStore:       blobStore,
+		urlTTL:          urlTTL,
+	}
+}
+
+// ranked reports whether a user may appear on the leaderboards
+func (l *leaderboardUseCase) ranked(ctx context.Context, userID string) (bool, error) {
+	profiles, err := l.profileRepo.GetPublicProfiles(ctx, []string{userID})
+	if err != nil {
+		return false, err
+	}
+	return len(profiles) == 1 && !profiles[0].HideFromLeaderboard, nil
+}
+
+func (l *leaderboardUseCase) RecordPoints(ctx context.Context, entry domain.PointsEntry) error {
+	ranked, err := l.ranked(ctx, entry.UserID)
+	if err != nil || !ranked {
+		return err
+	}
+
+	// Entries count in the periods they were recorded in, as on a rebuild, so
+	// a reversal takes points off this week also for an award of an earlier one
+	return l.leaderboardRepo.AddPoints(ctx, leaderboardPeriods(entry.CreatedAt), entry.UserID, entry.Points)
+}
+
+func (l *leaderboardUseCase) GetLeaderboard(ctx context.Context, userID, window string, limit int) (*domain.Leaderboard, error) {
+	window, err := leaderboardWindow(window)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultLeaderboardLimit
+	}
+	limit = min(limit, maxLeaderboardLimit)
+
+	period := leaderboardPeriod(window, time.Now())
+	top, err := l.leaderboardRepo.Top(ctx, period, limit)
+	if err != nil {
+		return nil, err
+	}
+	me, err := l.leaderboardRepo.Rank(ctx, period, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(top)+1)
+	for _, score := range top {
+		userIDs = append(userIDs, score.UserID)
+	}
+	if me != nil {
+		userIDs = append(userIDs, userID)
+	}
+	profiles, err := l.profileRepo.GetPublicProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[string]domain.PublicProfile, len(profiles))
+	for _, profile := range profiles {
+		byUser[profile.UserID] = profile
+	}
+
+	// Users who left or opted out a moment ago are skipped until the boards
+	// catch up, as are scores brought to zero by reversals
+	entry := func(score domain.LeaderboardScore) *domain.LeaderboardEntry {
+		profile, ok := byUser[score.UserID]
+		if !ok || profile.HideFromLeaderboard || score.Points <= 0 {
+			return nil
+		}
+		return &domain.LeaderboardEntry{
+			Rank:          score.Rank,
+			Name:          profile.Name,
+			AvatarURL:     l.avatarURL(ctx, profile.AvatarURL),
+			Points:        score.Points,
+			IsCurrentUser: score.UserID == userID,
+		}
+	}
+
+	leaderboard := newLeaderboard(period)
+	for _, score := range top {
+		if e := entry(score); e != nil {
+			leaderboard.Entries = append(leaderboard.Entries, *e)
+		}
+	}
+	if me != nil {
+		leaderboard.Me = entry(*me)
+	}
+	return leaderboard, nil
+}
+
+// avatarURL links to the leaderboard thumbnail of a stored avatar
+func (l *leaderboardUseCase) avatarURL(ctx context.Context, key string) string {
+	return avatarThumbnailURL(ctx, l.blobStore, key, leaderboardAvatarSize, l.urlTTL)
+}
+
+// leaderboardWindow checks a requested window, all time when it is empty
+func leaderboardWindow(window string) (string, error) {
+	if window == "" {
+		return domain.LeaderboardAllTime, nil
+	}
+	if !slices.Contains(leaderboardWindows, window) {
+		return "", fmt.Errorf("%w: unknown leaderboard window %q", domain.ErrInvalidRequest, window)
+	}
+	return window, nil
+}
+
+// newLeaderboard is an empty leaderboard of a period
+func newLeaderboard(period domain.LeaderboardPeriod) *domain.Leaderboard {
+	leaderboard := &domain.Leaderboard{
+		Window:  period.Window,
+		Entries: []domain.LeaderboardEntry{},
+	}
+	if !period.Start.IsZero() {
+		leaderboard.PeriodStart = &period.Start
+		leaderboard.PeriodEnd = &period.End
+	}
+	return leaderboard
+}
+
+func (l *leaderboardUseCase) GetMembersLeaderboard(ctx context.Context, userID, window string, userIDs []string) (*domain.Leaderboard, error) {
+	window, err := leaderboardWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	period := leaderboardPeriod(window, time.Now())
+	scores, err := l.leaderboardRepo.Scores(ctx, period, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := l.profileRepo.GetPublicProfiles(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := newLeaderboard(period)
+	for _, profile := range profiles {
+		if profile.HideFromLeaderboard {
+			continue
+		}
+		leaderboard.Entries = append(leaderboard.Entries, domain.LeaderboardEntry{
+			Name:          profile.Name,
+			AvatarURL:     l.avatarURL(ctx, profile.AvatarURL),
+			Points:        max(scores[profile.UserID], 0),
+			IsCurrentUser: profile.UserID == userID,
+		})
+	}
+	slices.SortStableFunc(leaderboard.Entries, func(a, b domain.LeaderboardEntry) int {
+		if a.Points != b.Points {
+			return b.Points - a.Points
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for i := range leaderboard.Entries {
+		leaderboard.Entries[i].Rank = i + 1
+		if leaderboard.Entries[i].IsCurrentUser {
+			me := leaderboard.Entries[i]
+			leaderboard.Me = &me
+		}
+	}
+	return leaderboard, nil
+}
+
+func (l *leaderboardUseCase) SyncUser(ctx context.Context, userID string) error {
+	periods := leaderboardPeriods(time.Now())
+	ranked, err := l.ranked(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ranked {
+		return l.leaderboardRepo.Remove(ctx, periods, userID)
+	}
+
+	for _, period := range periods {
+		points, err := l.pointsRepo.UserTotal(ctx, userID, period.Start)
+		if err != nil {
+			return err
+		}
+		if err := l.leaderboardRepo.SetPoints(ctx, period, userID, points); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *leaderboardUseCase) Rebuild(ctx context.Context) error {
+	for _, period := range leaderboardPeriods(time.Now()) {
+		points, err := l.pointsRepo.RankedTotals(ctx, period.Start)
+		if err != nil {
+			return fmt.Errorf("failed to total %s points: %w", period.Window, err)
+		}
+		if err := l.leaderboardRepo.Replace(ctx, period, points); err != nil {
+			return err
+		}
+	}
+	return nil
+}
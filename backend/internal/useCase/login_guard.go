@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+)
+
+const (
+	// maxIPFailures is the number of failed logins from one IP allowed per window
+	maxIPFailures = 20
+	// maxEmailFailures is the number of failed logins for one email allowed per window
+	maxEmailFailures = 10
+	// lockoutThreshold is the persisted failure count at which accounts start locking
+	lockoutThreshold = 5
+	// baseLockDuration is the first lock, it doubles with every further failure
+	baseLockDuration = time.Minute
+	// maxLockDuration caps the exponential backoff
+	maxLockDuration = 24 * time.Hour
+)
+
+// loginGuard protects Login against brute force. Short lived per-IP and
+// per-email windows live in redis, while the per-account failure count is
+// persisted and drives an exponentially growing lockout.
+type loginGuard struct {
+	userRepo     domain.UserRepository
+	attemptRepo  domain.LoginAttemptRepository
+	secEventRepo domain.SecurityEventRepository
+}
+
+func newLoginGuard(userRepo domain.UserRepository, attemptRepo domain.LoginAttemptRepository, secEventRepo domain.SecurityEventRepository) *loginGuard {
+	return &loginGuard{
+		userRepo:     userRepo,
+		attemptRepo:  attemptRepo,
+		secEventRepo: secEventRepo,
+	}
+}
+
+// lockDuration returns how long an account is locked after failedCount failures
+func lockDuration(failedCount int) time.Duration {
+	if failedCount < lockoutThreshold {
+		return 0
+	}
+	exponent := float64(failedCount - lockoutThreshold)
+	duration := time.Duration(float64(baseLockDuration) * math.Pow(2, exponent))
+	if duration <= 0 || duration > maxLockDuration {
+		return maxLockDuration
+	}
+	return duration
+}
+
+// allow rejects the attempt when the IP or the email exhausted its window
+func (g *loginGuard) allow(ctx context.Context, ipAddress, email string) error {
+	ipFailures, emailFailures, err := g.attemptRepo.GetFailures(ctx, ipAddress, email)
+	if err != nil {
+		// Fail open, the persisted lockout still applies
+		logger.Log.WithError(err).Error("Could not read login failure windows")
+		return nil
+	}
+
+	if ipFailures >= maxIPFailures || emailFailures >= maxEmailFailures {
+		return domain.ErrRateLimitExceeded
+	}
+	return nil
+}
+
+// recordFailure updates the windows and, for a known user, the persisted count
+// and lockout. user is nil when the email does not belong to any account.
+func (g *loginGuard) recordFailure(ctx context.Context, user *domain.User, ipAddress, userAgent, email string) {
+	ipFailures, emailFailures, err := g.attemptRepo.RecordFailure(ctx, ipAddress, email)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not record login failure")
+	}
+
+	if ipFailures == maxIPFailures || emailFailures == maxEmailFailures {
+		logger.Log.WithFields(map[string]any{
+			"ip_address":     ipAddress,
+			"ip_failures":    ipFailures,
+			"email_failures": emailFailures,
+		}).Warn("Login rate limit reached")
+
+		if user != nil {
+			g.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventSuspiciousActivity, ipAddress, userAgent, types.JSONMap{
+				"reason":         "login_rate_limit",
+				"ip_failures":    ipFailures,
+				"email_failures": emailFailures,
+			})
+		}
+	}
+
+	if user == nil {
+		return
+	}
+
+	g.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventLoginFailed, ipAddress, userAgent, nil)
+
+	failedCount, err := g.userRepo.IncrementFailedLogin(ctx, user.ID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not persist failed login")
+		return
+	}
+
+	duration := lockDuration(failedCount)
+	if duration == 0 {
+		return
+	}
+
+	lockUntil := time.Now().Add(duration)
+	if err := g.userRepo.LockAccount(ctx, user.ID, lockUntil); err != nil {
+		logger.Log.WithError(err).Error("Could not lock account")
+		return
+	}
+
+	g.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountLocked, ipAddress, userAgent, types.JSONMap{
+		"failed_attempts": failedCount,
+		"locked_until":    lockUntil,
+	})
+}
+
+// recordSuccess clears the email window and reports a lock that expired on its own
+func (g *loginGuard) recordSuccess(ctx context.Context, user *domain.User, ipAddress, userAgent string) {
+	if err := g.attemptRepo.ResetEmail(ctx, user.Email); err != nil {
+		logger.Log.WithError(err).Error("Could not reset login failure window")
+	}
+
+	if user.AccountLockedUntil != nil {
+		g.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountUnlocked, ipAddress, userAgent, types.JSONMap{
+			"reason": "lock_expired",
+		})
+	}
+}
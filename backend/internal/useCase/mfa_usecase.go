@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	// mfaChallengeTTL is how long the second login step stays open
+	mfaChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts is the number of codes a challenge accepts
+	maxMFAAttempts = 5
+	// recoveryCodeCount is the number of recovery codes issued on enrollment
+	recoveryCodeCount = 10
+	// defaultMFAIssuer names the account in authenticator apps when the server has no name
+	defaultMFAIssuer = "Yefe"
+)
+
+// startMFAChallenge parks a password-verified login until the second factor arrives
+func (a *authUseCase) startMFAChallenge(ctx context.Context, user *domain.User, challenge *domain.MFAChallenge) (*dto.LoginResponse, error) {
+	token := utils.GenerateSecureToken()
+	challenge.ExpiresAt = time.Now().Add(mfaChallengeTTL)
+
+	if err := a.mfaChallengeRepo.Create(ctx, utils.HashToken(token), challenge); err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int64(mfaChallengeTTL / time.Second),
+	}, nil
+}
+
+// VerifyMFA completes a login that was answered with a challenge token
+func (a *authUseCase) VerifyMFA(ctx context.Context, req dto.VerifyMFARequest) (*dto.LoginResponse, error) {
+	tokenHash := utils.HashToken(req.ChallengeToken)
+	challenge, err := a.mfaChallengeRepo.Get(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take the attempt before checking the code, so parallel requests cannot
+	// all get in under the limit
+	attempts, err := a.mfaChallengeRepo.IncrementAttempts(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > maxMFAAttempts {
+		a.mfaChallengeRepo.Delete(ctx, tokenHash)
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := a.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.loginGuard.allow(ctx, req.IPAddress, user.Email); err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, domain.ErrAccountInactive
+	}
+	if user.AccountLockedUntil != nil && time.Now().Before(*user.AccountLockedUntil) {
+		return nil, domain.ErrAccountLocked
+	}
+
+	mfa, err := a.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := a.checkMFACode(ctx, mfa, req.Code)
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidMFACode) {
+			return nil, err
+		}
+
+		if attempts >= maxMFAAttempts {
+			a.mfaChallengeRepo.Delete(ctx, tokenHash)
+		}
+		a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventMFAFailed, req.IPAddress, req.UserAgent, types.JSONMap{
+			"attempts": attempts,
+		})
+		// A wrong code counts as a failed login, a new challenge from the
+		// password does not start the count over
+		a.loginGuard.recordFailure(ctx, user, req.IPAddress, req.UserAgent, user.Email)
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	if err := a.mfaChallengeRepo.Delete(ctx, tokenHash); err != nil {
+		logger.Log.WithError(err).Error("Could not delete two-factor challenge")
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventMFAVerified, req.IPAddress, req.UserAgent, types.JSONMap{
+		"method": method,
+	})
+
+	response, err := a.createSession(ctx, user, &domain.Session{
+		IPAddress:   challenge.IPAddress,
+		UserAgent:   challenge.UserAgent,
+		DeviceLabel: challenge.DeviceLabel,
+		MFAVerified: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.loginGuard.recordSuccess(ctx, user, req.IPAddress, req.UserAgent)
+	return response, nil
+}
+
+// SetupMFA starts an enrollment. The secret only becomes active once EnableMFA
+// receives a code generated from it.
+func (a *authUseCase) SetupMFA(ctx context.Context, user *domain.User) (*dto.MFASetupResponse, error) {
+	existing, err := a.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrMFANotEnabled) {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	mfa := &domain.UserMFA{
+		ID:        utils.GenerateID(),
+		UserID:    user.ID,
+		Secret:    utils.GenerateTOTPSecret(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := a.mfaRepo.Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	issuer := a.config.Name
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	return &dto.MFASetupResponse{
+		Secret:          mfa.Secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(issuer, user.Email, mfa.Secret),
+	}, nil
+}
+
+// EnableMFA confirms an enrollment with a first code and hands out the
+// recovery codes. The current session counts as verified from then on.
+func (a *authUseCase) EnableMFA(ctx context.Context, req dto.EnableMFARequest) (*dto.MFARecoveryCodesResponse, error) {
+	mfa, err := a.mfaRepo.GetByUserID(ctx, req.UserID)
+	if errors.Is(err, domain.ErrMFANotEnabled) {
+		return nil, domain.ErrMFASetupNotStarted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTPCode(mfa.Secret, req.Code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		a.secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventMFAFailed, req.IPAddress, req.UserAgent, types.JSONMap{
+			"step": "enroll",
+		})
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+
+	now := time.Now()
+	mfa.Enabled = true
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = step
+	mfa.RecoveryCodes = hashes
+	mfa.UpdatedAt = now
+	if err := a.mfaRepo.Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	if session, err := a.sessionRepo.GetByID(ctx, req.SessionID); err == nil {
+		session.MFAVerified = true
+		if err := a.sessionRepo.Update(ctx, session); err != nil {
+			logger.Log.WithError(err).Error("Could not mark session as two-factor verified")
+		}
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventMFAEnrolled, req.IPAddress, req.UserAgent, types.JSONMap{
+		"session_id": req.SessionID,
+	})
+
+	return &dto.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA removes two-factor for users whose role does not require it
+func (a *authUseCase) DisableMFA(ctx context.Context, req dto.DisableMFARequest) error {
+	user, err := a.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin() {
+		return domain.ErrMFAMandatoryForRole
+	}
+
+	if !utils.VerifyPassword(req.Password, user.Salt, user.PasswordHash, utils.DefaultPasswordConfig) {
+		return domain.ErrInvalidCredentials
+	}
+
+	mfa, err := a.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled {
+		return domain.ErrMFANotEnabled
+	}
+
+	if _, err := a.checkMFACode(ctx, mfa, req.Code); err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventMFAFailed, req.IPAddress, req.UserAgent, types.JSONMap{
+				"step": "disable",
+			})
+		}
+		return err
+	}
+
+	if err := a.mfaRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventMFADisabled, req.IPAddress, req.UserAgent, nil)
+	return nil
+}
+
+// checkMFACode accepts a TOTP code or burns a recovery code and reports which one was used
+func (a *authUseCase) checkMFACode(ctx context.Context, mfa *domain.UserMFA, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		step, ok := utils.ValidateTOTPCode(mfa.Secret, code, time.Now(), mfa.LastUsedStep)
+		if !ok {
+			return "", domain.ErrInvalidMFACode
+		}
+		// Fails if a concurrent request already used this step
+		if err := a.mfaRepo.UpdateLastUsedStep(ctx, mfa.UserID, step); err != nil {
+			return "", err
+		}
+		return "totp", nil
+	}
+
+	codeHash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	if err := a.mfaRepo.ConsumeRecoveryCode(ctx, mfa.UserID, codeHash); err != nil {
+		return "", err
+	}
+	return "recovery_code", nil
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/services/fire_base"
+)
+
+type notificationAdminUseCase struct {
+	fmcService *fire_base.FCMNotificationService
+}
+
+func NewNotificationAdminUseCase(fmcService *fire_base.FCMNotificationService) domain.NotificationAdminUseCase {
+	return &notificationAdminUseCase{fmcService: fmcService}
+}
+
+func (n *notificationAdminUseCase) GetDeliveryStats(ctx context.Context, filter dto.NotificationStatsFilter) (*dto.NotificationStatsResponse, error) {
+	groupBy := "campaign"
+	if filter.GroupBy == "user" {
+		groupBy = "user"
+	} else if filter.GroupBy != "" && filter.GroupBy != "campaign" {
+		return nil, domain.ErrInvalidRequest
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	response := &dto.NotificationStatsResponse{GroupBy: groupBy, Stats: []dto.NotificationDeliveryStats{}}
+	if n.fmcService == nil {
+		return response, nil
+	}
+
+	storeGroupBy := "campaign"
+	if groupBy == "user" {
+		storeGroupBy = "user_id"
+	}
+	stats, err := n.fmcService.GetDeliveryStats(ctx, fire_base.DeliveryStatsFilter{
+		GroupBy:  storeGroupBy,
+		Campaign: filter.Campaign,
+		UserID:   filter.UserID,
+		From:     filter.From,
+		To:       filter.To,
+		Limit:    filter.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range stats {
+		response.Stats = append(response.Stats, toDeliveryStats(s))
+	}
+	return response, nil
+}
+
+func toDeliveryStats(s fire_base.DeliveryStats) dto.NotificationDeliveryStats {
+	entry := dto.NotificationDeliveryStats{
+		Key:        s.Key,
+		Total:      s.Total,
+		Sent:       s.Sent,
+		Failed:     s.Failed,
+		Pending:    s.Pending,
+		Deferred:   s.Deferred,
+		Suppressed: s.Suppressed,
+		Retries:    s.Retries,
+	}
+	if finished := s.Sent + s.Failed; finished > 0 {
+		entry.DeliveryRate = float64(s.Sent) / float64(finished)
+	}
+	return entry
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	// maxNameLength matches the users.name column
+	maxNameLength = 50
+	// oauthNonceTTL is how long the app has to complete a provider sign-in
+	oauthNonceTTL = 10 * time.Minute
+)
+
+// IssueOAuthNonce hands out a nonce for the next provider sign-in. An ID token
+// is only accepted once with the nonce it carries, so a leaked token cannot be
+// replayed.
+func (a *authUseCase) IssueOAuthNonce(ctx context.Context) (*dto.OAuthNonceResponse, error) {
+	nonce := utils.GenerateSecureToken()
+	expiresAt := time.Now().Add(oauthNonceTTL)
+
+	if err := a.oauthNonceRepo.Create(ctx, utils.HashToken(nonce), expiresAt); err != nil {
+		return nil, err
+	}
+	return &dto.OAuthNonceResponse{Nonce: nonce, ExpiresAt: expiresAt}, nil
+}
+
+// OAuthLogin signs a user in with an ID token from a configured OpenID Connect
+// provider. The token must carry a nonce from IssueOAuthNonce, which is used
+// up. Unknown subjects are linked to the account with the same verified email,
+// or get a new account.
+func (a *authUseCase) OAuthLogin(ctx context.Context, req dto.OAuthLoginRequest) (*dto.LoginResponse, error) {
+	verifier, ok := a.oidcVerifiers[req.Provider]
+	if !ok {
+		return nil, domain.ErrUnknownProvider
+	}
+
+	external, err := verifier.Verify(ctx, req.IDToken, req.Nonce)
+	if err != nil {
+		logger.Log.WithError(err).WithField("provider", req.Provider).Warn("Rejected ID token")
+		return nil, domain.ErrInvalidToken
+	}
+	// Consumed only once the token checks out, of parallel requests one wins
+	if err := a.oauthNonceRepo.Consume(ctx, utils.HashToken(req.Nonce)); err != nil {
+		if !errors.Is(err, domain.ErrInvalidToken) {
+			logger.Log.WithError(err).Error("Could not consume OAuth nonce")
+		}
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := a.userForIdentity(ctx, req, external)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.AccountLockedUntil != nil && time.Now().Before(*user.AccountLockedUntil) {
+		return nil, domain.ErrAccountLocked
+	}
+	if !user.IsActive {
+		return nil, domain.ErrAccountInactive
+	}
+
+	return a.completeLogin(ctx, user, req.IPAddress, req.UserAgent, req.DeviceName)
+}
+
+// userForIdentity resolves the account behind a provider subject, linking or
+// creating one the first time the subject signs in
+func (a *authUseCase) userForIdentity(ctx context.Context, req dto.OAuthLoginRequest, external *domain.ExternalIdentity) (*domain.User, error) {
+	identity, err := a.identityRepo.GetByProviderSubject(ctx, req.Provider, external.Subject)
+	if err == nil {
+		if err := a.identityRepo.UpdateLastLogin(ctx, identity.ID); err != nil {
+			logger.Log.WithError(err).Error("Could not update identity last login")
+		}
+		return a.userRepo.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, domain.ErrResourceNotFound) {
+		return nil, err
+	}
+
+	if external.Email == "" {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := a.userRepo.GetByEmail(ctx, external.Email)
+	switch {
+	case err == nil:
+		// Only a provider that vouches for the address may take over an existing account
+		if !external.EmailVerified {
+			return nil, domain.ErrEmailAlreadyExists
+		}
+		if !user.IsEmailVerified {
+			if err := a.claimUnverifiedAccount(ctx, req, user); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, domain.ErrUserNotFound):
+		user, err = a.createOAuthUser(ctx, req, external)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	identity = &domain.UserIdentity{
+		ID:          utils.GenerateID(),
+		UserID:      user.ID,
+		Provider:    req.Provider,
+		Subject:     external.Subject,
+		Email:       external.Email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	}
+	if err := a.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	if external.EmailVerified && !user.IsEmailVerified {
+		if err := a.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			logger.Log.WithError(err).Error("Could not mark email verified")
+		}
+		user.IsEmailVerified = true
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventIdentityLinked, req.IPAddress, req.UserAgent, types.JSONMap{
+		"provider": req.Provider,
+	})
+	return user, nil
+}
+
+// claimUnverifiedAccount hands an account whose address was never verified
+// over to the owner of the address. Whoever registered it first did not prove
+// they own the address, so their password, second factor, providers and
+// sessions are dropped before the provider is linked.
+func (a *authUseCase) claimUnverifiedAccount(ctx context.Context, req dto.OAuthLoginRequest, user *domain.User) error {
+	if err := a.userRepo.ClearPassword(ctx, user.ID); err != nil {
+		return err
+	}
+	user.PasswordHash, user.Salt = "", ""
+
+	if err := a.resetRepo.InvalidateByUserID(ctx, user.ID); err != nil {
+		logger.Log.WithError(err).Error("Could not invalidate password reset tokens")
+	}
+	if err := a.mfaRepo.Delete(ctx, user.ID); err != nil && !errors.Is(err, domain.ErrMFANotEnabled) {
+		return err
+	}
+	if err := a.identityRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := a.sessionRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		logger.Log.WithError(err).Error("Could not revoke sessions of claimed account")
+		return err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountClaimed, req.IPAddress, req.UserAgent, types.JSONMap{
+		"provider": req.Provider,
+	})
+	return nil
+}
+
+// createOAuthUser registers an account without a password. The user can set
+// one later through the forgot-password flow.
+func (a *authUseCase) createOAuthUser(ctx context.Context, req dto.OAuthLoginRequest, external *domain.ExternalIdentity) (*domain.User, error) {
+	name := strings.TrimSpace(external.Name)
+	if name == "" {
+		// Apple only hands the name to the app, on the first sign-in
+		name = strings.TrimSpace(req.Name)
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(external.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+
+	user := &domain.User{
+		ID:              utils.GenerateID(),
+		Email:           external.Email,
+		Name:            name,
+		IsEmailVerified: external.EmailVerified,
+		IsActive:        true,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	user.DowngradeToFree()
+
+	// A bad timezone from the app should not block signing in
+	timezone, err := utils.NormalizeTimezone(req.Timezone)
+	if err != nil {
+		timezone = utils.DefaultTimezone
+	}
+
+	prefs := types.DefaultNotificationsPref()
+	if err := a.userRepo.Create(ctx, user, prefs, timezone); err != nil {
+		logger.Log.WithError(err).Error("error creating user")
+		return nil, err
+	}
+
+	a.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventAccountCreated, req.IPAddress, req.UserAgent, types.JSONMap{
+		"message":  "User created",
+		"provider": req.Provider,
+	})
+
+	a.scheduleReminders(ctx, user.ID, prefs, timezone)
+
+	if !user.IsEmailVerified {
+		if err := a.sendEmailVerification(ctx, user); err != nil {
+			logger.Log.WithError(err).Error("Could not send verification email")
+		}
+	}
+	return user, nil
+}
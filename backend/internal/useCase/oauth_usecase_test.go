@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fakes implement only what signing in through a provider needs, any other call
+// panics on the nil interface they embed
+
+type fakeUserRepo struct {
+	domain.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) ClearPassword(ctx context.Context, userID string) error {
+	r.users[userID].PasswordHash, r.users[userID].Salt = "", ""
+	return nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(ctx context.Context, userID string) error {
+	r.users[userID].IsEmailVerified = true
+	return nil
+}
+
+type fakeIdentityRepo struct {
+	domain.UserIdentityRepository
+	identities []domain.UserIdentity
+}
+
+func (r *fakeIdentityRepo) GetByProviderSubject(ctx context.Context, provider, subject string) (*domain.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, domain.ErrResourceNotFound
+}
+
+func (r *fakeIdentityRepo) Create(ctx context.Context, identity *domain.UserIdentity) error {
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) UpdateLastLogin(ctx context.Context, id string) error {
+	return nil
+}
+
+func (r *fakeIdentityRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	kept := r.identities[:0]
+	for _, identity := range r.identities {
+		if identity.UserID != userID {
+			kept = append(kept, identity)
+		}
+	}
+	r.identities = kept
+	return nil
+}
+
+type fakeSessionRepo struct {
+	domain.SessionRepository
+	revoked []string
+}
+
+func (r *fakeSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeResetRepo struct {
+	domain.PasswordResetRepository
+}
+
+func (r *fakeResetRepo) InvalidateByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+type fakeMFARepo struct {
+	domain.MFARepository
+	enrolled map[string]bool
+}
+
+func (r *fakeMFARepo) Delete(ctx context.Context, userID string) error {
+	if !r.enrolled[userID] {
+		return domain.ErrMFANotEnabled
+	}
+	delete(r.enrolled, userID)
+	return nil
+}
+
+type fakeSecurityEventRepo struct {
+	domain.SecurityEventRepository
+	events []types.SecurityEventType
+}
+
+func (r *fakeSecurityEventRepo) LogSecurityEvent(ctx context.Context, userID string, eventType types.SecurityEventType, ipAddress, userAgent string, details types.JSONMap) error {
+	r.events = append(r.events, eventType)
+	return nil
+}
+
+type fakeNonceRepo struct {
+	nonces map[string]bool
+}
+
+func (r *fakeNonceRepo) Create(ctx context.Context, nonceHash string, expiresAt time.Time) error {
+	r.nonces[nonceHash] = true
+	return nil
+}
+
+func (r *fakeNonceRepo) Consume(ctx context.Context, nonceHash string) error {
+	if !r.nonces[nonceHash] {
+		return domain.ErrInvalidToken
+	}
+	delete(r.nonces, nonceHash)
+	return nil
+}
+
+// fakeVerifier accepts any token that names the nonce it was requested with
+type fakeVerifier struct {
+	identity domain.ExternalIdentity
+}
+
+func (v fakeVerifier) Verify(ctx context.Context, rawIDToken, nonce string) (*domain.ExternalIdentity, error) {
+	if rawIDToken != "token-for-"+nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	identity := v.identity
+	return &identity, nil
+}
+
+type oauthFixture struct {
+	auth       *authUseCase
+	users      *fakeUserRepo
+	identities *fakeIdentityRepo
+	sessions   *fakeSessionRepo
+	mfa        *fakeMFARepo
+}
+
+// newOAuthFixture builds an auth use case around an existing account
+func newOAuthFixture(t *testing.T, user domain.User) *oauthFixture {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	logger.Log = log
+
+	f := &oauthFixture{
+		users:      &fakeUserRepo{users: map[string]*domain.User{user.ID: &user}},
+		identities: &fakeIdentityRepo{},
+		sessions:   &fakeSessionRepo{},
+		mfa:        &fakeMFARepo{enrolled: map[string]bool{user.ID: true}},
+	}
+	f.auth = &authUseCase{
+		userRepo:       f.users,
+		sessionRepo:    f.sessions,
+		secEventRepo:   &fakeSecurityEventRepo{},
+		resetRepo:      &fakeResetRepo{},
+		mfaRepo:        f.mfa,
+		identityRepo:   f.identities,
+		oauthNonceRepo: &fakeNonceRepo{nonces: map[string]bool{}},
+		oidcVerifiers: map[string]domain.IDTokenVerifier{
+			"google": fakeVerifier{identity: domain.ExternalIdentity{Subject: "owner", Email: user.Email, EmailVerified: true}},
+		},
+	}
+	return f
+}
+
+func TestLinkingClaimsUnverifiedAccount(t *testing.T) {
+	// Someone registered the address with a password but never verified it
+	f := newOAuthFixture(t, domain.User{
+		ID:           "user-1",
+		Email:        "owner@example.com",
+		PasswordHash: "hash",
+		Salt:         "salt",
+		IsActive:     true,
+	})
+	f.identities.identities = []domain.UserIdentity{{ID: "identity-1", UserID: "user-1", Provider: "apple", Subject: "squatter"}}
+
+	req := dto.OAuthLoginRequest{Provider: "google"}
+	external := &domain.ExternalIdentity{Subject: "owner", Email: "owner@example.com", EmailVerified: true}
+	user, err := f.auth.userForIdentity(context.Background(), req, external)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := f.users.users["user-1"]
+	if user.ID != "user-1" || !stored.IsEmailVerified {
+		t.Errorf("signed in as %s with verified email %v, want the existing account verified", user.ID, stored.IsEmailVerified)
+	}
+	if stored.PasswordHash != "" || stored.Salt != "" {
+		t.Error("password of the unverified registration kept")
+	}
+	if len(f.sessions.revoked) != 1 || f.sessions.revoked[0] != "user-1" {
+		t.Errorf("revoked sessions of %v, want user-1", f.sessions.revoked)
+	}
+	if f.mfa.enrolled["user-1"] {
+		t.Error("second factor of the unverified registration kept")
+	}
+	if len(f.identities.identities) != 1 || f.identities.identities[0].Subject != "owner" {
+		t.Errorf("linked identities %+v, want only the provider that verified the email", f.identities.identities)
+	}
+}
+
+func TestLinkingKeepsVerifiedAccount(t *testing.T) {
+	f := newOAuthFixture(t, domain.User{
+		ID:              "user-1",
+		Email:           "owner@example.com",
+		PasswordHash:    "hash",
+		Salt:            "salt",
+		IsEmailVerified: true,
+		IsActive:        true,
+	})
+
+	req := dto.OAuthLoginRequest{Provider: "google"}
+	external := &domain.ExternalIdentity{Subject: "owner", Email: "owner@example.com", EmailVerified: true}
+	if _, err := f.auth.userForIdentity(context.Background(), req, external); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.users.users["user-1"].PasswordHash != "hash" || len(f.sessions.revoked) != 0 || !f.mfa.enrolled["user-1"] {
+		t.Error("credentials of a verified account changed by linking a provider")
+	}
+	if len(f.identities.identities) != 1 {
+		t.Errorf("%d identities linked, want 1", len(f.identities.identities))
+	}
+}
+
+func TestOAuthLoginUsesUpNonce(t *testing.T) {
+	// An inactive account stops the sign-in right after the nonce is checked
+	f := newOAuthFixture(t, domain.User{ID: "user-1", Email: "owner@example.com", IsEmailVerified: true})
+	f.identities.identities = []domain.UserIdentity{{ID: "identity-1", UserID: "user-1", Provider: "google", Subject: "owner"}}
+	ctx := context.Background()
+
+	issued, err := f.auth.IssueOAuthNonce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		nonce string
+		want  error
+	}{
+		{name: "never issued", nonce: "made-up", want: domain.ErrInvalidToken},
+		{name: "issued", nonce: issued.Nonce, want: domain.ErrAccountInactive},
+		{name: "replayed", nonce: issued.Nonce, want: domain.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := dto.OAuthLoginRequest{Provider: "google", IDToken: "token-for-" + tt.nonce, Nonce: tt.nonce}
+			if _, err := f.auth.OAuthLogin(ctx, req); !errors.Is(err, tt.want) {
+				t.Errorf("OAuthLogin() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+)
+
+// pointsBackfillBatch is how many historical awards a backfill reads at once
+const pointsBackfillBatch = 500
+
+// Reasons of the entries the app writes to the ledger on its own
+const (
+	reasonPuzzleSolved       = "Solved puzzle"
+	reasonChallengeCompleted = "Completed daily challenge"
+	reasonJournalEntry       = "Wrote journal entry"
+	reasonJournalDeleted     = "Journal entry deleted"
+	reasonAchievementEarned  = "Earned achievement"
+)
+
+type pointsUseCase struct {
+	pointsRepo  domain.PointsRepository
+	leaderboard domain.LeaderboardUseCase
+}
+
+func NewPointsUseCase(pointsRepo domain.PointsRepository, leaderboard domain.LeaderboardUseCase) domain.PointsUseCase {
+	return &pointsUseCase{
+		pointsRepo:  pointsRepo,
+		leaderboard: leaderboard,
+	}
+}
+
+// add records an entry and moves the user on the leaderboards, it reports
+// false when the ledger already had the entry
+func (p *pointsUseCase) add(ctx context.Context, entry domain.PointsEntry) (bool, error) {
+	added, err := p.pointsRepo.Add(ctx, entry)
+	if err != nil || added == 0 {
+		return false, err
+	}
+
+	// The boards are rebuilt from the ledger every day, a miss is repaired then
+	if err := p.leaderboard.RecordPoints(ctx, entry); err != nil {
+		logger.Log.WithError(err).WithField("user_id", entry.UserID).Error("Could not update leaderboards")
+	}
+	return true, nil
+}
+
+func (p *pointsUseCase) Award(ctx context.Context, userID, sourceType, sourceID string, points int, reason string) error {
+	if points <= 0 {
+		return nil
+	}
+	_, err := p.add(ctx, domain.PointsEntry{
+		ID:         utils.GenerateID(),
+		UserID:     userID,
+		Points:     points,
+		SourceType: sourceType,
+		SourceID:   sourceID,
+		Reason:     reason,
+		CreatedAt:  time.Now().UTC(),
+	})
+	return err
+}
+
+func (p *pointsUseCase) ReverseSource(ctx context.Context, userID, sourceType, sourceID, reason string) error {
+	award, err := p.pointsRepo.GetAward(ctx, userID, sourceType, sourceID)
+	if errors.Is(err, domain.ErrResourceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = p.reverse(ctx, *award, reason)
+	return err
+}
+
+// ReverseEntry takes back an award, an award can be reversed only once
+func (p *pointsUseCase) ReverseEntry(ctx context.Context, id, reason string) (*domain.PointsEntry, error) {
+	award, err := p.pointsRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if award.ReversalOf != "" {
+		return nil, fmt.Errorf("%w: a reversal cannot be reversed", domain.ErrInvalidRequest)
+	}
+
+	reversal, err := p.reverse(ctx, *award, reason)
+	if err != nil {
+		return nil, err
+	}
+	if reversal == nil {
+		return nil, domain.ErrPointsAlreadyReversed
+	}
+	return reversal, nil
+}
+
+// reverse records the reversal of award, it returns nil when award was
+// already reversed
+func (p *pointsUseCase) reverse(ctx context.Context, award domain.PointsEntry, reason string) (*domain.PointsEntry, error) {
+	reversal := domain.PointsEntry{
+		ID:         utils.GenerateID(),
+		UserID:     award.UserID,
+		Points:     -award.Points,
+		SourceType: award.SourceType,
+		SourceID:   award.SourceID,
+		Reason:     reason,
+		ReversalOf: award.ID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	added, err := p.add(ctx, reversal)
+	if err != nil || !added {
+		return nil, err
+	}
+	return &reversal, nil
+}
+
+func (p *pointsUseCase) GetBalance(ctx context.Context, userID string) (*domain.PointsBalance, error) {
+	bySource, err := p.pointsRepo.TotalsBySource(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &domain.PointsBalance{BySource: bySource}
+	for _, points := range bySource {
+		balance.Total += points
+	}
+	return balance, nil
+}
+
+// ListEntries lists the ledger of a user, latest first
+func (p *pointsUseCase) ListEntries(ctx context.Context, filter dto.PointsFilter) (*domain.PointsLedger, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	entries, total, err := p.pointsRepo.List(ctx, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PointsLedger{
+		Entries: entries,
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}, nil
+}
+
+func (p *pointsUseCase) Backfill(ctx context.Context) (*domain.PointsBackfillResult, error) {
+	var result domain.PointsBackfillResult
+	var err error
+
+	if result.Puzzles, err = p.backfill(ctx, p.pointsRepo.PuzzleAwards, reasonPuzzleSolved); err != nil {
+		return nil, fmt.Errorf("failed to backfill puzzle points: %w", err)
+	}
+	if result.Challenges, err = p.backfill(ctx, p.pointsRepo.ChallengeAwards, reasonChallengeCompleted); err != nil {
+		return nil, fmt.Errorf("failed to backfill challenge points: %w", err)
+	}
+	if result.Achievements, err = p.backfill(ctx, p.pointsRepo.AchievementAwards, reasonAchievementEarned); err != nil {
+		return nil, fmt.Errorf("failed to backfill achievement points: %w", err)
+	}
+	return &result, nil
+}
+
+// backfill adds the awards read page by page into the ledger
+func (p *pointsUseCase) backfill(
+	ctx context.Context,
+	awards func(ctx context.Context, limit, offset int) ([]domain.PointsEntry, error),
+	reason string,
+) (int64, error) {
+	var added int64
+	for offset := 0; ; offset += pointsBackfillBatch {
+		entries, err := awards(ctx, pointsBackfillBatch, offset)
+		if err != nil {
+			return added, err
+		}
+		for i := range entries {
+			entries[i].Reason = reason
+		}
+
+		n, err := p.pointsRepo.Add(ctx, entries...)
+		added += n
+		if err != nil {
+			return added, err
+		}
+		if len(entries) < pointsBackfillBatch {
+			return added, nil
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	dateOfBirthLayout = "2006-01-02"
+
+	// maxAvatarPixels rejects images that would take too much memory to decode
+	maxAvatarPixels = 40_000_000
+	// avatarMaxSide bounds the stored full size avatar
+	avatarMaxSide = 1024
+	// defaultSignedURLTTL applies when the storage settings leave it unset
+	defaultSignedURLTTL = time.Hour
+)
+
+// avatarThumbnailSizes are the square thumbnails generated for every avatar
+var avatarThumbnailSizes = []int{256, 64}
+
+type profileUseCase struct {
+	userRepo     domain.UserRepository
+	profileRepo  domain.UserProfileRepository
+	secEventRepo domain.SecurityEventRepository
+	blobStore    domain.BlobStore
+	fmcService   *fire_base.FCMNotificationService
+	leaderboard  domain.LeaderboardUseCase
+	urlTTL       time.Duration
+}
+
+func NewProfileUseCase(
+	userRepo domain.UserRepository,
+	profileRepo domain.UserProfileRepository,
+	secEventRepo domain.SecurityEventRepository,
+	blobStore domain.BlobStore,
+	fmcService *fire_base.FCMNotificationService,
+	leaderboard domain.LeaderboardUseCase,
+	storageConfig utils.StorageConfig,
+) domain.ProfileUseCase {
+	urlTTL := storageConfig.SignedURLTTL
+	if urlTTL <= 0 {
+		urlTTL = defaultSignedURLTTL
+	}
+	return &profileUseCase{
+		userRepo:     userRepo,
+		profileRepo:  profileRepo,
+		secEventRepo: secEventRepo,
+		blobStore:    blobStore,
+		fmcService:   fmcService,
+		leaderboard:  leaderboard,
+		urlTTL:       urlTTL,
+	}
+}
+
+func (p *profileUseCase) GetMe(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := p.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p.signAvatar(ctx, user.Profile)
+	return user, nil
+}
+
+func (p *profileUseCase) UpdateMe(ctx context.Context, req dto.UpdateMeRequest) (*domain.User, error) {
+	user, err := p.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := types.JSONMap{}
+	if req.Name != nil && *req.Name != user.Name {
+		if err := p.userRepo.UpdateName(ctx, user.ID, *req.Name); err != nil {
+			return nil, err
+		}
+		changes["name"] = fieldChange(user.Name, *req.Name)
+	}
+
+	if len(changes) > 0 {
+		p.secEventRepo.LogSecurityEvent(ctx, user.ID, types.EventProfileUpdated, req.IPAddress, req.UserAgent, changes)
+	}
+	return p.GetMe(ctx, user.ID)
+}
+
+func (p *profileUseCase) GetProfile(ctx context.Context, userID string) (*domain.UserProfile, error) {
+	profile, err := p.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p.signAvatar(ctx, profile)
+	return profile, nil
+}
+
+func (p *profileUseCase) UpdateProfile(ctx context.Context, req dto.UpdateProfileRequest) (*domain.UserProfile, error) {
+	profile, err := p.profileRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	changes := types.JSONMap{}
+	setString := func(column, current string, value *string) {
+		if value != nil && *value != current {
+			updates[column] = *value
+			changes[column] = fieldChange(current, *value)
+		}
+	}
+	setString("bio", profile.Bio, req.Bio)
+	setString("location", profile.Location, req.Location)
+	setString("phone_number", profile.PhoneNumber, req.PhoneNumber)
+
+	if req.DateOfBirth != nil {
+		current := ""
+		if profile.DateOfBirth != nil {
+			current = profile.DateOfBirth.Format(dateOfBirthLayout)
+		}
+		if *req.DateOfBirth != current {
+			dateOfBirth, err := parseDateOfBirth(*req.DateOfBirth)
+			if err != nil {
+				return nil, err
+			}
+			updates["date_of_birth"] = dateOfBirth
+			changes["date_of_birth"] = fieldChange(current, *req.DateOfBirth)
+		}
+	}
+
+	timezone := profile.Timezone
+	if req.Timezone != nil {
+		normalized, err := utils.NormalizeTimezone(*req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		if normalized != profile.Timezone {
+			timezone = normalized
+			updates["timezone"] = timezone
+			changes["timezone"] = fieldChange(profile.Timezone, timezone)
+		}
+	}
+
+	if req.HideFromLeaderboard != nil && *req.HideFromLeaderboard != profile.HideFromLeaderboard {
+		updates["hide_from_leaderboard"] = *req.HideFromLeaderboard
+		changes["hide_from_leaderboard"] = fieldChange(profile.HideFromLeaderboard, *req.HideFromLeaderboard)
+	}
+
+	if len(updates) > 0 {
+		if err := p.profileRepo.UpdatePartial(ctx, profile.ID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	prefs := profile.NotificationPreferences
+	if req.NotificationPreferences != nil {
+		prefs = notificationsPrefFromRequest(*req.NotificationPreferences)
+		if err := prefs.Reminders.MorningReminder.Validate(); err != nil {
+			return nil, domain.ErrInvalidRequest
+		}
+		if err := prefs.Reminders.EveningReminder.Validate(); err != nil {
+			return nil, domain.ErrInvalidRequest
+		}
+		if err := prefs.QuietHours.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidQuietHours, err)
+		}
+
+		if prefs != profile.NotificationPreferences {
+			if err := p.profileRepo.UpdateNotificationPreferences(ctx, req.UserID, prefs); err != nil {
+				return nil, err
+			}
+			changes["notification_preferences"] = fieldChange(profile.NotificationPreferences, prefs)
+		}
+	}
+
+	// Reminder jobs follow the profile, a failure here is retried on the next change
+	_, prefsChanged := changes["notification_preferences"]
+	if p.fmcService != nil && (prefsChanged || timezone != profile.Timezone) {
+		if err := p.fmcService.SyncUserReminders(ctx, req.UserID, prefs, timezone); err != nil {
+			logger.Log.WithError(err).Error("Could not sync reminder jobs")
+		}
+	}
+
+	// The nightly rebuild catches up with a failure here
+	if _, hideChanged := updates["hide_from_leaderboard"]; hideChanged {
+		if err := p.leaderboard.SyncUser(ctx, req.UserID); err != nil {
+			logger.Log.WithError(err).Error("Could not sync leaderboards")
+		}
+	}
+
+	if len(changes) > 0 {
+		p.secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventProfileUpdated, req.IPAddress, req.UserAgent, changes)
+	}
+	return p.GetProfile(ctx, req.UserID)
+}
+
+func (p *profileUseCase) UploadAvatar(ctx context.Context, req dto.UploadAvatarRequest) (*domain.UserProfile, error) {
+	if len(req.Data) > domain.MaxAvatarSize {
+		return nil, domain.ErrFileTooLarge
+	}
+	// Decoding and encoding again leaves EXIF data, such as the location, behind
+	img, format, err := utils.DecodeImage(req.Data, maxAvatarPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := p.profileRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ext, contentType := ".jpg", "image/jpeg"
+	if format == utils.ImageFormatPNG {
+		ext, contentType = ".png", "image/png"
+	}
+	key := fmt.Sprintf("avatars/%s/%s%s", req.UserID, utils.GenerateID(), ext)
+
+	variants := map[string]*image.RGBA{key: utils.ResizeToFit(img, avatarMaxSide)}
+	for _, size := range avatarThumbnailSizes {
+		variants[avatarThumbnailKey(key, size)] = utils.SquareThumbnail(img, size)
+	}
+	for variantKey, variant := range variants {
+		data, err := utils.EncodeImage(variant, format)
+		if err == nil {
+			err = p.blobStore.Put(ctx, variantKey, bytes.NewReader(data), int64(len(data)), contentType)
+		}
+		if err != nil {
+			deleteAvatarBlobs(ctx, p.blobStore, key)
+			return nil, err
+		}
+	}
+
+	if err := p.profileRepo.UpdateAvatar(ctx, req.UserID, key); err != nil {
+		deleteAvatarBlobs(ctx, p.blobStore, key)
+		return nil, err
+	}
+	if profile.AvatarURL != "" {
+		deleteAvatarBlobs(ctx, p.blobStore, profile.AvatarURL)
+	}
+
+	p.secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventProfileUpdated, req.IPAddress, req.UserAgent, types.JSONMap{
+		"avatar_url": fieldChange(profile.AvatarURL, key),
+	})
+	return p.GetProfile(ctx, req.UserID)
+}
+
+func (p *profileUseCase) RemoveAvatar(ctx context.Context, req dto.RemoveAvatarRequest) error {
+	profile, err := p.profileRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if profile.AvatarURL == "" {
+		return nil
+	}
+
+	if err := p.profileRepo.UpdateAvatar(ctx, req.UserID, ""); err != nil {
+		return err
+	}
+	deleteAvatarBlobs(ctx, p.blobStore, profile.AvatarURL)
+
+	p.secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventProfileUpdated, req.IPAddress, req.UserAgent, types.JSONMap{
+		"avatar_url": fieldChange(profile.AvatarURL, ""),
+	})
+	return nil
+}
+
+// signAvatar replaces the stored avatar key of a profile with links that
+// expire. Avatars that are already URLs are left as they are.
+func (p *profileUseCase) signAvatar(ctx context.Context, profile *domain.UserProfile) {
+	if profile == nil || profile.AvatarURL == "" || isExternalURL(profile.AvatarURL) {
+		return
+	}
+
+	key := profile.AvatarURL
+	signed, err := p.blobStore.SignedURL(ctx, key, p.urlTTL)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not sign avatar URL")
+		profile.AvatarURL = ""
+		return
+	}
+	profile.AvatarURL = signed
+
+	profile.AvatarThumbnails = map[string]string{}
+	for _, size := range avatarThumbnailSizes {
+		if signed, err := p.blobStore.SignedURL(ctx, avatarThumbnailKey(key, size), p.urlTTL); err == nil {
+			profile.AvatarThumbnails[strconv.Itoa(size)] = signed
+		}
+	}
+}
+
+// avatarThumbnailKey derives the key of a thumbnail from the avatar key,
+// avatars/<user>/<id>.jpg becomes avatars/<user>/<id>_256.jpg
+func avatarThumbnailKey(key string, size int) string {
+	ext := path.Ext(key)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(key, ext), size, ext)
+}
+
+// avatarThumbnailURL links to a thumbnail of a stored avatar, it is empty
+// when the link cannot be signed
+func avatarThumbnailURL(ctx context.Context, store domain.BlobStore, key string, size int, ttl time.Duration) string {
+	if key == "" || isExternalURL(key) {
+		return key
+	}
+	signed, err := store.SignedURL(ctx, avatarThumbnailKey(key, size), ttl)
+	if err != nil {
+		logger.Log.WithError(err).Error("Could not sign avatar URL")
+		return ""
+	}
+	return signed
+}
+
+// deleteAvatarBlobs removes an avatar and its thumbnails, failures only leave
+// unreferenced files behind so they are logged and ignored
+func deleteAvatarBlobs(ctx context.Context, store domain.BlobStore, key string) {
+	if key == "" || isExternalURL(key) {
+		return
+	}
+	keys := []string{key}
+	for _, size := range avatarThumbnailSizes {
+		keys = append(keys, avatarThumbnailKey(key, size))
+	}
+	for _, k := range keys {
+		if err := store.Delete(ctx, k); err != nil {
+			logger.Log.WithError(err).WithField("key", k).Warn("Could not delete avatar file")
+		}
+	}
+}
+
+func isExternalURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+// parseDateOfBirth accepts an empty string, which clears the date, or a date
+// in the past within a plausible lifetime
+func parseDateOfBirth(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	dateOfBirth, err := time.Parse(dateOfBirthLayout, value)
+	if err != nil {
+		return nil, domain.ErrInvalidDateOfBirth
+	}
+	if dateOfBirth.After(time.Now()) || dateOfBirth.Before(time.Now().AddDate(-120, 0, 0)) {
+		return nil, domain.ErrInvalidDateOfBirth
+	}
+	return &dateOfBirth, nil
+}
+
+func notificationsPrefFromRequest(prefs dto.UserPrefsRequest) types.NotificationsPref {
+	return types.NotificationsPref{
+		MorningPrompt:     prefs.MorningPrompt,
+		EveningReflection: prefs.EveningReflection,
+		Challenge:         prefs.Challenge,
+		Language:          prefs.Language,
+		Reminders: types.ReminderRequest{
+			MorningReminder: types.ReminderStr(prefs.Reminders.MorningReminder),
+			EveningReminder: types.ReminderStr(prefs.Reminders.EveningReminder),
+		},
+		QuietHours: types.QuietHours{
+			Start: prefs.QuietHours.Start,
+			End:   prefs.QuietHours.End,
+		},
+		Caps: types.NotificationCaps{
+			Daily:      prefs.Caps.Daily,
+			Reminders:  prefs.Caps.Reminders,
+			Challenges: prefs.Caps.Challenges,
+			Campaigns:  prefs.Caps.Campaigns,
+		},
+	}
+}
+
+// fieldChange is the entry recorded for one field in a profile_updated event
+func fieldChange(from, to any) types.JSONMap {
+	return types.JSONMap{"from": from, "to": to}
+}
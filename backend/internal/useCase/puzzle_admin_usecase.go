@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/utils"
+)
+
+// A puzzle offers at least two options to choose from
+const minPuzzleOptions = 2
+
+var puzzleDifficulties = []string{
+	domain.PuzzleDifficultyEasy,
+	domain.PuzzleDifficultyMedium,
+	domain.PuzzleDifficultyHard,
+}
+
+type puzzleAdminUseCase struct {
+	puzzleRepo domain.PuzzleRepository
+}
+
+func NewPuzzleAdminUseCase(puzzleRepo domain.PuzzleRepository) domain.PuzzleAdminUseCase {
+	return &puzzleAdminUseCase{puzzleRepo: puzzleRepo}
+}
+
+// validatePuzzle checks what the request validation cannot, and all of it for
+// imported puzzles. The option keys are numbers since answers are submitted
+// as one.
+func validatePuzzle(puzzle domain.Puzzle) error {
+	if strings.TrimSpace(puzzle.Title) == "" || strings.TrimSpace(puzzle.Question) == "" {
+		return fmt.Errorf("%w: title and question are required", domain.ErrInvalidPuzzle)
+	}
+	if !slices.Contains(puzzleDifficulties, puzzle.Difficulty) {
+		return fmt.Errorf("%w: unknown difficulty %q", domain.ErrInvalidPuzzle, puzzle.Difficulty)
+	}
+	if puzzle.Points < 0 {
+		return fmt.Errorf("%w: points cannot be negative", domain.ErrInvalidPuzzle)
+	}
+	if len(puzzle.Options) < minPuzzleOptions {
+		return fmt.Errorf("%w: at least %d options are required", domain.ErrInvalidPuzzle, minPuzzleOptions)
+	}
+	for key, option := range puzzle.Options {
+		if _, err := strconv.Atoi(key); err != nil {
+			return fmt.Errorf("%w: option key %q is not a number", domain.ErrInvalidPuzzle, key)
+		}
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("%w: option %s is empty", domain.ErrInvalidPuzzle, key)
+		}
+	}
+	if _, ok := puzzle.Options[strconv.Itoa(puzzle.CorrectAnswer)]; !ok {
+		return fmt.Errorf("%w: correct answer %d is not one of the option keys", domain.ErrInvalidPuzzle, puzzle.CorrectAnswer)
+	}
+	return nil
+}
+
+func puzzleFromRequest(req dto.PuzzleRequest) (domain.Puzzle, error) {
+	puzzle := domain.Puzzle{
+		Title:         req.Title,
+		Question:      req.Question,
+		Options:       req.Options,
+		CorrectAnswer: req.CorrectAnswer,
+		Difficulty:    req.Difficulty,
+		Category:      req.Category,
+		Points:        req.Points,
+		Explanation:   req.Explanation,
+	}
+	if err := validatePuzzle(puzzle); err != nil {
+		return domain.Puzzle{}, err
+	}
+	return puzzle, nil
+}
+
+// samePuzzleContent tells whether an update would change what users see
+func samePuzzleContent(a, b domain.Puzzle) bool {
+	return a.Title == b.Title &&
+		a.Question == b.Question &&
+		maps.Equal(a.Options, b.Options) &&
+		a.CorrectAnswer == b.CorrectAnswer &&
+		a.Difficulty == b.Difficulty &&
+		a.Category == b.Category &&
+		a.Points == b.Points &&
+		a.Explanation == b.Explanation
+}
+
+func (p *puzzleAdminUseCase) ListPuzzles(ctx context.Context, includeRetired bool) ([]domain.Puzzle, error) {
+	return p.puzzleRepo.List(ctx, includeRetired)
+}
+
+func (p *puzzleAdminUseCase) GetPuzzle(ctx context.Context, id string) (*domain.Puzzle, error) {
+	return p.puzzleRepo.GetPuzzleByID(id)
+}
+
+func (p *puzzleAdminUseCase) CreatePuzzle(ctx context.Context, req dto.PuzzleRequest) (*domain.Puzzle, error) {
+	puzzle, err := puzzleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	puzzle.ID = utils.GenerateID()
+	if err := p.puzzleRepo.Create(ctx, &puzzle); err != nil {
+		return nil, err
+	}
+	return &puzzle, nil
+}
+
+func (p *puzzleAdminUseCase) UpdatePuzzle(ctx context.Context, id string, req dto.PuzzleRequest) (*domain.Puzzle, error) {
+	puzzle, err := puzzleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := p.puzzleRepo.GetPuzzleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if samePuzzleContent(*current, puzzle) {
+		return current, nil
+	}
+
+	puzzle.ID = id
+	if err := p.puzzleRepo.Update(ctx, &puzzle); err != nil {
+		return nil, err
+	}
+	return &puzzle, nil
+}
+
+func (p *puzzleAdminUseCase) RetirePuzzle(ctx context.Context, id string) error {
+	return p.puzzleRepo.SetRetired(ctx, id, true)
+}
+
+func (p *puzzleAdminUseCase) RestorePuzzle(ctx context.Context, id string) error {
+	return p.puzzleRepo.SetRetired(ctx, id, false)
+}
+
+func (p *puzzleAdminUseCase) ListPuzzleVersions(ctx context.Context, id string) ([]domain.PuzzleVersion, error) {
+	return p.puzzleRepo.ListVersions(ctx, id)
+}
+
+func (p *puzzleAdminUseCase) ImportPuzzles(ctx context.Context, data domain.PuzzleData) (*domain.PuzzleImportResult, error) {
+	seen := make(map[string]bool, len(data.Puzzles))
+	for _, puzzle := range data.Puzzles {
+		if puzzle.ID == "" || len(puzzle.ID) > 36 {
+			return nil, fmt.Errorf("%w: puzzle ID %q must be 1 to 36 characters", domain.ErrInvalidPuzzle, puzzle.ID)
+		}
+		if seen[puzzle.ID] {
+			return nil, fmt.Errorf("%w: puzzle %s appears twice", domain.ErrInvalidPuzzle, puzzle.ID)
+		}
+		seen[puzzle.ID] = true
+
+		if err := validatePuzzle(puzzle); err != nil {
+			return nil, fmt.Errorf("puzzle %s: %w", puzzle.ID, err)
+		}
+	}
+	return p.puzzleRepo.Import(ctx, data.Puzzles)
+}
+
+func (p *puzzleAdminUseCase) ExportPuzzles(ctx context.Context) (*domain.PuzzleData, error) {
+	puzzles, err := p.puzzleRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PuzzleData{Puzzles: puzzles}, nil
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/services/fire_base"
+	"yefe_app/v1/pkg/utils"
+)
+
+// Screens of the app reminders open
+const (
+	deepLinkChallenge      = "yefe://challenges/today"
+	deepLinkPuzzle         = "yefe://puzzle/daily"
+	deepLinkEveningJournal = "yefe://journal/new?type=evening"
+)
+
+const defaultReminderLanguage = "English"
+
+// reminderTexts holds the title and body of each kind of reminder content per
+// notification language, %s is replaced by the title of the challenge
+var reminderTexts = map[string]map[string][2]string{
+	"English": {
+		"challenge":         {"Today's challenge", "%s. Take it on today!"},
+		"challenge_pending": {"Your challenge is waiting", "There is still time to complete: %s"},
+		"puzzle":            {"Your daily puzzle is ready", "Take a minute to sharpen your mind."},
+		"journal":           {"Time to reflect", "How did your day go? Write your evening journal entry."},
+	},
+	"French": {
+		"challenge":         {"Le défi du jour", "%s. Relevez-le aujourd'hui !"},
+		"challenge_pending": {"Votre défi vous attend", "Il est encore temps de terminer : %s"},
+		"puzzle":            {"Votre énigme du jour est prête", "Prenez une minute pour aiguiser votre esprit."},
+		"journal":           {"C'est l'heure de faire le point", "Comment s'est passée votre journée ? Écrivez votre journal du soir."},
+	},
+	"Spanish": {
+		"challenge":         {"El reto de hoy", "%s. ¡Acéptalo hoy!"},
+		"challenge_pending": {"Tu reto te espera", "Todavía tienes tiempo para completar: %s"},
+		"puzzle":            {"Tu acertijo diario está listo", "Tómate un minuto para agudizar la mente."},
+		"journal":           {"Hora de reflexionar", "¿Cómo te fue hoy? Escribe tu diario de la noche."},
+	},
+	"Portuguese": {
+		"challenge":         {"O desafio de hoje", "%s. Aceite-o hoje!"},
+		"challenge_pending": {"Seu desafio está esperando", "Ainda há tempo para concluir: %s"},
+		"puzzle":            {"Seu quebra-cabeça diário está pronto", "Tire um minuto para exercitar a mente."},
+		"journal":           {"Hora de refletir", "Como foi o seu dia? Escreva seu diário da noite."},
+	},
+}
+
+// reminderDraft is the content picked for a reminder before it is written in
+// the user's language
+type reminderDraft struct {
+	text string // Key in reminderTexts
+	arg  string // Challenge title for the body
+	data map[string]string
+}
+
+type reminderContentUseCase struct {
+	profileRepo       domain.UserProfileRepository
+	challengeRepo     domain.ChallengeRepository
+	userChallengeRepo domain.UserChallengeRepository
+	userPuzzleRepo    domain.UserPuzzleRepository
+	journalRepo       domain.JournalRepository
+}
+
+// NewReminderContentUseCase writes daily reminders from the challenge, puzzle
+// and journal of the day, in the user's notification language
+func NewReminderContentUseCase(
+	profileRepo domain.UserProfileRepository,
+	challengeRepo domain.ChallengeRepository,
+	userChallengeRepo domain.UserChallengeRepository,
+	userPuzzleRepo domain.UserPuzzleRepository,
+	journalRepo domain.JournalRepository,
+) fire_base.ReminderContent {
+	return &reminderContentUseCase{
+		profileRepo:       profileRepo,
+		challengeRepo:     challengeRepo,
+		userChallengeRepo: userChallengeRepo,
+		userPuzzleRepo:    userPuzzleRepo,
+		journalRepo:       journalRepo,
+	}
+}
+
+// ReminderMessage picks what a reminder is about. In the morning it is today's
+// challenge, or the daily puzzle when the user does not want challenges or has
+// done it. In the evening it asks for the journal entry, then falls back to an
+// unfinished challenge or puzzle. Nothing is sent when the reminder is turned
+// off or everything is done.
+func (r *reminderContentUseCase) ReminderMessage(ctx context.Context, userID, kind string) (*fire_base.ReminderMessage, error) {
+	profile, err := r.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := profile.NotificationPreferences
+
+	var candidates []func() (*reminderDraft, error)
+	switch kind {
+	case fire_base.ReminderMorning:
+		if !prefs.MorningPrompt {
+			return nil, nil
+		}
+		if prefs.Challenge {
+			candidates = append(candidates, func() (*reminderDraft, error) { return r.challengeMessage(userID, "challenge") })
+		}
+		candidates = append(candidates, func() (*reminderDraft, error) { return r.puzzleMessage(userID) })
+	case fire_base.ReminderEvening:
+		if !prefs.EveningReflection {
+			return nil, nil
+		}
+		candidates = append(candidates, func() (*reminderDraft, error) {
+			return r.journalMessage(ctx, userID, profile.Timezone)
+		})
+		if prefs.Challenge {
+			candidates = append(candidates, func() (*reminderDraft, error) { return r.challengeMessage(userID, "challenge_pending") })
+		}
+		candidates = append(candidates, func() (*reminderDraft, error) { return r.puzzleMessage(userID) })
+	default:
+		return nil, fmt.Errorf("unknown reminder kind %q", kind)
+	}
+
+	for _, candidate := range candidates {
+		draft, err := candidate()
+		if err != nil {
+			// Try the next kind of content rather than miss the reminder
+			logger.Log.WithError(err).WithField("user_id", userID).Warn("Could not check reminder content")
+			continue
+		}
+		if draft != nil {
+			return draft.message(prefs.Language, kind), nil
+		}
+	}
+	return nil, nil
+}
+
+// challengeMessage is about today's challenge, unless the user completed it
+func (r *reminderContentUseCase) challengeMessage(userID, text string) (*reminderDraft, error) {
+	challenge, err := r.challengeRepo.GetTodaysChallenge()
+	if err != nil {
+		return nil, err
+	}
+	if challenge.ID == "" {
+		return nil, nil
+	}
+	userChallenge, err := r.userChallengeRepo.GetTodaysUserChallenge(userID)
+	if err == nil && userChallenge.Status == dto.StatusCompleted {
+		return nil, nil
+	}
+
+	return &reminderDraft{
+		text: text,
+		arg:  challenge.Title,
+		data: map[string]string{
+			"content":      "challenge",
+			"challenge_id": challenge.ID,
+			"deep_link":    deepLinkChallenge,
+		},
+	}, nil
+}
+
+// puzzleMessage is about the daily puzzle, unless the user solved it today
+func (r *reminderContentUseCase) puzzleMessage(userID string) (*reminderDraft, error) {
+	progress, err := r.userPuzzleRepo.GetUserPuzzleProgressForDate(userID, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		return nil, nil
+	}
+	return &reminderDraft{
+		text: "puzzle",
+		data: map[string]string{"content": "puzzle", "deep_link": deepLinkPuzzle},
+	}, nil
+}
+
+// journalMessage asks for the evening journal entry, unless the user wrote it
+// today in their timezone
+func (r *reminderContentUseCase) journalMessage(ctx context.Context, userID, timezone string) (*reminderDraft, error) {
+	now := time.Now().In(utils.LoadTimezone(timezone))
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	entries, err := r.journalRepo.GetByUserIDAndDateRange(ctx, userID, startOfDay.UTC(), startOfDay.AddDate(0, 0, 1).UTC())
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Type == "evening" {
+			return nil, nil
+		}
+	}
+	return &reminderDraft{
+		text: "journal",
+		data: map[string]string{"content": "journal", "deep_link": deepLinkEveningJournal},
+	}, nil
+}
+
+// message writes a draft in the user's notification language, English when
+// the language is unknown
+func (d reminderDraft) message(language, kind string) *fire_base.ReminderMessage {
+	texts, ok := reminderTexts[language]
+	if !ok {
+		texts = reminderTexts[defaultReminderLanguage]
+	}
+	text := texts[d.text]
+
+	body := text[1]
+	if d.arg != "" {
+		body = fmt.Sprintf(text[1], d.arg)
+	}
+
+	data := map[string]string{"type": "reminder", "reminder": kind}
+	for key, value := range d.data {
+		data[key] = value
+	}
+	return &fire_base.ReminderMessage{Title: text[0], Body: body, Data: data}
+}
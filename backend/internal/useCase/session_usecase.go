@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/types"
+)
+
+// listActiveSessions returns the user's live sessions, most recently seen first
+func listActiveSessions(ctx context.Context, sessionRepo domain.SessionRepository, userID, currentSessionID string) ([]dto.SessionResponse, error) {
+	sessions, err := sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.IsActive || now.After(session.ExpiresAt) {
+			continue
+		}
+
+		lastSeen := session.LastSeenAt
+		if lastSeen.IsZero() {
+			lastSeen = session.CreatedAt
+		}
+
+		response = append(response, dto.SessionResponse{
+			ID:          session.ID,
+			DeviceLabel: session.DeviceLabel,
+			IPAddress:   session.IPAddress,
+			UserAgent:   session.UserAgent,
+			CreatedAt:   session.CreatedAt,
+			LastSeenAt:  lastSeen,
+			ExpiresAt:   session.ExpiresAt,
+			Current:     session.ID == currentSessionID,
+		})
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].LastSeenAt.After(response[j].LastSeenAt)
+	})
+
+	return response, nil
+}
+
+// revokeSession deletes a single session after checking it belongs to the user
+func revokeSession(ctx context.Context, sessionRepo domain.SessionRepository, secEventRepo domain.SecurityEventRepository, req dto.RevokeSessionRequest) error {
+	session, err := sessionRepo.GetByID(ctx, req.SessionID)
+	if err != nil || session == nil || session.UserID != req.UserID {
+		return domain.ErrSessionNotFound
+	}
+
+	if err := sessionRepo.Delete(ctx, session.ID); err != nil {
+		return err
+	}
+
+	details := types.JSONMap{
+		"session_id": session.ID,
+		"scope":      "single",
+	}
+	if req.RevokedBy != "" {
+		details["revoked_by"] = req.RevokedBy
+	}
+	secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventLogout, req.IPAddress, req.UserAgent, details)
+	return nil
+}
+
+// revokeAllSessions deletes every session of a user, keeping req.ExceptSessionID if set
+func revokeAllSessions(ctx context.Context, sessionRepo domain.SessionRepository, secEventRepo domain.SecurityEventRepository, req dto.LogoutAllRequest) error {
+	if req.ExceptSessionID == "" {
+		if err := sessionRepo.DeleteByUserID(ctx, req.UserID); err != nil {
+			return err
+		}
+	} else {
+		sessions, err := sessionRepo.GetByUserID(ctx, req.UserID)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if session.ID == req.ExceptSessionID {
+				continue
+			}
+			if err := sessionRepo.Delete(ctx, session.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	details := types.JSONMap{"scope": "all"}
+	if req.ExceptSessionID != "" {
+		details["scope"] = "others"
+		details["kept_session_id"] = req.ExceptSessionID
+	}
+	if req.RevokedBy != "" {
+		details["revoked_by"] = req.RevokedBy
+	}
+	secEventRepo.LogSecurityEvent(ctx, req.UserID, types.EventLogout, req.IPAddress, req.UserAgent, details)
+	return nil
+}
@@ -1,24 +1,33 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"time"
 	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/internal/handlers/dto"
+	"yefe_app/v1/pkg/logger"
 )
 
 type puzzleUseCase struct {
 	puzzleRepo     domain.PuzzleRepository
 	userPuzzleRepo domain.UserPuzzleRepository
+	achievements   domain.AchievementUseCase
+	points         domain.PointsUseCase
 }
 
 func NewPuzzleUseCase(
 	puzzleRepo domain.PuzzleRepository,
 	userPuzzleRepo domain.UserPuzzleRepository,
+	achievements domain.AchievementUseCase,
+	points domain.PointsUseCase,
 ) domain.PuzzleUseCase {
 	return &puzzleUseCase{
 		puzzleRepo:     puzzleRepo,
 		userPuzzleRepo: userPuzzleRepo,
+		achievements:   achievements,
+		points:         points,
 	}
 }
 
@@ -37,8 +46,8 @@ func (uc *puzzleUseCase) SubmitPuzzleAnswer(userID, puzzleID string, selectedAns
 		return nil, fmt.Errorf("failed to get puzzle: %w", err)
 	}
 
-	// Validate selected answer
-	if selectedAnswer < 0 || selectedAnswer >= len(puzzle.Options) {
+	// Validate selected answer, options are keyed by the number submitted
+	if _, ok := puzzle.Options[strconv.Itoa(selectedAnswer)]; !ok {
 		return nil, fmt.Errorf("invalid answer selection")
 	}
 
@@ -65,6 +74,7 @@ func (uc *puzzleUseCase) SubmitPuzzleAnswer(userID, puzzleID string, selectedAns
 			ID:             fmt.Sprintf("%s_%s_%d", userID, puzzleID, now.Unix()),
 			UserID:         userID,
 			PuzzleID:       puzzleID,
+			PuzzleVersion:  puzzle.Version,
 			IsCompleted:    true,
 			SelectedAnswer: &selectedAnswer,
 			IsCorrect:      &isCorrect,
@@ -80,6 +90,7 @@ func (uc *puzzleUseCase) SubmitPuzzleAnswer(userID, puzzleID string, selectedAns
 	} else {
 		// Update existing progress
 		existingProgress.IsCompleted = true
+		existingProgress.PuzzleVersion = puzzle.Version
 		existingProgress.SelectedAnswer = &selectedAnswer
 		existingProgress.IsCorrect = &isCorrect
 		existingProgress.CompletedAt = &now
@@ -98,6 +109,15 @@ func (uc *puzzleUseCase) SubmitPuzzleAnswer(userID, puzzleID string, selectedAns
 		}
 	}
 
+	// A puzzle earns points once, the ledger ignores a second award
+	if err := uc.points.Award(context.Background(), userID, domain.PointsSourcePuzzle, puzzleID, pointsEarned, reasonPuzzleSolved); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not award puzzle points")
+	}
+
+	if _, err := uc.achievements.EvaluateAchievements(context.Background(), userID, domain.AchievementSourcePuzzle); err != nil {
+		logger.Log.WithError(err).WithField("user_id", userID).Error("Could not evaluate achievements")
+	}
+
 	return &dto.PuzzleSubmissionResult{
 		IsCorrect:      isCorrect,
 		CorrectAnswer:  puzzle.CorrectAnswer,
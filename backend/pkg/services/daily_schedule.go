@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// DailySchedule runs once a day at a wall clock time in a timezone. Unlike a
+// cron spec it copes with daylight saving changes: a time skipped when the
+// clocks go forward runs the same amount later, and a time repeated when they
+// go back runs only once.
+type DailySchedule struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+	// Now is the clock Upcoming reads, time.Now when nil
+	Now func() time.Time
+}
+
+// NewDailySchedule checks the time of day and defaults the location to UTC
+func NewDailySchedule(hour, minute int, loc *time.Location) (DailySchedule, error) {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return DailySchedule{}, fmt.Errorf("invalid time of day %02d:%02d", hour, minute)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return DailySchedule{Hour: hour, Minute: minute, Location: loc}, nil
+}
+
+// Next returns the first run after t, it implements cron.Schedule
+func (d DailySchedule) Next(t time.Time) time.Time {
+	local := t.In(d.Location)
+	for day := 0; day < 3; day++ {
+		next := d.on(local.Year(), local.Month(), local.Day()+day)
+		if next.After(t) {
+			return next.In(t.Location())
+		}
+	}
+	return time.Time{}
+}
+
+// on returns the run on a day. A time repeated when the clocks go back runs at
+// its first occurrence, every time, so the next day is picked once it has run.
+// A time skipped when they go forward runs as much later as the clocks moved.
+func (d DailySchedule) on(year int, month time.Month, day int) time.Time {
+	wall := time.Date(year, month, day, d.Hour, d.Minute, 0, 0, time.UTC)
+	// Two days either side the offsets are clear of a change on the day
+	_, before := wall.Add(-48 * time.Hour).In(d.Location).Zone()
+	_, after := wall.Add(48 * time.Hour).In(d.Location).Zone()
+
+	early := wall.Add(-time.Duration(before) * time.Second)
+	late := wall.Add(-time.Duration(after) * time.Second)
+	if late.Before(early) {
+		early, late = late, early
+	}
+	for _, candidate := range []time.Time{early, late} {
+		local := candidate.In(d.Location)
+		if local.Hour() == d.Hour && local.Minute() == d.Minute {
+			return local
+		}
+	}
+	// Skipped, with the offset from before the change it lands past the gap
+	return wall.Add(-time.Duration(before) * time.Second).In(d.Location)
+}
+
+// Upcoming returns the first run after the current time of the schedule's clock
+func (d DailySchedule) Upcoming() time.Time {
+	now := d.Now
+	if now == nil {
+		now = time.Now
+	}
+	return d.Next(now())
+}
+
+// String describes the schedule, it is shown as the job's schedule
+func (d DailySchedule) String() string {
+	return fmt.Sprintf("daily at %02d:%02d %s", d.Hour, d.Minute, d.Location)
+}
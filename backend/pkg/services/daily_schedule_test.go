@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestDailyScheduleNext(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	lordHowe := mustLoadLocation(t, "Australia/Lord_Howe")
+
+	tests := []struct {
+		name   string
+		hour   int
+		minute int
+		loc    *time.Location
+		after  time.Time
+		want   time.Time
+	}{
+		{
+			name: "later the same day",
+			hour: 7, loc: newYork,
+			after: time.Date(2025, 3, 8, 6, 0, 0, 0, newYork),
+			want:  time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "already ran today",
+			hour: 7, loc: newYork,
+			after: time.Date(2025, 3, 8, 7, 0, 0, 0, newYork),
+			want:  time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "spring forward keeps the wall clock time",
+			hour: 7, loc: newYork,
+			after: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork),
+			want:  time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "spring forward skips the time, runs an hour later",
+			hour: 2, minute: 30, loc: newYork,
+			after: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork),
+			want:  time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC), // 03:30 EDT
+		},
+		{
+			name: "fall back runs at the first of the repeated times",
+			hour: 1, minute: 30, loc: newYork,
+			after: time.Date(2025, 11, 2, 0, 0, 0, 0, newYork),
+			want:  time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+		},
+		{
+			name: "fall back does not run again at the repeated time",
+			hour: 1, minute: 30, loc: newYork,
+			after: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC),
+			want:  time.Date(2025, 11, 3, 6, 30, 0, 0, time.UTC), // 01:30 EST the next day
+		},
+		{
+			name: "half hour offset",
+			hour: 9, loc: kolkata,
+			after: time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC),
+			want:  time.Date(2025, 6, 1, 3, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "half hour spring forward skips the time, runs half an hour later",
+			hour: 2, minute: 15, loc: lordHowe,
+			after: time.Date(2025, 10, 5, 0, 0, 0, 0, lordHowe),
+			want:  time.Date(2025, 10, 4, 15, 45, 0, 0, time.UTC), // 02:45 +11
+		},
+		{
+			name: "half hour fall back runs at the first of the repeated times",
+			hour: 1, minute: 45, loc: lordHowe,
+			after: time.Date(2025, 4, 6, 0, 0, 0, 0, lordHowe),
+			want:  time.Date(2025, 4, 5, 14, 45, 0, 0, time.UTC), // 01:45 +11
+		},
+		{
+			name: "half hour fall back does not run again at the repeated time",
+			hour: 1, minute: 45, loc: lordHowe,
+			after: time.Date(2025, 4, 5, 14, 45, 0, 0, time.UTC),
+			want:  time.Date(2025, 4, 6, 15, 15, 0, 0, time.UTC), // 01:45 +10:30 the next day
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := NewDailySchedule(tt.hour, tt.minute, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got.In(tt.loc), tt.want.In(tt.loc))
+			}
+		})
+	}
+}
+
+func TestDailyScheduleRunsOncePerDayAcrossDaylightSaving(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	for _, minute := range []int{0, 30} {
+		for hour := 0; hour < 24; hour++ {
+			schedule, err := NewDailySchedule(hour, minute, newYork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// From a week before the clocks go back to a week after they go forward
+			run := schedule.Next(time.Date(2025, 10, 26, 0, 0, 0, 0, newYork))
+			for i := 0; i < 150; i++ {
+				next := schedule.Next(run)
+				if gap := next.Sub(run); gap < 23*time.Hour || gap > 25*time.Hour {
+					t.Fatalf("%02d:%02d: run at %s followed by %s", hour, minute, run, next)
+				}
+				run = next
+			}
+		}
+	}
+}
+
+func TestDailyScheduleUpcomingReadsClock(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	schedule, err := NewDailySchedule(8, 0, newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 3, 8, 9, 0, 0, 0, newYork)
+	schedule.Now = func() time.Time { return now }
+	if got, want := schedule.Upcoming(), time.Date(2025, 3, 9, 8, 0, 0, 0, newYork); !got.Equal(want) {
+		t.Errorf("Upcoming() = %s, want %s", got, want)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if got, want := schedule.Upcoming(), time.Date(2025, 3, 10, 8, 0, 0, 0, newYork); !got.Equal(want) {
+		t.Errorf("Upcoming() after a day = %s, want %s", got, want)
+	}
+}
+
+func TestNewDailyScheduleRejectsInvalidTime(t *testing.T) {
+	for _, tt := range []struct{ hour, minute int }{{24, 0}, {-1, 0}, {7, 60}} {
+		if _, err := NewDailySchedule(tt.hour, tt.minute, nil); err == nil {
+			t.Errorf("NewDailySchedule(%d, %d) succeeded", tt.hour, tt.minute)
+		}
+	}
+}
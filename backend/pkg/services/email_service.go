@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"html"
 	"net/smtp"
 	"strings"
 	"sync"
@@ -165,6 +166,41 @@ func (e *EmailServiceImpl) SendAdminInvitation(ctx context.Context, req dto.Admi
 
 	return e.SendEmail(ctx, emailReq)
 }
+
+// SendEmailVerification sends the link a user follows to verify their email address
+func (e *EmailServiceImpl) SendEmailVerification(ctx context.Context, req dto.EmailVerificationEmailData) error {
+	subject := "Verify your email address"
+
+	htmlBody := e.buildEmailVerificationHTML(req)
+	textBody := e.buildEmailVerificationText(req)
+
+	emailReq := dto.EmailRequest{
+		To:       []string{req.Email},
+		Subject:  subject,
+		Body:     textBody,
+		HTMLBody: htmlBody,
+	}
+
+	return e.SendEmail(ctx, emailReq)
+}
+
+// SendPasswordReset sends the link a user follows to choose a new password
+func (e *EmailServiceImpl) SendPasswordReset(ctx context.Context, req dto.PasswordResetEmailData) error {
+	subject := "Reset your password"
+
+	htmlBody := e.buildPasswordResetHTML(req)
+	textBody := e.buildPasswordResetText(req)
+
+	emailReq := dto.EmailRequest{
+		To:       []string{req.Email},
+		Subject:  subject,
+		Body:     textBody,
+		HTMLBody: htmlBody,
+	}
+
+	return e.SendEmail(ctx, emailReq)
+}
+
 func (e *EmailServiceImpl) SendPaymentConfirmationEmail(ctx context.Context, req dto.PaymentConfirmationEmailData) error {
 	// Build the invitation email content
 	subject := fmt.Sprintf("Payment confitmation for Yefe Plus")
@@ -438,6 +474,156 @@ This is an automated message. Please do not reply to this email.
 	)
 }
 
+// buildEmailVerificationHTML creates the HTML content for the email verification email
+func (e *EmailServiceImpl) buildEmailVerificationHTML(req dto.EmailVerificationEmailData) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Verify your email</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #4CAF50; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { 
+            display: inline-block; 
+            padding: 12px 24px; 
+            background-color: #4CAF50; 
+            color: white; 
+            text-decoration: none; 
+            border-radius: 4px; 
+            margin: 20px 0;
+        }
+        .footer { padding: 20px; text-align: center; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Verify your email</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>Thanks for signing up. Please confirm your email address by clicking the button below:</p>
+            <a href="%s" class="button">Verify Email</a>
+            <p>Or copy and paste this link into your browser:</p>
+            <p><a href="%s">%s</a></p>
+            <p>This link expires on %s.</p>
+            <p>If you didn't create an account, please ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		html.EscapeString(req.Name),
+		req.VerificationLink,
+		req.VerificationLink,
+		req.VerificationLink,
+		req.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+	)
+}
+
+// buildEmailVerificationText creates the plain text content for the email verification email
+func (e *EmailServiceImpl) buildEmailVerificationText(req dto.EmailVerificationEmailData) string {
+	return fmt.Sprintf(`
+Verify your email
+
+Hello %s,
+
+Thanks for signing up. Please confirm your email address by opening the link below: %s
+
+This link expires on %s.
+
+If you didn't create an account, please ignore this email.
+
+---
+This is an automated message. Please do not reply to this email.
+`,
+		req.Name,
+		req.VerificationLink,
+		req.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+	)
+}
+
+// buildPasswordResetHTML creates the HTML content for the password reset email
+func (e *EmailServiceImpl) buildPasswordResetHTML(req dto.PasswordResetEmailData) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Reset your password</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #4CAF50; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { 
+            display: inline-block; 
+            padding: 12px 24px; 
+            background-color: #4CAF50; 
+            color: white; 
+            text-decoration: none; 
+            border-radius: 4px; 
+            margin: 20px 0;
+        }
+        .footer { padding: 20px; text-align: center; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Reset your password</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>We received a request to reset your password. Click the button below to choose a new one:</p>
+            <a href="%s" class="button">Reset Password</a>
+            <p>Or copy and paste this link into your browser:</p>
+            <p><a href="%s">%s</a></p>
+            <p>This link can only be used once and expires on %s.</p>
+            <p>If you didn't request a password reset, you can safely ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>This is an automated message. Please do not reply to this email.</p>
+        </div>
+    </div>
+</body>
+</html>`,
+		html.EscapeString(req.Name),
+		req.ResetLink,
+		req.ResetLink,
+		req.ResetLink,
+		req.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+	)
+}
+
+// buildPasswordResetText creates the plain text content for the password reset email
+func (e *EmailServiceImpl) buildPasswordResetText(req dto.PasswordResetEmailData) string {
+	return fmt.Sprintf(`
+Reset your password
+
+Hello %s,
+
+We received a request to reset your password. Open the link below to choose a new one: %s
+
+This link can only be used once and expires on %s.
+
+If you didn't request a password reset, you can safely ignore this email.
+
+---
+This is an automated message. Please do not reply to this email.
+`,
+		req.Name,
+		req.ResetLink,
+		req.ExpiresAt.UTC().Format("January 2, 2006 15:04 MST"),
+	)
+}
+
 // buildPaymentConfirmationHTML creates the HTML content for a payment confirmation email
 func (e *EmailServiceImpl) buildPaymentConfirmationHTML(req dto.PaymentConfirmationEmailData) string {
 	var b strings.Builder
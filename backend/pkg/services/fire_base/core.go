@@ -2,7 +2,7 @@ package fire_base
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,59 +11,57 @@ import (
 	"yefe_app/v1/pkg/logger"
 	"yefe_app/v1/pkg/utils"
 
-	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
-	"google.golang.org/api/option"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // FCMUserPreferences represents FCM-specific user preferences
 type FCMUserPreferences struct {
-	ID          uint   `json:"id" gorm:"primaryKey"`
-	UserID      string `json:"user_id" gorm:"unique;not null"`
-	FCMToken    string `json:"fcm_token"`
-	MorningTime string `json:"morning_time"` // Format: "08:30"
-	EveningTime string `json:"evening_time"` // Format: "18:00"
-	Timezone    string `json:"timezone" gorm:"default:UTC"`
-	IsActive    bool   `json:"is_active" gorm:"default:true"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      string         `json:"user_id" gorm:"unique;not null"`
+	FCMToken    string         `json:"fcm_token"`    // Deprecated: tokens are kept per device in DeviceToken
+	MorningTime string         `json:"morning_time"` // Format: "08:30"
+	EveningTime string         `json:"evening_time"` // Format: "18:00"
+	Timezone    string         `json:"timezone" gorm:"default:UTC"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	Limits      DeliveryLimits `json:"limits" gorm:"embedded"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// ReminderSchedule is a daily reminder job of a user, kept so the jobs can be
+// scheduled again when the server restarts. A user has at most one of each kind.
+type ReminderSchedule struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       string `json:"user_id" gorm:"not null;uniqueIndex:idx_reminder_user_kind"`
+	Kind         string `json:"kind" gorm:"not null;uniqueIndex:idx_reminder_user_kind"` // "morning" or "evening"
+	Time         string `json:"time"`                                                    // As stored in the profile, e.g. "07:00"
+	CronSchedule string `json:"cron_schedule" gorm:"not null"`                           // Wall clock time in Timezone
+	Timezone     string `json:"timezone" gorm:"default:UTC"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 // NotificationRequest represents a notification to be sent
 type NotificationRequest struct {
-	Token string            `json:"token"`
-	Title string            `json:"title"`
-	Body  string            `json:"body"`
-	Data  map[string]string `json:"data,omitempty"`
+	UserID   string            `json:"user_id,omitempty"`
+	Campaign string            `json:"campaign,omitempty"` // Groups deliveries in the stats, e.g. "reminder_morning"
+	Token    string            `json:"token"`              // Empty to send to every active device of UserID
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Data     map[string]string `json:"data,omitempty"`
 }
 
 // FCMCoreService handles Firebase Cloud Messaging operations
 type FCMCoreService struct {
-	client      *messaging.Client
+	sender      PushSender
 	db          *gorm.DB
 	userUseCase domain.AdminUserUseCase
 }
 
-// NewFCMCoreService creates a new FCM core service
-func NewFCMCoreService(config utils.FirebaseConfig, userUseCase domain.AdminUserUseCase, dbPath string) (*FCMCoreService, error) {
-	// Initialize Firebase
-	jsonCreds, err := json.Marshal(config)
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling firebase config: %v", err)
-	}
-	opt := option.WithCredentialsJSON(jsonCreds)
-	app, err := firebase.NewApp(context.Background(), nil, opt)
-	if err != nil {
-		return nil, fmt.Errorf("error initializing firebase app: %v", err)
-	}
-
-	client, err := app.Messaging(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("error getting messaging client: %v", err)
-	}
-
+// NewFCMCoreService creates a new FCM core service sending with sender
+func NewFCMCoreService(sender PushSender, userUseCase domain.AdminUserUseCase, dbPath string) (*FCMCoreService, error) {
 	// Initialize database
 	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 	if err != nil {
@@ -71,50 +69,91 @@ func NewFCMCoreService(config utils.FirebaseConfig, userUseCase domain.AdminUser
 	}
 
 	// Auto migrate the schema
-	if err := db.AutoMigrate(&FCMUserPreferences{}); err != nil {
+	if err := db.AutoMigrate(&FCMUserPreferences{}, &DeviceToken{}, &ReminderSchedule{}, &NotificationLog{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %v", err)
 	}
+	if err := migrateLegacyTokens(db); err != nil {
+		logger.Log.WithError(err).Error("Failed to move FCM tokens to the devices table")
+	}
 
 	return &FCMCoreService{
-		client:      client,
+		sender:      sender,
 		db:          db,
 		userUseCase: userUseCase,
 	}, nil
 }
 
-// SendNotification sends a single notification
+// SendNotification sends a notification and records it in the notification
+// log, it is retried later if FCM fails temporarily. Without a token it goes to
+// every active device of the user, and fails only if no device was reached.
+// During the user's quiet hours it is held back until they end, and over one of
+// the user's daily caps it is not sent.
 func (f *FCMCoreService) SendNotification(ctx context.Context, req NotificationRequest) error {
-	message := &messaging.Message{
-		Token: req.Token,
-		Notification: &messaging.Notification{
-			Title: req.Title,
-			Body:  req.Body,
-		},
-		Data: req.Data,
-		Webpush: &messaging.WebpushConfig{
-			Notification: &messaging.WebpushNotification{
-				Title: req.Title,
-				Body:  req.Body,
-				Icon:  "/icon-192x192.png",
-			},
-		},
+	tokens := []string{req.Token}
+	if req.Token == "" {
+		if req.UserID == "" {
+			return fmt.Errorf("notification has neither a token nor a user")
+		}
+		var err error
+		if tokens, err = f.activeDeviceTokens(ctx, req.UserID); err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return fmt.Errorf("no active devices for user: %s", req.UserID)
+		}
+	} else if req.UserID == "" {
+		req.UserID = f.userIDsByToken(ctx, tokens)[req.Token]
 	}
 
-	response, err := f.client.Send(ctx, message)
-	if err != nil {
-		logger.Log.Errorf("Failed to send notification: %v", err)
-		return err
+	verdict, until := sendNow, time.Time{}
+	if req.UserID != "" {
+		prefs, err := f.GetUserPreferences(ctx, req.UserID)
+		if err != nil {
+			return err
+		}
+		verdict, until = f.checkDeliveryLimits(ctx, prefs, notificationCategory(req.Campaign), time.Now())
 	}
 
-	logger.Log.Infof("Notification sent successfully, message ID: %s", response)
+	notificationID := utils.GenerateID()
+	logs := make([]NotificationLog, len(tokens))
+	held := false
+	for i, token := range tokens {
+		deviceReq := req
+		deviceReq.Token = token
+		logs[i] = newNotificationLog(deviceReq, notificationID)
+		held = holdBack(&logs[i], verdict, until)
+	}
+	if err := f.db.WithContext(ctx).Create(&logs).Error; err != nil {
+		return fmt.Errorf("failed to log notification: %v", err)
+	}
+	if held {
+		logger.Log.WithFields(map[string]any{"user_id": req.UserID, "status": logs[0].Status}).Info("Notification held back by the user's limits")
+		return nil
+	}
+
+	var errs []error
+	for i := range logs {
+		if err := f.deliver(ctx, &logs[i]); err != nil {
+			logger.Log.Errorf("Failed to send notification: %v", err)
+			errs = append(errs, err)
+			continue
+		}
+		logger.Log.Infof("Notification sent successfully, message ID: %s", logs[i].MessageID)
+	}
+	if len(errs) == len(logs) {
+		return errors.Join(errs...)
+	}
 	return nil
 }
 
-// SendBulkNotifications sends notifications to multiple tokens
-func (f *FCMCoreService) SendBulkNotifications(ctx context.Context, tokens []string, title, body string, data map[string]string) error {
+// SendBulkNotifications sends notifications to multiple tokens, recording the
+// outcome for each token in the notification log
+func (f *FCMCoreService) SendBulkNotifications(ctx context.Context, campaign string, tokens []string, title, body string, data map[string]string) error {
 	if len(tokens) == 0 {
 		return nil
 	}
+	userIDs := f.userIDsByToken(ctx, tokens)
+	limits := f.bulkDeliveryLimits(ctx, userIDs, notificationCategory(campaign))
 
 	// FCM supports up to 500 tokens per request
 	batchSize := 500
@@ -124,29 +163,46 @@ func (f *FCMCoreService) SendBulkNotifications(ctx context.Context, tokens []str
 			end = len(tokens)
 		}
 
-		batch := tokens[i:end]
-		message := &messaging.MulticastMessage{
-			Tokens: batch,
-			Notification: &messaging.Notification{
-				Title: title,
-				Body:  body,
-			},
-			Data: data,
-			Webpush: &messaging.WebpushConfig{
-				Notification: &messaging.WebpushNotification{
-					Title: title,
-					Body:  body,
-					Icon:  "/icon-192x192.png",
-				},
-			},
+		logs := make([]NotificationLog, end-i)
+		var sending []*NotificationLog
+		var sendTokens []string
+		for j, token := range tokens[i:end] {
+			userLimits := limits[userIDs[token]]
+			logs[j] = newNotificationLog(NotificationRequest{
+				UserID:   userIDs[token],
+				Campaign: campaign,
+				Token:    token,
+				Title:    title,
+				Body:     body,
+				Data:     data,
+			}, userLimits.notificationID)
+			if !holdBack(&logs[j], userLimits.verdict, userLimits.until) {
+				sending = append(sending, &logs[j])
+				sendTokens = append(sendTokens, token)
+			}
+		}
+		if err := f.db.WithContext(ctx).Create(&logs).Error; err != nil {
+			logger.Log.Errorf("Error logging batch notification: %v", err)
+			continue
+		}
+		if len(sending) == 0 {
+			continue
 		}
 
-		response, err := f.client.SendEachForMulticast(ctx, message)
+		message := buildMulticastMessage(sendTokens, title, body, data)
+		response, err := f.sender.SendEachForMulticast(ctx, message)
 		if err != nil {
 			logger.Log.Errorf("Error sending batch notification: %v", err)
+			for _, log := range sending {
+				f.recordDelivery(ctx, log, "", err)
+			}
 			continue
 		}
 
+		for j, result := range response.Responses {
+			f.recordDelivery(ctx, sending[j], result.MessageID, result.Error)
+		}
+
 		logger.Log.Infof("Successfully sent %d notifications, failed: %d",
 			response.SuccessCount, response.FailureCount)
 	}
@@ -154,10 +210,82 @@ func (f *FCMCoreService) SendBulkNotifications(ctx context.Context, tokens []str
 	return nil
 }
 
+// userDeliveryLimits is what the limits of one user allow for a bulk notification
+type userDeliveryLimits struct {
+	notificationID string
+	verdict        deliveryVerdict
+	until          time.Time
+}
+
+// bulkDeliveryLimits checks the limits of each user a bulk notification goes to,
+// once for all of their devices
+func (f *FCMCoreService) bulkDeliveryLimits(ctx context.Context, userIDs map[string]string, category string) map[string]userDeliveryLimits {
+	ids := make([]string, 0, len(userIDs))
+	limits := make(map[string]userDeliveryLimits, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := limits[userID]; !ok {
+			limits[userID] = userDeliveryLimits{notificationID: utils.GenerateID()}
+			ids = append(ids, userID)
+		}
+	}
+
+	now := time.Now()
+	for start := 0; start < len(ids); start += deviceLookupBatchSize {
+		end := min(start+deviceLookupBatchSize, len(ids))
+		var prefs []FCMUserPreferences
+		if err := f.db.WithContext(ctx).Where("user_id IN ?", ids[start:end]).Find(&prefs).Error; err != nil {
+			logger.Log.WithError(err).Error("Failed to look up notification limits, sending to everyone")
+			continue
+		}
+		for i := range prefs {
+			userLimits := limits[prefs[i].UserID]
+			userLimits.verdict, userLimits.until = f.checkDeliveryLimits(ctx, &prefs[i], category, now)
+			limits[prefs[i].UserID] = userLimits
+		}
+	}
+	return limits
+}
+
+func buildMessage(req NotificationRequest) *messaging.Message {
+	return &messaging.Message{
+		Token: req.Token,
+		Notification: &messaging.Notification{
+			Title: req.Title,
+			Body:  req.Body,
+		},
+		Data: req.Data,
+		Webpush: &messaging.WebpushConfig{
+			Notification: &messaging.WebpushNotification{
+				Title: req.Title,
+				Body:  req.Body,
+				Icon:  "/icon-192x192.png",
+			},
+		},
+	}
+}
+
+func buildMulticastMessage(tokens []string, title, body string, data map[string]string) *messaging.MulticastMessage {
+	return &messaging.MulticastMessage{
+		Tokens: tokens,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+		Data: data,
+		Webpush: &messaging.WebpushConfig{
+			Notification: &messaging.WebpushNotification{
+				Title: title,
+				Body:  body,
+				Icon:  "/icon-192x192.png",
+			},
+		},
+	}
+}
+
 // GetActiveUserPreferences returns all active FCM user preferences
 func (f *FCMCoreService) GetActiveUserPreferences(ctx context.Context) ([]FCMUserPreferences, error) {
 	var preferences []FCMUserPreferences
-	if err := f.db.Where("is_active = ? AND fcm_token != ''", true).Find(&preferences).Error; err != nil {
+	if err := f.db.Where("is_active = ?", true).Find(&preferences).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active preferences: %v", err)
 	}
 	return preferences, nil
@@ -212,6 +340,100 @@ func (f *FCMCoreService) UpdateUserPreferences(ctx context.Context, userID strin
 	return nil
 }
 
+// UpdateReminderTimes stores the reminder times and timezone of a user who has preferences
+func (f *FCMCoreService) UpdateReminderTimes(ctx context.Context, userID, morningTime, eveningTime, timezone string) error {
+	err := f.db.Model(&FCMUserPreferences{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"morning_time": morningTime,
+			"evening_time": eveningTime,
+			"timezone":     timezone,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update reminder times: %v", err)
+	}
+	return nil
+}
+
+// DeleteUserPreferences removes the FCM preferences, devices and reminder
+// schedules of a user. The user's notification logs are kept for the delivery
+// stats, but without their token and content and no longer tied to the user.
+// Those still waiting to be sent are marked failed.
+func (f *FCMCoreService) DeleteUserPreferences(ctx context.Context, userID string) error {
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&DeviceToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&ReminderSchedule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&NotificationLog{}).
+			Where("user_id = ? AND status IN ?", userID, []string{DeliveryPending, DeliveryDeferred}).
+			Updates(map[string]interface{}{
+				"status":          DeliveryFailed,
+				"error_code":      "user_deleted",
+				"next_attempt_at": nil,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&NotificationLog{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"user_id": "deleted-" + utils.GenerateID(),
+				"token":   "",
+				"title":   "",
+				"body":    "",
+				"data":    "",
+				"error":   "",
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&FCMUserPreferences{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user preferences: %v", err)
+	}
+	logger.Log.Infof("Deleted FCM preferences for user: %s", userID)
+	return nil
+}
+
+// ReplaceReminderSchedules makes schedules the only reminder schedules of a user
+func (f *FCMCoreService) ReplaceReminderSchedules(ctx context.Context, userID string, schedules []ReminderSchedule) error {
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&ReminderSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		for i := range schedules {
+			schedules[i].UserID = userID
+		}
+		return tx.Create(&schedules).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save reminder schedules: %v", err)
+	}
+	return nil
+}
+
+// GetReminderSchedules returns the reminder schedules of every user
+func (f *FCMCoreService) GetReminderSchedules(ctx context.Context) ([]ReminderSchedule, error) {
+	var schedules []ReminderSchedule
+	if err := f.db.Order("id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reminder schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// DeleteReminderSchedules removes the reminder schedules of a user
+func (f *FCMCoreService) DeleteReminderSchedules(ctx context.Context, userID string) error {
+	if err := f.db.Where("user_id = ?", userID).Delete(&ReminderSchedule{}).Error; err != nil {
+		return fmt.Errorf("failed to delete reminder schedules: %v", err)
+	}
+	return nil
+}
+
 // ValidateUser checks if user exists and has notifications enabled
 func (f *FCMCoreService) ValidateUser(ctx context.Context, userID string) (bool, error) {
 	user, err := f.userUseCase.GetUserByID(ctx, userID)
@@ -253,16 +475,3 @@ func ConvertTo24Hour(time12 string) (string, error) {
 
 	return t.Format("15:04"), nil
 }
-
-// isTimeMatch checks if current time matches scheduled time (within 1 minute)
-func (f *FCMCoreService) isTimeMatch(currentTime, scheduledTime string) bool {
-	current, err1 := time.Parse("15:04", currentTime)
-	scheduled, err2 := time.Parse("15:04", scheduledTime)
-
-	if err1 != nil || err2 != nil {
-		return false
-	}
-
-	diff := current.Sub(scheduled)
-	return diff >= 0 && diff < time.Minute
-}
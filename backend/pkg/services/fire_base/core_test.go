@@ -0,0 +1,63 @@
+package fire_base
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeleteUserPreferencesAnonymisesLogs(t *testing.T) {
+	fns, _ := newTestNotificationService(t, nil)
+	addTestUser(t, fns, "user-1", "token-1")
+	ctx := context.Background()
+	db := fns.fcmCore.db
+
+	if err := fns.SendNotification(ctx, NotificationRequest{UserID: "user-1", Title: "Private", Body: "Journal reminder"}); err != nil {
+		t.Fatal(err)
+	}
+	deferred := addDeferredLog(t, fns, "user-1", "token-1", time.Now().UTC().Add(time.Hour))
+
+	if err := fns.DeleteUserPreferences(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := db.Model(&NotificationLog{}).Where("user_id = ?", "user-1").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%d notification logs still tied to the user", count)
+	}
+
+	var logs []NotificationLog
+	if err := db.Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("kept %d notification logs, want 2", len(logs))
+	}
+	for _, log := range logs {
+		if !strings.HasPrefix(log.UserID, "deleted-") || log.Token != "" || log.Title != "" || log.Body != "" || log.Data != "" {
+			t.Errorf("notification log not anonymised: %+v", log)
+		}
+	}
+	if log := getLog(t, fns, deferred.ID); log.Status != DeliveryFailed || log.NextAttemptAt != nil {
+		t.Errorf("deferred notification is %s, want %s", log.Status, DeliveryFailed)
+	}
+	if err := db.Model(&NotificationLog{}).Where("status = ?", DeliverySent).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d sent notifications kept, want 1", count)
+	}
+
+	for _, model := range []any{&DeviceToken{}, &ReminderSchedule{}, &FCMUserPreferences{}} {
+		if err := db.Model(model).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%d %T rows left", count, model)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package fire_base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const deviceLookupBatchSize = 500
+
+// Device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+)
+
+// DeviceToken is the FCM registration token of one device of a user.
+// Notifications to a user go to all of their active devices.
+type DeviceToken struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     string    `json:"-" gorm:"not null;index"`
+	Token      string    `json:"-" gorm:"not null;uniqueIndex"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"app_version"`
+	IsActive   bool      `json:"is_active" gorm:"default:true"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RegisterDevice stores the token of a device for a user and marks it active.
+// A token registered before, by this or another user, moves to the user.
+func (f *FCMCoreService) RegisterDevice(ctx context.Context, userID string, device DeviceToken) (*DeviceToken, error) {
+	device.ID = 0
+	device.UserID = userID
+	device.IsActive = true
+	device.LastSeenAt = time.Now().UTC()
+
+	err := f.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "app_version", "is_active", "last_seen_at", "updated_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %v", err)
+	}
+
+	var stored DeviceToken
+	if err := f.db.WithContext(ctx).Where("token = ?", device.Token).First(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to get device: %v", err)
+	}
+	return &stored, nil
+}
+
+// UnregisterDevice removes a device of a user, domain.ErrResourceNotFound when
+// the token is not theirs
+func (f *FCMCoreService) UnregisterDevice(ctx context.Context, userID, token string) error {
+	result := f.db.WithContext(ctx).Where("user_id = ? AND token = ?", userID, token).Delete(&DeviceToken{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unregister device: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrResourceNotFound
+	}
+	return nil
+}
+
+// GetUserDevices returns the devices of a user, most recently seen first
+func (f *FCMCoreService) GetUserDevices(ctx context.Context, userID string) ([]DeviceToken, error) {
+	var devices []DeviceToken
+	if err := f.db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get devices: %v", err)
+	}
+	return devices, nil
+}
+
+// activeDeviceTokens returns the tokens notifications to a user are sent to
+func (f *FCMCoreService) activeDeviceTokens(ctx context.Context, userID string) ([]string, error) {
+	var tokens []string
+	err := f.db.WithContext(ctx).Model(&DeviceToken{}).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Pluck("token", &tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device tokens: %v", err)
+	}
+	return tokens, nil
+}
+
+// GetActiveDeviceTokens returns the tokens of the active devices of users
+func (f *FCMCoreService) GetActiveDeviceTokens(ctx context.Context, userIDs []string) ([]string, error) {
+	var tokens []string
+	// Keep within the number of variables SQLite allows in a query
+	for start := 0; start < len(userIDs); start += deviceLookupBatchSize {
+		end := min(start+deviceLookupBatchSize, len(userIDs))
+		var batch []string
+		err := f.db.WithContext(ctx).Model(&DeviceToken{}).
+			Where("user_id IN ? AND is_active = ?", userIDs[start:end], true).
+			Pluck("token", &batch).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device tokens: %v", err)
+		}
+		tokens = append(tokens, batch...)
+	}
+	return tokens, nil
+}
+
+// HasActiveDevice reports whether a user has a device to send notifications to
+func (f *FCMCoreService) HasActiveDevice(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	err := f.db.WithContext(ctx).Model(&DeviceToken{}).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to count devices: %v", err)
+	}
+	return count > 0, nil
+}
+
+// migrateLegacyTokens copies the single token kept in FCMUserPreferences, from
+// before users could have several devices, into the devices table. Tokens FCM
+// had already rejected are dropped.
+func migrateLegacyTokens(db *gorm.DB) error {
+	var prefs []FCMUserPreferences
+	if err := db.Where("fcm_token != ''").Find(&prefs).Error; err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pref := range prefs {
+		if pref.IsActive {
+			device := DeviceToken{
+				UserID:     pref.UserID,
+				Token:      pref.FCMToken,
+				LastSeenAt: pref.UpdatedAt,
+			}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&device).Error; err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if err := db.Model(&FCMUserPreferences{}).Where("id = ?", pref.ID).Update("fcm_token", "").Error; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(prefs) > 0 {
+		logger.Log.WithField("tokens", len(prefs)).Info("Moved FCM tokens to the devices table")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package fire_base
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+// Categories of notifications, each with its own daily cap
+const (
+	CategoryReminder  = "reminder"
+	CategoryChallenge = "challenge"
+	CategoryCampaign  = "campaign"
+	CategoryGroup     = "group"
+	CategoryOther     = "other"
+)
+
+// DeliveryLimits keep a user from getting many notifications in a short time.
+// They mirror the quiet hours and caps of the user's notification preferences.
+type DeliveryLimits struct {
+	QuietHoursStart string `json:"quiet_hours_start"` // "22:00" in the user's timezone, empty when off
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	DailyCap        int    `json:"daily_cap"` // Zero caps are no limit
+	ReminderCap     int    `json:"reminder_cap"`
+	ChallengeCap    int    `json:"challenge_cap"`
+	CampaignCap     int    `json:"campaign_cap"`
+}
+
+// deliveryLimitsFromPrefs takes the limits from the notification preferences of a profile
+func deliveryLimitsFromPrefs(prefs types.NotificationsPref) DeliveryLimits {
+	limits := DeliveryLimits{
+		DailyCap:     prefs.Caps.Daily,
+		ReminderCap:  prefs.Caps.Reminders,
+		ChallengeCap: prefs.Caps.Challenges,
+		CampaignCap:  prefs.Caps.Campaigns,
+	}
+	if prefs.QuietHours.Enabled() {
+		limits.QuietHoursStart = prefs.QuietHours.Start
+		limits.QuietHoursEnd = prefs.QuietHours.End
+	}
+	return limits
+}
+
+func (l DeliveryLimits) isZero() bool {
+	return l == DeliveryLimits{}
+}
+
+// capFor is the daily cap of a category, zero when it has none
+func (l DeliveryLimits) capFor(category string) int {
+	switch category {
+	case CategoryReminder:
+		return l.ReminderCap
+	case CategoryChallenge:
+		return l.ChallengeCap
+	case CategoryCampaign:
+		return l.CampaignCap
+	default:
+		return 0
+	}
+}
+
+// quietUntil reports whether now is in the quiet hours, and if so when they end
+func (l DeliveryLimits) quietUntil(now time.Time) (time.Time, bool) {
+	start, errStart := time.Parse("15:04", l.QuietHoursStart)
+	end, errEnd := time.Parse("15:04", l.QuietHoursEnd)
+	if errStart != nil || errEnd != nil || start.Equal(end) {
+		return time.Time{}, false
+	}
+
+	minuteOfDay := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+	current, from, to := minuteOfDay(now), minuteOfDay(start), minuteOfDay(end)
+	endOn := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, now.Location())
+	}
+
+	if from < to {
+		// Within a day, e.g. 13:00 to 15:00
+		if current >= from && current < to {
+			return endOn(now), true
+		}
+		return time.Time{}, false
+	}
+	// Past midnight, e.g. 22:00 to 07:00
+	if current >= from {
+		return endOn(now.AddDate(0, 0, 1)), true
+	}
+	if current < to {
+		return endOn(now), true
+	}
+	return time.Time{}, false
+}
+
+// notificationCategory tells the category of a notification from its campaign
+func notificationCategory(campaign string) string {
+	switch {
+	case strings.HasPrefix(campaign, "reminder_"):
+		return CategoryReminder
+	case strings.HasPrefix(campaign, "challenge"):
+		return CategoryChallenge
+	case strings.HasPrefix(campaign, "campaign-"):
+		return CategoryCampaign
+	case strings.HasPrefix(campaign, "group_"):
+		return CategoryGroup
+	default:
+		return CategoryOther
+	}
+}
+
+// deliveryVerdict is what the limits of a user allow for a notification
+type deliveryVerdict int
+
+const (
+	sendNow deliveryVerdict = iota
+	sendLater
+	suppress
+)
+
+// checkDeliveryLimits decides whether a notification of a category goes to a
+// user now, is held back until the quiet hours end, or is over a daily cap.
+// Notifications to users without preferences are sent, and so are those whose
+// caps cannot be checked rather than lose them.
+func (f *FCMCoreService) checkDeliveryLimits(ctx context.Context, prefs *FCMUserPreferences, category string, now time.Time) (deliveryVerdict, time.Time) {
+	if prefs == nil || prefs.Limits.isZero() {
+		return sendNow, time.Time{}
+	}
+	local := now.In(utils.LoadTimezone(prefs.Timezone))
+
+	if until, quiet := prefs.Limits.quietUntil(local); quiet {
+		return sendLater, until.UTC()
+	}
+
+	categoryCap := prefs.Limits.capFor(category)
+	if prefs.Limits.DailyCap == 0 && categoryCap == 0 {
+		return sendNow, time.Time{}
+	}
+	startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	sent, err := f.sentTodayByCategory(ctx, prefs.UserID, startOfDay.UTC())
+	if err != nil {
+		logger.Log.WithError(err).WithField("user_id", prefs.UserID).Warn("Could not check notification caps")
+		return sendNow, time.Time{}
+	}
+
+	total := 0
+	for _, count := range sent {
+		total += count
+	}
+	if prefs.Limits.DailyCap > 0 && total >= prefs.Limits.DailyCap {
+		return suppress, time.Time{}
+	}
+	if categoryCap > 0 && sent[category] >= categoryCap {
+		return suppress, time.Time{}
+	}
+	return sendNow, time.Time{}
+}
+
+// holdBack marks a log deferred until the quiet hours end or suppressed over a
+// cap, as the verdict says. It reports whether the log is held back.
+func holdBack(log *NotificationLog, verdict deliveryVerdict, until time.Time) bool {
+	switch verdict {
+	case sendLater:
+		log.Status = DeliveryDeferred
+		log.NextAttemptAt = &until
+		return true
+	case suppress:
+		log.Status = DeliverySuppressed
+		log.NextAttemptAt = nil
+		return true
+	default:
+		return false
+	}
+}
+
+// sentTodayByCategory counts the notifications sent to a user since a time.
+// A notification sent to several devices of the user counts once.
+func (f *FCMCoreService) sentTodayByCategory(ctx context.Context, userID string, since time.Time) (map[string]int, error) {
+	var rows []struct {
+		Category string
+		Count    int
+	}
+	err := f.db.WithContext(ctx).Model(&NotificationLog{}).
+		Select("category, COUNT(DISTINCT notification_id) AS count").
+		Where("user_id = ? AND status = ? AND sent_at >= ?", userID, DeliverySent, since).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sent notifications: %v", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+// UpdateDeliveryLimits stores the quiet hours and caps of a user who has preferences
+func (f *FCMCoreService) UpdateDeliveryLimits(ctx context.Context, userID string, limits DeliveryLimits) error {
+	err := f.db.WithContext(ctx).Model(&FCMUserPreferences{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"quiet_hours_start": limits.QuietHoursStart,
+			"quiet_hours_end":   limits.QuietHoursEnd,
+			"daily_cap":         limits.DailyCap,
+			"reminder_cap":      limits.ReminderCap,
+			"challenge_cap":     limits.ChallengeCap,
+			"campaign_cap":      limits.CampaignCap,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update delivery limits: %v", err)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"yefe_app/v1/internal/domain"
 	"yefe_app/v1/pkg/logger"
 	service "yefe_app/v1/pkg/services"
+	"yefe_app/v1/pkg/types"
 	"yefe_app/v1/pkg/utils"
 )
 
@@ -18,13 +19,32 @@ type FCMNotificationService struct {
 	serviceManager *service.ServiceManager
 	ctx            context.Context
 	cancel         context.CancelFunc
+
+	reminderContent ReminderContent
+	inbox           Inbox
+	now             func() time.Time
+}
+
+// Inbox keeps the notifications sent to users for the in-app notification list
+type Inbox interface {
+	AddToInbox(ctx context.Context, userIDs []string, notification domain.InboxNotification) error
 }
 
 // FCMServiceConfig holds configuration for FCM notification service
 type FCMServiceConfig struct {
-	Config                 utils.FirebaseConfig
+	Config utils.FirebaseConfig
+	Push   utils.PushConfig
+	// Sender replaces the one selected in Push, so tests can pass a MemorySender
+	Sender PushSender
+	// ReminderContent writes the daily reminders, they have a fixed text without it
+	ReminderContent ReminderContent
+	// Inbox, when set, keeps the notifications sent to a user
+	Inbox                  Inbox
 	DatabasePath           string
 	NotificationWorkerName string
+	// Clock is the time reminders are scheduled from, time.Now when nil. Tests
+	// pass a fake one.
+	Clock func() time.Time
 }
 
 // NewFCMNotificationService creates a new FCM notification service
@@ -33,7 +53,16 @@ func NewFCMNotificationService(ctx context.Context, cancel context.CancelFunc, c
 	// Create context for the service
 
 	// Initialize FCM core service
-	fcmCore, err := NewFCMCoreService(config.Config, userUseCase, config.DatabasePath)
+	sender := config.Sender
+	if sender == nil {
+		var err error
+		sender, err = NewPushSender(config.Push, config.Config)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create push sender: %v", err)
+		}
+	}
+	fcmCore, err := NewFCMCoreService(sender, userUseCase, config.DatabasePath)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to create FCM core service: %v", err)
@@ -55,12 +84,21 @@ func NewFCMNotificationService(ctx context.Context, cancel context.CancelFunc, c
 	// Create service manager
 	serviceManager := service.NewServiceManager()
 
+	clock := config.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
 	return &FCMNotificationService{
 		fcmCore:        fcmCore,
 		scheduler:      scheduler,
 		serviceManager: serviceManager,
 		ctx:            ctx,
 		cancel:         cancel,
+
+		reminderContent: config.ReminderContent,
+		inbox:           config.Inbox,
+		now:             clock,
 	}, nil
 }
 
@@ -105,9 +143,9 @@ func (fns *FCMNotificationService) SendNotification(ctx context.Context, req Not
 	return fns.fcmCore.SendNotification(ctx, req)
 }
 
-// SendBulkNotifications sends bulk notifications
-func (fns *FCMNotificationService) SendBulkNotifications(ctx context.Context, tokens []string, title, body string, data map[string]string) error {
-	return fns.fcmCore.SendBulkNotifications(ctx, tokens, title, body, data)
+// SendBulkNotifications sends bulk notifications, campaign groups them in the delivery stats
+func (fns *FCMNotificationService) SendBulkNotifications(ctx context.Context, campaign string, tokens []string, title, body string, data map[string]string) error {
+	return fns.fcmCore.SendBulkNotifications(ctx, campaign, tokens, title, body, data)
 }
 
 // ScheduleNotification schedules a one-time notification
@@ -116,10 +154,11 @@ func (fns *FCMNotificationService) ScheduleNotification(id, title, body string,
 
 	notificationFunc := func(ctx context.Context) error {
 		req := NotificationRequest{
-			Token: token,
-			Title: title,
-			Body:  body,
-			Data:  data,
+			Campaign: id,
+			Token:    token,
+			Title:    title,
+			Body:     body,
+			Data:     data,
 		}
 		return fns.fcmCore.SendNotification(ctx, req)
 	}
@@ -127,12 +166,22 @@ func (fns *FCMNotificationService) ScheduleNotification(id, title, body string,
 	return fns.scheduler.AddOneTimeJob(id, jobName, runAt, notificationFunc)
 }
 
-// ScheduleBulkNotification schedules a bulk notification
-func (fns *FCMNotificationService) ScheduleBulkNotification(id, title, body string, tokens []string, runAt time.Time, data map[string]string) error {
+// ScheduleBulkNotification schedules a bulk notification to the tokens that
+// resolveTokens returns when it runs, so devices registered or removed in the
+// meantime are taken into account. onDone, when not nil, is called with the
+// outcome once it has been sent.
+func (fns *FCMNotificationService) ScheduleBulkNotification(id, title, body string, resolveTokens func(context.Context) ([]string, error), runAt time.Time, data map[string]string, onDone func(context.Context, error)) error {
 	jobName := fmt.Sprintf("fcm-bulk-notification-%s", id)
 
 	notificationFunc := func(ctx context.Context) error {
-		return fns.fcmCore.SendBulkNotifications(ctx, tokens, title, body, data)
+		tokens, err := resolveTokens(ctx)
+		if err == nil {
+			err = fns.fcmCore.SendBulkNotifications(ctx, id, tokens, title, body, data)
+		}
+		if onDone != nil {
+			onDone(ctx, err)
+		}
+		return err
 	}
 
 	return fns.scheduler.AddOneTimeJob(id, jobName, runAt, notificationFunc)
@@ -141,18 +190,46 @@ func (fns *FCMNotificationService) ScheduleBulkNotification(id, title, body stri
 // AddRecurringNotification adds a recurring notification job
 func (fns *FCMNotificationService) AddRecurringNotification(id, prefId, title, body, cronSchedule string, data map[string]string) error {
 	jobName := fmt.Sprintf("fcm-recurring-notification-%s", id)
+	return fns.scheduler.AddJob(id, jobName, cronSchedule, fns.userNotificationFunc("recurring", prefId, title, body, data))
+}
 
-	notificationFunc := func(ctx context.Context) error {
-		pref, err := fns.GetUserPreferences(ctx, prefId)
-		if err != nil || !pref.IsActive {
-			logger.Log.WithError(err).Error("Error or pref is not active")
-			return err
+// userNotificationFunc sends a notification to the active devices of a user
+// each time it runs
+func (fns *FCMNotificationService) userNotificationFunc(campaign, prefId, title, body string, data map[string]string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return fns.SendToUser(ctx, campaign, prefId, title, body, data)
+	}
+}
+
+// SendToUser keeps a notification in the inbox of a user and sends it to their
+// active devices when they have notifications turned on. Users without a
+// device only get it in the inbox.
+func (fns *FCMNotificationService) SendToUser(ctx context.Context, campaign, userID, title, body string, data map[string]string) error {
+	if fns.inbox != nil {
+		notification := domain.InboxNotification{Category: notificationCategory(campaign), Title: title, Body: body, Data: data}
+		if err := fns.inbox.AddToInbox(ctx, []string{userID}, notification); err != nil {
+			logger.Log.WithError(err).WithField("user_id", userID).Error("Could not add notification to the inbox")
 		}
-		req := NotificationRequest{Token: pref.FCMToken, Title: title, Body: body, Data: data}
-		return fns.fcmCore.SendNotification(ctx, req)
 	}
 
-	return fns.scheduler.AddJob(id, jobName, cronSchedule, notificationFunc)
+	pref, err := fns.GetUserPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if pref == nil || !pref.IsActive {
+		logger.Log.WithField("user_id", userID).Debug("Push notifications off, not sending")
+		return nil
+	}
+	hasDevice, err := fns.fcmCore.HasActiveDevice(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !hasDevice {
+		logger.Log.WithField("user_id", userID).Debug("No active device, not sending")
+		return nil
+	}
+	req := NotificationRequest{UserID: userID, Campaign: campaign, Title: title, Body: body, Data: data}
+	return fns.fcmCore.SendNotification(ctx, req)
 }
 
 // RemoveScheduledNotification removes a scheduled notification
@@ -160,6 +237,12 @@ func (fns *FCMNotificationService) RemoveScheduledNotification(id string) error
 	return fns.scheduler.RemoveJob(id)
 }
 
+// Sender returns the sender notifications go out with, a *MemorySender when
+// push.sender is "memory"
+func (fns *FCMNotificationService) Sender() PushSender {
+	return fns.fcmCore.sender
+}
+
 // GetUserPreferences gets FCM preferences for a user
 func (fns *FCMNotificationService) GetUserPreferences(ctx context.Context, userID string) (*FCMUserPreferences, error) {
 	return fns.fcmCore.GetUserPreferences(ctx, userID)
@@ -170,6 +253,70 @@ func (fns *FCMNotificationService) UpdateUserPreferences(ctx context.Context, us
 	return fns.fcmCore.UpdateUserPreferences(ctx, userID, preferences)
 }
 
+// DeleteUserPreferences removes the FCM preferences and devices of a user and
+// anonymises their notification logs
+func (fns *FCMNotificationService) DeleteUserPreferences(ctx context.Context, userID string) error {
+	return fns.fcmCore.DeleteUserPreferences(ctx, userID)
+}
+
+// RegisterDevice registers the FCM token of a device of a user and schedules
+// the user's reminders, which go to all of their devices
+func (fns *FCMNotificationService) RegisterDevice(ctx context.Context, userID string, device DeviceToken, prefs types.NotificationsPref, timezone string) (*DeviceToken, error) {
+	stored, err := fns.fcmCore.RegisterDevice(ctx, userID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := FCMUserPreferences{
+		MorningTime: prefs.Reminders.MorningReminder.String(),
+		EveningTime: prefs.Reminders.EveningReminder.String(),
+		Timezone:    timezone,
+	}
+	if err := fns.UpdateUserPreferences(ctx, userID, preferences); err != nil {
+		return nil, err
+	}
+	if err := fns.SyncUserReminders(ctx, userID, prefs, timezone); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// UnregisterDevice stops sending notifications to a device of a user. The
+// reminders of the user keep going to their inbox.
+func (fns *FCMNotificationService) UnregisterDevice(ctx context.Context, userID, token string) error {
+	return fns.fcmCore.UnregisterDevice(ctx, userID, token)
+}
+
+// GetUserDevices returns the registered devices of a user
+func (fns *FCMNotificationService) GetUserDevices(ctx context.Context, userID string) ([]DeviceToken, error) {
+	return fns.fcmCore.GetUserDevices(ctx, userID)
+}
+
+// GetActiveDeviceTokens returns the tokens of the active devices of users
+func (fns *FCMNotificationService) GetActiveDeviceTokens(ctx context.Context, userIDs []string) ([]string, error) {
+	return fns.fcmCore.GetActiveDeviceTokens(ctx, userIDs)
+}
+
+// HasActiveDevice reports whether a user has a device to send notifications to
+func (fns *FCMNotificationService) HasActiveDevice(ctx context.Context, userID string) (bool, error) {
+	return fns.fcmCore.HasActiveDevice(ctx, userID)
+}
+
+// RetryPendingNotifications sends again the notifications whose retry is due
+func (fns *FCMNotificationService) RetryPendingNotifications(ctx context.Context) (int, error) {
+	return fns.fcmCore.RetryPendingNotifications(ctx)
+}
+
+// ReleaseDeferredNotifications sends the notifications held back by quiet hours that have ended
+func (fns *FCMNotificationService) ReleaseDeferredNotifications(ctx context.Context) (int, error) {
+	return fns.fcmCore.ReleaseDeferredNotifications(ctx)
+}
+
+// GetDeliveryStats counts notification deliveries per campaign or per user
+func (fns *FCMNotificationService) GetDeliveryStats(ctx context.Context, filter DeliveryStatsFilter) ([]DeliveryStats, error) {
+	return fns.fcmCore.GetDeliveryStats(ctx, filter)
+}
+
 // GetSchedulerJobs returns all scheduled jobs
 func (fns *FCMNotificationService) GetSchedulerJobs() map[string]*service.Job {
 	return fns.scheduler.GetJobs()
@@ -0,0 +1,90 @@
+package fire_base
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"yefe_app/v1/pkg/logger"
+	service "yefe_app/v1/pkg/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestNotificationService builds a service that sends through a
+// MemorySender and keeps its data in a temporary database, with now as the
+// clock
+func newTestNotificationService(t *testing.T, now func() time.Time) (*FCMNotificationService, *MemorySender) {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	logger.Log = log
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	sender := NewMemorySender()
+	fns, err := NewFCMNotificationService(ctx, cancel, FCMServiceConfig{
+		Sender:       sender,
+		DatabasePath: t.TempDir() + "/fcm.db",
+		Clock:        now,
+	}, nil, service.NewScheduler(log, ctx, cancel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fns, sender
+}
+
+// addTestUser stores the preferences and the active devices of a user
+func addTestUser(t *testing.T, fns *FCMNotificationService, userID string, tokens ...string) {
+	t.Helper()
+	db := fns.fcmCore.db
+	if err := db.Create(&FCMUserPreferences{UserID: userID, Timezone: "UTC", IsActive: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range tokens {
+		device := DeviceToken{UserID: userID, Token: token, Platform: "android", IsActive: true, LastSeenAt: time.Now()}
+		if err := db.Create(&device).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScheduleBulkNotificationResolvesTokensWhenSent(t *testing.T) {
+	fns, sender := newTestNotificationService(t, nil)
+	ctx := context.Background()
+
+	var tokens []string
+	resolved := 0
+	resolveTokens := func(ctx context.Context) ([]string, error) {
+		resolved++
+		return tokens, nil
+	}
+	var outcome error
+	done := false
+	onDone := func(ctx context.Context, err error) { outcome, done = err, true }
+
+	if err := fns.ScheduleBulkNotification("campaign-1", "Hello", "World", resolveTokens, time.Now().Add(time.Hour), nil, onDone); err != nil {
+		t.Fatal(err)
+	}
+	if resolved != 0 {
+		t.Fatal("tokens resolved when the notification was scheduled")
+	}
+
+	// A device registered after scheduling still receives it
+	tokens = []string{"token-1", "token-2"}
+	job, ok := fns.scheduler.GetJob("campaign-1")
+	if !ok {
+		t.Fatal("bulk notification not scheduled")
+	}
+	if err := job.Function(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !done || outcome != nil {
+		t.Errorf("onDone called %v with %v, want called with nil", done, outcome)
+	}
+	if sent := sender.Sent(); len(sent) != 2 {
+		t.Errorf("sent %d notifications, want 2", len(sent))
+	}
+}
@@ -0,0 +1,376 @@
+package fire_base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"yefe_app/v1/pkg/logger"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// Delivery statuses of a NotificationLog. Deferred notifications wait for the
+// user's quiet hours to end, suppressed ones were over a daily cap.
+const (
+	DeliveryPending    = "pending"
+	DeliverySent       = "sent"
+	DeliveryFailed     = "failed"
+	DeliveryDeferred   = "deferred"
+	DeliverySuppressed = "suppressed"
+)
+
+const (
+	// maxDeliveryRetries is how often a send failing with a temporary error is retried
+	maxDeliveryRetries = 3
+	// retryBaseDelay doubles with every retry
+	retryBaseDelay = time.Minute
+	retryBatchSize = 100
+)
+
+var errDeviceInactive = errors.New("device is no longer active for the user")
+
+// NotificationLog records the delivery of a notification to one token. Sends
+// failing with a temporary error stay pending and are retried with backoff.
+// The logs of one notification to the devices of a user share a NotificationID.
+type NotificationLog struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	NotificationID string     `json:"notification_id" gorm:"index"`
+	UserID         string     `json:"user_id" gorm:"index"`
+	Campaign       string     `json:"campaign" gorm:"index"`
+	Category       string     `json:"category" gorm:"index"`
+	Token          string     `json:"-"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	Data           string     `json:"-"` // JSON encoded data payload
+	Status         string     `json:"status" gorm:"index"`
+	MessageID      string     `json:"message_id,omitempty"`
+	ErrorCode      string     `json:"error_code,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	RetryCount     int        `json:"retry_count"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// DeliveryStatsFilter selects the logs counted by GetDeliveryStats
+type DeliveryStatsFilter struct {
+	GroupBy  string // "campaign" or "user_id"
+	Campaign string
+	UserID   string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// DeliveryStats counts the deliveries of one campaign or user
+type DeliveryStats struct {
+	Key        string `json:"key"`
+	Total      int64  `json:"total"`
+	Sent       int64  `json:"sent"`
+	Failed     int64  `json:"failed"`
+	Pending    int64  `json:"pending"`
+	Deferred   int64  `json:"deferred"`
+	Suppressed int64  `json:"suppressed"`
+	Retries    int64  `json:"retries"`
+}
+
+func newNotificationLog(req NotificationRequest, notificationID string) NotificationLog {
+	data, _ := json.Marshal(req.Data)
+	return NotificationLog{
+		NotificationID: notificationID,
+		UserID:         req.UserID,
+		Campaign:       req.Campaign,
+		Category:       notificationCategory(req.Campaign),
+		Token:          req.Token,
+		Title:          req.Title,
+		Body:           req.Body,
+		Data:           string(data),
+		Status:         DeliveryPending,
+	}
+}
+
+func (l NotificationLog) request() NotificationRequest {
+	req := NotificationRequest{UserID: l.UserID, Campaign: l.Campaign, Token: l.Token, Title: l.Title, Body: l.Body}
+	if l.Data != "" && l.Data != "null" {
+		json.Unmarshal([]byte(l.Data), &req.Data)
+	}
+	return req
+}
+
+// deliver sends the notification of a log and records the outcome
+func (f *FCMCoreService) deliver(ctx context.Context, log *NotificationLog) error {
+	messageID, err := f.sender.Send(ctx, buildMessage(log.request()))
+	f.recordDelivery(ctx, log, messageID, err)
+	return err
+}
+
+// redeliver sends a log again after it was retried or held back, unless its
+// device was signed out or moved to another account since. Those logs are
+// marked failed without sending.
+func (f *FCMCoreService) redeliver(ctx context.Context, log *NotificationLog) error {
+	ok, err := f.stillOwnsToken(ctx, log.UserID, log.Token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		f.failDelivery(ctx, log, "device_inactive", errDeviceInactive.Error())
+		return errDeviceInactive
+	}
+	return f.deliver(ctx, log)
+}
+
+// stillOwnsToken reports whether token is an active device of the user. A log
+// without a user went to a token that was never registered, it may be sent
+// again as long as no user registered it since.
+func (f *FCMCoreService) stillOwnsToken(ctx context.Context, userID, token string) (bool, error) {
+	var devices []DeviceToken
+	err := f.db.WithContext(ctx).Select("user_id", "is_active").Where("token = ?", token).Limit(1).Find(&devices).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check device: %v", err)
+	}
+	if len(devices) == 0 {
+		return userID == "", nil
+	}
+	return devices[0].IsActive && devices[0].UserID == userID, nil
+}
+
+// recordDelivery stores the outcome of a send. Tokens FCM no longer accepts are
+// deactivated, temporary errors are retried until maxDeliveryRetries.
+func (f *FCMCoreService) recordDelivery(ctx context.Context, log *NotificationLog, messageID string, sendErr error) {
+	now := time.Now().UTC()
+	log.NextAttemptAt = nil
+
+	switch {
+	case sendErr == nil:
+		log.Status = DeliverySent
+		log.MessageID = messageID
+		log.ErrorCode, log.Error = "", ""
+		log.SentAt = &now
+	case isRetryable(sendErr) && log.RetryCount < maxDeliveryRetries:
+		next := now.Add(retryBaseDelay << log.RetryCount)
+		log.Status = DeliveryPending
+		log.RetryCount++
+		log.NextAttemptAt = &next
+	default:
+		log.Status = DeliveryFailed
+	}
+	if sendErr != nil {
+		log.ErrorCode = deliveryErrorCode(sendErr)
+		log.Error = sendErr.Error()
+	}
+
+	if err := f.db.WithContext(ctx).Save(log).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to record notification delivery")
+	}
+
+	if isDeadToken(sendErr) {
+		if err := f.DeactivateToken(ctx, log.Token); err != nil {
+			logger.Log.WithError(err).Error("Failed to deactivate FCM token")
+		}
+	}
+}
+
+// RetryPendingNotifications sends again the notifications whose retry is due,
+// to the devices still active for their user. It returns how many were
+// delivered.
+func (f *FCMCoreService) RetryPendingNotifications(ctx context.Context) (int, error) {
+	var logs []NotificationLog
+	err := f.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, time.Now().UTC()).
+		Order("next_attempt_at").
+		Limit(retryBatchSize).
+		Find(&logs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending notifications: %v", err)
+	}
+
+	delivered := 0
+	for i := range logs {
+		if err := f.redeliver(ctx, &logs[i]); err == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// ReleaseDeferredNotifications sends the notifications held back by quiet hours
+// that have ended, the longest waiting first. The limits of their users are
+// checked again, so they may be held back once more. Notifications whose user
+// is gone or whose limits cannot be read are marked failed, so they do not
+// wait forever. It returns how many were delivered.
+func (f *FCMCoreService) ReleaseDeferredNotifications(ctx context.Context) (int, error) {
+	now := time.Now()
+	var notificationIDs []string
+	err := f.db.WithContext(ctx).Model(&NotificationLog{}).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryDeferred, now.UTC()).
+		Group("notification_id").
+		Order("MIN(next_attempt_at)").
+		Limit(retryBatchSize).
+		Pluck("notification_id", &notificationIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deferred notifications: %v", err)
+	}
+
+	delivered := 0
+	for _, notificationID := range notificationIDs {
+		var logs []NotificationLog
+		err := f.db.WithContext(ctx).
+			Where("notification_id = ? AND status = ?", notificationID, DeliveryDeferred).
+			Find(&logs).Error
+		if err != nil || len(logs) == 0 {
+			continue
+		}
+
+		prefs, err := f.GetUserPreferences(ctx, logs[0].UserID)
+		if err != nil || prefs == nil {
+			reason := "user no longer receives notifications"
+			if err != nil {
+				reason = err.Error()
+			}
+			logger.Log.WithField("user_id", logs[0].UserID).Warnf("Dropping deferred notification: %s", reason)
+			for i := range logs {
+				f.failDelivery(ctx, &logs[i], "unreleasable", reason)
+			}
+			continue
+		}
+		verdict, until := f.checkDeliveryLimits(ctx, prefs, logs[0].Category, now)
+		for i := range logs {
+			if holdBack(&logs[i], verdict, until) {
+				if err := f.db.WithContext(ctx).Save(&logs[i]).Error; err != nil {
+					logger.Log.WithError(err).Error("Failed to record notification delivery")
+				}
+				continue
+			}
+			logs[i].Status = DeliveryPending
+			if err := f.redeliver(ctx, &logs[i]); err == nil {
+				delivered++
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// failDelivery gives up on a log without sending it
+func (f *FCMCoreService) failDelivery(ctx context.Context, log *NotificationLog, code, reason string) {
+	log.Status = DeliveryFailed
+	log.NextAttemptAt = nil
+	log.ErrorCode = code
+	log.Error = reason
+	if err := f.db.WithContext(ctx).Save(log).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to record notification delivery")
+	}
+}
+
+// DeactivateToken stops sending to a token FCM reported as unregistered
+func (f *FCMCoreService) DeactivateToken(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	result := f.db.WithContext(ctx).Model(&DeviceToken{}).
+		Where("token = ? AND is_active = ?", token, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		return fmt.Errorf("failed to deactivate token: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Info("Deactivated FCM token rejected by FCM")
+	}
+	return nil
+}
+
+// GetDeliveryStats counts deliveries per campaign or per user
+func (f *FCMCoreService) GetDeliveryStats(ctx context.Context, filter DeliveryStatsFilter) ([]DeliveryStats, error) {
+	groupBy := "campaign"
+	if filter.GroupBy == "user_id" {
+		groupBy = "user_id"
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := f.db.WithContext(ctx).Model(&NotificationLog{}).
+		Select(fmt.Sprintf(`%s AS key,
+			COUNT(*) AS total,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS sent,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS failed,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS pending,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS deferred,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS suppressed,
+			SUM(retry_count) AS retries`, groupBy), DeliverySent, DeliveryFailed, DeliveryPending, DeliveryDeferred, DeliverySuppressed)
+	if filter.Campaign != "" {
+		query = query.Where("campaign = ?", filter.Campaign)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", filter.To.UTC())
+	}
+
+	var stats []DeliveryStats
+	if err := query.Group(groupBy).Order("total DESC").Limit(limit).Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get delivery stats: %v", err)
+	}
+	return stats, nil
+}
+
+// userIDsByToken maps tokens to the users they belong to
+func (f *FCMCoreService) userIDsByToken(ctx context.Context, tokens []string) map[string]string {
+	var devices []DeviceToken
+	userIDs := make(map[string]string, len(tokens))
+	if err := f.db.WithContext(ctx).Select("user_id", "token").Where("token IN ?", tokens).Find(&devices).Error; err != nil {
+		logger.Log.WithError(err).Error("Failed to look up users of FCM tokens")
+		return userIDs
+	}
+	for _, device := range devices {
+		userIDs[device.Token] = device.UserID
+	}
+	return userIDs
+}
+
+// isRetryable reports whether a send may succeed later. Errors that do not come
+// from FCM, such as timeouts, are retried too.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return messaging.IsUnavailable(err) || messaging.IsInternal(err) || messaging.IsQuotaExceeded(err) || deliveryErrorCode(err) == "unknown"
+}
+
+// isDeadToken reports whether FCM will never accept the token again. An
+// invalid argument is not one, FCM also reports it for a bad payload.
+func isDeadToken(err error) bool {
+	return messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err)
+}
+
+// deliveryErrorCode names the FCM error of a failed send
+func deliveryErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case messaging.IsUnregistered(err):
+		return "unregistered"
+	case messaging.IsInvalidArgument(err):
+		return "invalid_argument"
+	case messaging.IsSenderIDMismatch(err):
+		return "sender_id_mismatch"
+	case messaging.IsQuotaExceeded(err):
+		return "quota_exceeded"
+	case messaging.IsUnavailable(err):
+		return "unavailable"
+	case messaging.IsInternal(err):
+		return "internal"
+	case messaging.IsThirdPartyAuthError(err):
+		return "third_party_auth_error"
+	default:
+		return "unknown"
+	}
+}
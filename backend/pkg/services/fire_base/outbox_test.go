@@ -0,0 +1,120 @@
+package fire_base
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yefe_app/v1/pkg/utils"
+)
+
+// addDeferredLog stores a notification held back until the given time
+func addDeferredLog(t *testing.T, fns *FCMNotificationService, userID, token string, until time.Time) NotificationLog {
+	t.Helper()
+	log := newNotificationLog(NotificationRequest{UserID: userID, Campaign: "reminder_morning", Token: token, Title: "Hello", Body: "World"}, utils.GenerateID())
+	log.Status = DeliveryDeferred
+	log.NextAttemptAt = &until
+	if err := fns.fcmCore.db.Create(&log).Error; err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func getLog(t *testing.T, fns *FCMNotificationService, id uint) NotificationLog {
+	t.Helper()
+	var log NotificationLog
+	if err := fns.fcmCore.db.First(&log, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func TestReleaseDeferredNotificationsFailsUnreleasable(t *testing.T) {
+	fns, sender := newTestNotificationService(t, nil)
+	addTestUser(t, fns, "user-1", "token-1")
+	ctx := context.Background()
+
+	// The user of the oldest notification was deleted since it was held back
+	gone := addDeferredLog(t, fns, "gone-user", "token-gone", time.Now().UTC().Add(-2*time.Hour))
+	kept := addDeferredLog(t, fns, "user-1", "token-1", time.Now().UTC().Add(-time.Hour))
+
+	delivered, err := fns.fcmCore.ReleaseDeferredNotifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 {
+		t.Errorf("delivered %d notifications, want 1", delivered)
+	}
+	if log := getLog(t, fns, gone.ID); log.Status != DeliveryFailed || log.NextAttemptAt != nil {
+		t.Errorf("notification of a deleted user is %s, want %s", log.Status, DeliveryFailed)
+	}
+	if log := getLog(t, fns, kept.ID); log.Status != DeliverySent {
+		t.Errorf("notification is %s, want %s", log.Status, DeliverySent)
+	}
+	if sent := sender.SentTo("token-gone"); len(sent) != 0 {
+		t.Errorf("sent %d notifications to a deleted user", len(sent))
+	}
+}
+
+func TestReleaseDeferredNotificationsLongestWaitingFirst(t *testing.T) {
+	fns, sender := newTestNotificationService(t, nil)
+	addTestUser(t, fns, "user-1", "token-1")
+	addTestUser(t, fns, "user-2", "token-2")
+	ctx := context.Background()
+
+	// A full batch of notifications held back after the one of user-2
+	start := time.Now().UTC().Add(-3 * time.Hour)
+	for i := range retryBatchSize {
+		addDeferredLog(t, fns, "user-1", "token-1", start.Add(time.Duration(i)*time.Second))
+	}
+	addDeferredLog(t, fns, "user-2", "token-2", start.Add(-time.Hour))
+
+	if _, err := fns.fcmCore.ReleaseDeferredNotifications(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sent := sender.SentTo("token-2"); len(sent) != 1 {
+		t.Errorf("sent %d notifications to the longest waiting user, want 1", len(sent))
+	}
+}
+
+func TestRetryPendingNotificationsChecksDevice(t *testing.T) {
+	fns, sender := newTestNotificationService(t, nil)
+	addTestUser(t, fns, "user-1", "token-active", "token-signed-out", "token-moved")
+	addTestUser(t, fns, "user-2")
+	ctx := context.Background()
+	db := fns.fcmCore.db
+
+	due := time.Now().UTC().Add(-time.Minute)
+	logs := map[string]NotificationLog{}
+	for _, token := range []string{"token-active", "token-signed-out", "token-moved"} {
+		log := newNotificationLog(NotificationRequest{UserID: "user-1", Token: token, Title: "Hello", Body: "World"}, utils.GenerateID())
+		log.RetryCount = 1
+		log.NextAttemptAt = &due
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatal(err)
+		}
+		logs[token] = log
+	}
+	if err := db.Model(&DeviceToken{}).Where("token = ?", "token-signed-out").Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&DeviceToken{}).Where("token = ?", "token-moved").Update("user_id", "user-2").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := fns.fcmCore.RetryPendingNotifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 {
+		t.Errorf("delivered %d notifications, want 1", delivered)
+	}
+	if sent := sender.Sent(); len(sent) != 1 || sent[0].Token != "token-active" {
+		t.Errorf("sent %+v, want only the notification to the active device", sent)
+	}
+	for _, token := range []string{"token-signed-out", "token-moved"} {
+		if log := getLog(t, fns, logs[token].ID); log.Status != DeliveryFailed || log.ErrorCode != "device_inactive" {
+			t.Errorf("retry to %s is %s (%s), want %s (device_inactive)", token, log.Status, log.ErrorCode, DeliveryFailed)
+		}
+	}
+}
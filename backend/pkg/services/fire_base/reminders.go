@@ -0,0 +1,231 @@
+package fire_base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"yefe_app/v1/pkg/logger"
+	service "yefe_app/v1/pkg/services"
+	"yefe_app/v1/pkg/types"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	ReminderMorning = "morning"
+	ReminderEvening = "evening"
+
+	reminderJobPrefix = "reminder-"
+)
+
+// ReminderMessage is the notification a daily reminder sends
+type ReminderMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// ReminderContent writes the notification of a daily reminder when it fires,
+// from the user's content of the day. A nil message skips the reminder.
+type ReminderContent interface {
+	ReminderMessage(ctx context.Context, userID, kind string) (*ReminderMessage, error)
+}
+
+// defaultReminderMessage is sent when there is no ReminderContent
+func defaultReminderMessage(kind string) *ReminderMessage {
+	return &ReminderMessage{
+		Title: "Daily Motivation",
+		Body:  "Here's your daily dose of motivation!",
+		Data:  map[string]string{"type": "daily", "reminder": kind},
+	}
+}
+
+// reminderJobID is the scheduler job of one kind of reminder of a user. It is
+// the same across restarts, so scheduling a reminder again replaces it.
+func reminderJobID(kind, userID string) string {
+	return reminderJobPrefix + kind + "-" + userID
+}
+
+// SyncUserReminders replaces the daily reminders of a user with ones that match
+// prefs, both the stored schedules and the jobs, and stores the quiet hours and
+// caps that apply to all of the user's notifications. Reminders fire at the wall
+// clock time in timezone. They are scheduled whether or not the user has a
+// device, a reminder always reaches the inbox and is only pushed to devices.
+func (fns *FCMNotificationService) SyncUserReminders(ctx context.Context, userID string, prefs types.NotificationsPref, timezone string) error {
+	morning := prefs.Reminders.MorningReminder.String()
+	evening := prefs.Reminders.EveningReminder.String()
+	if timezone == "" {
+		timezone = utils.DefaultTimezone
+	}
+	if err := fns.fcmCore.UpdateReminderTimes(ctx, userID, morning, evening, timezone); err != nil {
+		return err
+	}
+	if err := fns.fcmCore.UpdateDeliveryLimits(ctx, userID, deliveryLimitsFromPrefs(prefs)); err != nil {
+		return err
+	}
+
+	var schedules []ReminderSchedule
+	if prefs.MorningPrompt {
+		cronSchedule, err := reminderCron(morning, false)
+		if err != nil {
+			return err
+		}
+		schedules = append(schedules, ReminderSchedule{Kind: ReminderMorning, Time: morning, CronSchedule: cronSchedule, Timezone: timezone})
+	}
+	if prefs.EveningReflection {
+		cronSchedule, err := reminderCron(evening, true)
+		if err != nil {
+			return err
+		}
+		schedules = append(schedules, ReminderSchedule{Kind: ReminderEvening, Time: evening, CronSchedule: cronSchedule, Timezone: timezone})
+	}
+
+	if err := fns.fcmCore.ReplaceReminderSchedules(ctx, userID, schedules); err != nil {
+		return err
+	}
+	fns.unscheduleUserReminders(userID)
+	for _, schedule := range schedules {
+		if err := fns.scheduleReminder(schedule); err != nil {
+			return err
+		}
+	}
+
+	logger.Log.WithField("user_id", userID).Debug("Synced reminder jobs")
+	return nil
+}
+
+// RestoreReminders schedules the stored reminders again, it runs at startup
+// since scheduler jobs only live in memory. It returns how many were scheduled.
+func (fns *FCMNotificationService) RestoreReminders(ctx context.Context) (int, error) {
+	schedules, err := fns.fcmCore.GetReminderSchedules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	var errs []error
+	for _, schedule := range schedules {
+		if err := fns.scheduleReminder(schedule); err != nil {
+			logger.Log.WithError(err).WithFields(map[string]any{
+				"user_id": schedule.UserID,
+				"kind":    schedule.Kind,
+			}).Error("Could not restore reminder")
+			errs = append(errs, err)
+			continue
+		}
+		restored++
+	}
+	return restored, errors.Join(errs...)
+}
+
+// RemoveUserReminders stops the daily reminders of a user and forgets them
+func (fns *FCMNotificationService) RemoveUserReminders(ctx context.Context, userID string) error {
+	fns.unscheduleUserReminders(userID)
+	return fns.fcmCore.DeleteReminderSchedules(ctx, userID)
+}
+
+// scheduleReminder adds the job of a stored reminder, replacing a job already
+// scheduled for it
+func (fns *FCMNotificationService) scheduleReminder(reminder ReminderSchedule) error {
+	schedule, err := reminderSchedule(reminder, fns.now)
+	if err != nil {
+		return err
+	}
+
+	id := reminderJobID(reminder.Kind, reminder.UserID)
+	if _, ok := fns.scheduler.GetJob(id); ok {
+		fns.scheduler.RemoveJob(id)
+	}
+	if err := fns.scheduler.AddScheduleJob(id, "fcm-reminder-"+id, schedule, fns.reminderNotificationFunc(reminder.Kind, reminder.UserID)); err != nil {
+		return err
+	}
+
+	logger.Log.WithFields(map[string]any{
+		"user_id":  reminder.UserID,
+		"kind":     reminder.Kind,
+		"next_run": schedule.Upcoming(),
+	}).Debug("Scheduled reminder")
+	return nil
+}
+
+// reminderNotificationFunc sends a reminder with the content of the day each
+// time it runs
+func (fns *FCMNotificationService) reminderNotificationFunc(kind, userID string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		message := defaultReminderMessage(kind)
+		if fns.reminderContent != nil {
+			var err error
+			message, err = fns.reminderContent.ReminderMessage(ctx, userID, kind)
+			if err != nil {
+				logger.Log.WithError(err).WithField("user_id", userID).Error("Could not write reminder, sending the default one")
+				message = defaultReminderMessage(kind)
+			}
+		}
+		if message == nil {
+			logger.Log.WithFields(map[string]any{"user_id": userID, "kind": kind}).Debug("Nothing to remind today")
+			return nil
+		}
+		return fns.SendToUser(ctx, "reminder_"+kind, userID, message.Title, message.Body, message.Data)
+	}
+}
+
+// reminderSchedule turns the wall clock cron spec of a stored reminder into a
+// schedule in the user's timezone, read from the clock now. Cron specs with
+// CRON_TZ are not used since they skip or repeat reminders when daylight saving
+// time starts or ends.
+func reminderSchedule(reminder ReminderSchedule, now func() time.Time) (service.DailySchedule, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(reminder.CronSchedule, "0 %d %d * * *", &minute, &hour); err != nil {
+		return service.DailySchedule{}, fmt.Errorf("invalid reminder schedule %q: %w", reminder.CronSchedule, err)
+	}
+	schedule, err := service.NewDailySchedule(hour, minute, utils.LoadTimezone(reminder.Timezone))
+	if err != nil {
+		return service.DailySchedule{}, err
+	}
+	schedule.Now = now
+	return schedule, nil
+}
+
+func (fns *FCMNotificationService) unscheduleUserReminders(userID string) {
+	for _, kind := range []string{ReminderMorning, ReminderEvening} {
+		id := reminderJobID(kind, userID)
+		if _, ok := fns.scheduler.GetJob(id); ok {
+			fns.scheduler.RemoveJob(id)
+		}
+	}
+}
+
+// reminderCron turns a reminder time on the 12 hour clock, as stored in the
+// profile, into a daily cron schedule with seconds. Times with an AM/PM suffix
+// are taken as is, otherwise pm picks the afternoon.
+func reminderCron(reminder string, pm bool) (string, error) {
+	upper := strings.ToUpper(reminder)
+	if strings.Contains(upper, "AM") || strings.Contains(upper, "PM") {
+		converted, err := ConvertTo24Hour(reminder)
+		if err != nil {
+			return "", err
+		}
+		reminder, pm = converted, false
+	}
+
+	hourStr, minuteStr, ok := strings.Cut(strings.TrimSpace(reminder), ":")
+	if !ok {
+		return "", fmt.Errorf("invalid reminder time: %s", reminder)
+	}
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil || hour < 0 || hour > 23 {
+		return "", fmt.Errorf("invalid reminder time: %s", reminder)
+	}
+	minute, err := strconv.Atoi(minuteStr)
+	if err != nil || minute < 0 || minute > 59 {
+		return "", fmt.Errorf("invalid reminder time: %s", reminder)
+	}
+
+	if pm && hour < 12 {
+		hour += 12
+	}
+	return fmt.Sprintf("0 %d %d * * *", minute, hour), nil
+}
@@ -0,0 +1,149 @@
+package fire_base
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/types"
+)
+
+func TestSyncUserRemindersReplacesJobs(t *testing.T) {
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	fns, _ := newTestNotificationService(t, func() time.Time { return now })
+	addTestUser(t, fns, "user-1", "token-1")
+	ctx := context.Background()
+
+	prefs := types.NotificationsPref{
+		MorningPrompt:     true,
+		EveningReflection: true,
+		Reminders:         types.ReminderRequest{MorningReminder: "07:00", EveningReminder: "08:00"},
+	}
+	if err := fns.SyncUserReminders(ctx, "user-1", prefs, "America/New_York"); err != nil {
+		t.Fatal(err)
+	}
+	assertReminderJob(t, fns, reminderJobID(ReminderMorning, "user-1"), "daily at 07:00 America/New_York")
+	assertReminderJob(t, fns, reminderJobID(ReminderEvening, "user-1"), "daily at 20:00 America/New_York")
+
+	// Syncing again replaces the jobs under the same IDs
+	prefs.EveningReflection = false
+	prefs.Reminders.MorningReminder = "06:30"
+	if err := fns.SyncUserReminders(ctx, "user-1", prefs, "Asia/Kolkata"); err != nil {
+		t.Fatal(err)
+	}
+	assertReminderJob(t, fns, reminderJobID(ReminderMorning, "user-1"), "daily at 06:30 Asia/Kolkata")
+	if _, ok := fns.scheduler.GetJob(reminderJobID(ReminderEvening, "user-1")); ok {
+		t.Error("evening reminder still scheduled after it was turned off")
+	}
+	if jobs := fns.scheduler.GetJobs(); len(jobs) != 1 {
+		t.Errorf("scheduled %d jobs, want 1", len(jobs))
+	}
+
+	stored, err := fns.fcmCore.GetReminderSchedules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Kind != ReminderMorning || stored[0].CronSchedule != "0 30 6 * * *" {
+		t.Fatalf("stored reminders = %+v, want the morning one at 06:30", stored)
+	}
+
+	// The stored reminder runs next at 06:30 in Kolkata from the clock
+	schedule, err := reminderSchedule(stored[0], fns.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schedule.Upcoming(), time.Date(2025, 3, 9, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next reminder at %s, want %s", got, want)
+	}
+}
+
+// recordingInbox keeps the notifications added to the inbox of each user
+type recordingInbox struct {
+	added map[string][]domain.InboxNotification
+}
+
+func (i *recordingInbox) AddToInbox(ctx context.Context, userIDs []string, notification domain.InboxNotification) error {
+	for _, userID := range userIDs {
+		i.added[userID] = append(i.added[userID], notification)
+	}
+	return nil
+}
+
+func TestRemindersWithoutDeviceGoToInbox(t *testing.T) {
+	now := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	fns, sender := newTestNotificationService(t, func() time.Time { return now })
+	inbox := &recordingInbox{added: map[string][]domain.InboxNotification{}}
+	fns.inbox = inbox
+	addTestUser(t, fns, "user-2", "token-2")
+	ctx := context.Background()
+
+	prefs := types.NotificationsPref{
+		MorningPrompt: true,
+		Reminders:     types.ReminderRequest{MorningReminder: "07:00", EveningReminder: "08:00"},
+	}
+	// user-1 never accepted push notifications, user-2 signed out of their only device
+	if err := fns.fcmCore.db.Model(&DeviceToken{}).Where("user_id = ?", "user-2").Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []string{"user-1", "user-2"} {
+		if err := fns.SyncUserReminders(ctx, userID, prefs, "UTC"); err != nil {
+			t.Fatal(err)
+		}
+
+		job, ok := fns.scheduler.GetJob(reminderJobID(ReminderMorning, userID))
+		if !ok {
+			t.Fatalf("morning reminder of %s not scheduled", userID)
+		}
+		if err := job.Function(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := inbox.added[userID]; len(got) != 1 || got[0].Category != CategoryReminder {
+			t.Errorf("inbox of %s = %+v, want the reminder", userID, got)
+		}
+	}
+	if sent := sender.Sent(); len(sent) != 0 {
+		t.Errorf("pushed %d reminders without an active device", len(sent))
+	}
+}
+
+func TestReminderCron(t *testing.T) {
+	tests := []struct {
+		reminder string
+		pm       bool
+		want     string
+	}{
+		{"07:00", false, "0 0 7 * * *"},
+		{"08:30", true, "0 30 20 * * *"},
+		{"12:15", true, "0 15 12 * * *"},
+		{"9:45 PM", false, "0 45 21 * * *"},
+		{"9:45 AM", true, "0 45 9 * * *"},
+	}
+	for _, tt := range tests {
+		got, err := reminderCron(tt.reminder, tt.pm)
+		if err != nil {
+			t.Errorf("reminderCron(%q, %v): %v", tt.reminder, tt.pm, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("reminderCron(%q, %v) = %q, want %q", tt.reminder, tt.pm, got, tt.want)
+		}
+	}
+
+	for _, reminder := range []string{"", "7", "25:00", "07:60"} {
+		if _, err := reminderCron(reminder, false); err == nil {
+			t.Errorf("reminderCron(%q) succeeded", reminder)
+		}
+	}
+}
+
+func assertReminderJob(t *testing.T, fns *FCMNotificationService, id, schedule string) {
+	t.Helper()
+	job, ok := fns.scheduler.GetJob(id)
+	if !ok {
+		t.Fatalf("job %s is not scheduled", id)
+	}
+	if job.Schedule != schedule {
+		t.Errorf("job %s runs %q, want %q", id, job.Schedule, schedule)
+	}
+}
@@ -0,0 +1,208 @@
+package fire_base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"yefe_app/v1/pkg/logger"
+	"yefe_app/v1/pkg/utils"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+// Push senders selectable with push.sender in config.yaml
+const (
+	SenderFirebase = "firebase"
+	SenderLog      = "log"
+	SenderMemory   = "memory"
+)
+
+// PushSender delivers push messages. *messaging.Client implements it, the log
+// and memory senders stand in for it where there are no Firebase credentials.
+type PushSender interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+}
+
+// NewPushSender creates the sender selected in the config, Firebase when none is
+func NewPushSender(config utils.PushConfig, firebaseConfig utils.FirebaseConfig) (PushSender, error) {
+	switch config.Sender {
+	case "", SenderFirebase:
+		return NewFirebaseSender(firebaseConfig)
+	case SenderLog:
+		return NewLogSender(), nil
+	case SenderMemory:
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("unknown push sender %q", config.Sender)
+	}
+}
+
+// NewFirebaseSender creates a Firebase Cloud Messaging client
+func NewFirebaseSender(config utils.FirebaseConfig) (PushSender, error) {
+	jsonCreds, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling firebase config: %v", err)
+	}
+	opt := option.WithCredentialsJSON(jsonCreds)
+	app, err := firebase.NewApp(context.Background(), nil, opt)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing firebase app: %v", err)
+	}
+
+	client, err := app.Messaging(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting messaging client: %v", err)
+	}
+	return client, nil
+}
+
+// SentPush is a message accepted by a LogSender or MemorySender
+type SentPush struct {
+	MessageID string
+	Token     string
+	Title     string
+	Body      string
+	Data      map[string]string
+	SentAt    time.Time
+}
+
+// fakeMessageIDs numbers the messages of the log and memory senders
+var fakeMessageIDs atomic.Uint64
+
+func newSentPush(token string, notification *messaging.Notification, data map[string]string) SentPush {
+	push := SentPush{
+		MessageID: fmt.Sprintf("local-%d", fakeMessageIDs.Add(1)),
+		Token:     token,
+		Data:      data,
+		SentAt:    time.Now().UTC(),
+	}
+	if notification != nil {
+		push.Title = notification.Title
+		push.Body = notification.Body
+	}
+	return push
+}
+
+// sendEach sends a multicast message as one message per token, keeping the
+// platform specific options
+func sendEach(ctx context.Context, sender PushSender, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	response := &messaging.BatchResponse{Responses: make([]*messaging.SendResponse, len(message.Tokens))}
+	for i, token := range message.Tokens {
+		messageID, err := sender.Send(ctx, &messaging.Message{
+			Token:        token,
+			Notification: message.Notification,
+			Data:         message.Data,
+			Android:      message.Android,
+			Webpush:      message.Webpush,
+			APNS:         message.APNS,
+			FCMOptions:   message.FCMOptions,
+		})
+		response.Responses[i] = &messaging.SendResponse{Success: err == nil, MessageID: messageID, Error: err}
+		if err == nil {
+			response.SuccessCount++
+		} else {
+			response.FailureCount++
+		}
+	}
+	return response, nil
+}
+
+// LogSender writes messages to the log instead of sending them, for
+// development without Firebase credentials
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	push := newSentPush(message.Token, message.Notification, message.Data)
+	logger.Log.WithFields(map[string]any{
+		"message_id": push.MessageID,
+		"title":      push.Title,
+		"body":       push.Body,
+		"data":       push.Data,
+	}).Info("Push notification")
+	return push.MessageID, nil
+}
+
+func (s *LogSender) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	return sendEach(ctx, s, message)
+}
+
+// MemorySender records messages so tests can check which notifications a flow
+// produced. Sends to a token set with FailToken fail with its error.
+type MemorySender struct {
+	mu       sync.Mutex
+	sent     []SentPush
+	failures map[string]error
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{failures: make(map[string]error)}
+}
+
+func (s *MemorySender) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.failures[message.Token]; err != nil {
+		return "", err
+	}
+	push := newSentPush(message.Token, message.Notification, message.Data)
+	s.sent = append(s.sent, push)
+	return push.MessageID, nil
+}
+
+func (s *MemorySender) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	return sendEach(ctx, s, message)
+}
+
+// Sent returns the messages sent so far, oldest first
+func (s *MemorySender) Sent() []SentPush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentPush(nil), s.sent...)
+}
+
+// SentTo returns the messages sent to a token, oldest first
+func (s *MemorySender) SentTo(token string) []SentPush {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sent []SentPush
+	for _, push := range s.sent {
+		if push.Token == token {
+			sent = append(sent, push)
+		}
+	}
+	return sent
+}
+
+// FailToken makes sends to token fail with err, nil makes them succeed again
+func (s *MemorySender) FailToken(token string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.failures, token)
+		return
+	}
+	s.failures[token] = err
+}
+
+// Reset forgets the messages sent so far and the failing tokens
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = nil
+	s.failures = make(map[string]error)
+}
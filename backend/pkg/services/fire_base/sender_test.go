@@ -0,0 +1,102 @@
+package fire_base
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// recordingSender keeps the messages it is asked to send
+type recordingSender struct {
+	messages []*messaging.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	s.messages = append(s.messages, message)
+	return "id", nil
+}
+
+func (s *recordingSender) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	return sendEach(ctx, s, message)
+}
+
+func TestSendEachKeepsPlatformOptions(t *testing.T) {
+	sender := &recordingSender{}
+	message := &messaging.MulticastMessage{
+		Tokens:       []string{"token-1", "token-2"},
+		Notification: &messaging.Notification{Title: "Hello"},
+		Data:         map[string]string{"key": "value"},
+		Android:      &messaging.AndroidConfig{Priority: "high"},
+		Webpush:      &messaging.WebpushConfig{Headers: map[string]string{"Urgency": "high"}},
+		APNS:         &messaging.APNSConfig{Headers: map[string]string{"apns-priority": "10"}},
+		FCMOptions:   &messaging.FCMOptions{AnalyticsLabel: "campaign"},
+	}
+
+	response, err := sender.SendEachForMulticast(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.SuccessCount != 2 {
+		t.Errorf("%d messages succeeded, want 2", response.SuccessCount)
+	}
+	for i, sent := range sender.messages {
+		if sent.Token != message.Tokens[i] {
+			t.Errorf("message %d sent to %s, want %s", i, sent.Token, message.Tokens[i])
+		}
+		if sent.Android != message.Android || sent.Webpush != message.Webpush || sent.APNS != message.APNS || sent.FCMOptions != message.FCMOptions {
+			t.Errorf("message %d lost its platform options: %+v", i, sent)
+		}
+	}
+}
+
+func TestSendNotificationFansOutThroughMemorySender(t *testing.T) {
+	fns, sender := newTestNotificationService(t, nil)
+	addTestUser(t, fns, "user-1", "token-1", "token-2", "token-3")
+	ctx := context.Background()
+
+	// One device failing does not fail the notification
+	sender.FailToken("token-3", errors.New("network down"))
+	err := fns.SendNotification(ctx, NotificationRequest{
+		UserID:   "user-1",
+		Campaign: "challenge",
+		Title:    "New challenge",
+		Body:     "Join today",
+		Data:     map[string]string{"challenge_id": "c-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := sender.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(sent))
+	}
+	for _, token := range []string{"token-1", "token-2"} {
+		pushes := sender.SentTo(token)
+		if len(pushes) != 1 {
+			t.Errorf("sent %d notifications to %s, want 1", len(pushes), token)
+			continue
+		}
+		if push := pushes[0]; push.Title != "New challenge" || push.Body != "Join today" || push.Data["challenge_id"] != "c-1" {
+			t.Errorf("sent %+v to %s", push, token)
+		}
+	}
+
+	var logs []NotificationLog
+	if err := fns.fcmCore.db.Order("token").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("logged %d deliveries, want 3", len(logs))
+	}
+	if logs[0].NotificationID != logs[2].NotificationID {
+		t.Error("deliveries of one notification have different notification IDs")
+	}
+	for i, want := range []string{DeliverySent, DeliverySent, DeliveryPending} {
+		if logs[i].Status != want {
+			t.Errorf("delivery to %s is %s, want %s", logs[i].Token, logs[i].Status, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const mockKeyID = "mock-key"
+
+// MockIssuer is a local OpenID provider for the verifier tests. It serves
+// discovery and keys over HTTP and signs whatever ID tokens it is asked for.
+type MockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+// NewMockIssuer starts a mock provider, call Close when done
+func NewMockIssuer() (*MockIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MockIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:  m.URL(),
+			JWKSURI: m.URL() + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			KeyType: "RSA",
+			KeyID:   mockKeyID,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	m.server = httptest.NewServer(mux)
+	return m, nil
+}
+
+// URL is the issuer, use it as Config.Issuer
+func (m *MockIssuer) URL() string {
+	return m.server.URL
+}
+
+// Config returns a verifier configuration trusting this issuer for clientID
+func (m *MockIssuer) Config(clientID string) Config {
+	return Config{
+		Issuer:     m.URL(),
+		ClientIDs:  []string{clientID},
+		HTTPClient: m.server.Client(),
+	}
+}
+
+// IssueIDToken signs an ID token for subject. iss, aud, iat and exp are set
+// unless claims overrides them.
+func (m *MockIssuer) IssueIDToken(clientID, subject string, claims map[string]any) (string, error) {
+	all := jwt.MapClaims{
+		"iss": m.URL(),
+		"aud": clientID,
+		"sub": subject,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = mockKeyID
+	return token.SignedString(m.key)
+}
+
+// Close stops the mock provider
+func (m *MockIssuer) Close() {
+	m.server.Close()
+}
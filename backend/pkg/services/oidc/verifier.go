@@ -0,0 +1,245 @@
+// Package oidc verifies ID tokens issued by OpenID Connect providers such as
+// Google and Apple. Provider keys are found through the issuer's discovery
+// document and cached.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// jwksCacheTTL is how long provider keys are trusted before being fetched again
+	jwksCacheTTL = time.Hour
+	// jwksMinRefresh limits refetches triggered by tokens with an unknown kid
+	jwksMinRefresh = time.Minute
+	// clockSkew is the leeway allowed between our clock and the provider's
+	clockSkew = 2 * time.Minute
+)
+
+// Config describes one provider
+type Config struct {
+	// Issuer is the exact iss claim, e.g. https://accounts.google.com
+	Issuer string
+	// ClientIDs are the accepted audiences, one per app (web, iOS, Android)
+	ClientIDs []string
+	// HTTPClient defaults to a client with a 10 second timeout
+	HTTPClient *http.Client
+}
+
+// Verifier implements domain.IDTokenVerifier for one provider
+type Verifier struct {
+	config Config
+	client *http.Client
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// NewVerifier creates a verifier, discovery happens on the first token
+func NewVerifier(config Config) *Verifier {
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Verifier{
+		config: config,
+		client: client,
+	}
+}
+
+// Verify checks the signature and the standard claims of an ID token
+func (v *Verifier) Verify(ctx context.Context, rawIDToken, nonce string) (*domain.ExternalIdentity, error) {
+	parser := jwt.Parser{
+		ValidMethods: []string{"RS256", "ES256"},
+		// Time based claims are checked below, with clock skew
+		SkipClaimsValidation: true,
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keyFor(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidToken, err)
+	}
+
+	if err := v.validateClaims(claims, nonce, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidToken, err)
+	}
+
+	identity := &domain.ExternalIdentity{
+		Issuer:        v.config.Issuer,
+		Subject:       stringClaim(claims, "sub"),
+		Email:         strings.ToLower(strings.TrimSpace(stringClaim(claims, "email"))),
+		EmailVerified: boolClaim(claims, "email_verified"),
+		Name:          stringClaim(claims, "name"),
+	}
+	return identity, nil
+}
+
+func (v *Verifier) validateClaims(claims jwt.MapClaims, nonce string, now time.Time) error {
+	if stringClaim(claims, "iss") != v.config.Issuer {
+		return errors.New("unexpected issuer")
+	}
+	if stringClaim(claims, "sub") == "" {
+		return errors.New("missing subject")
+	}
+
+	audienceOK := false
+	for _, aud := range audiences(claims) {
+		if slices.Contains(v.config.ClientIDs, aud) {
+			audienceOK = true
+			break
+		}
+	}
+	if !audienceOK {
+		return errors.New("unexpected audience")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-clockSkew).Unix() > int64(exp) {
+		return errors.New("token expired")
+	}
+	if iat, ok := claims["iat"].(float64); ok && now.Add(clockSkew).Unix() < int64(iat) {
+		return errors.New("token issued in the future")
+	}
+
+	// Without a nonce a token could be replayed for as long as it is valid
+	if nonce == "" {
+		return errors.New("no nonce to check")
+	}
+	// Apple echoes the SHA-256 of the nonce the app passed to it
+	sum := sha256.Sum256([]byte(nonce))
+	got := stringClaim(claims, "nonce")
+	if got == "" {
+		return errors.New("missing nonce")
+	}
+	if got != nonce && got != hex.EncodeToString(sum[:]) {
+		return errors.New("nonce mismatch")
+	}
+	return nil
+}
+
+// keyFor returns the provider key with the given kid, refreshing the cache
+// when it is stale or does not know the kid yet
+func (v *Verifier) keyFor(ctx context.Context, kid string) (any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, known := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > jwksCacheTTL
+	canRefetch := time.Since(v.fetchedAt) > jwksMinRefresh
+
+	if stale || (!known && canRefetch) {
+		keys, err := v.fetchKeys(ctx)
+		if err != nil {
+			if known {
+				// Keep using the cached key while the provider is unreachable
+				return key, nil
+			}
+			return nil, err
+		}
+		v.keys = keys
+		v.fetchedAt = time.Now()
+		key, known = v.keys[kid]
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// fetchKeys downloads the provider key set, running discovery first if needed
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]any, error) {
+	if v.jwksURI == "" {
+		var doc discoveryDocument
+		discoveryURL := strings.TrimSuffix(v.config.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, discoveryURL, &doc); err != nil {
+			return nil, fmt.Errorf("oidc discovery failed: %w", err)
+		}
+		if doc.Issuer != v.config.Issuer || doc.JWKSURI == "" {
+			return nil, fmt.Errorf("oidc discovery returned issuer %q", doc.Issuer)
+		}
+		v.jwksURI = doc.JWKSURI
+	}
+
+	var set jsonWebKeySet
+	if err := v.getJSON(ctx, v.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch provider keys: %w", err)
+	}
+	return set.publicKeys(), nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// boolClaim reads a boolean claim, Apple sends email_verified as a string
+func boolClaim(claims jwt.MapClaims, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	default:
+		return false
+	}
+}
+
+func audiences(claims jwt.MapClaims) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		values := make([]string, 0, len(aud))
+		for _, item := range aud {
+			if value, ok := item.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
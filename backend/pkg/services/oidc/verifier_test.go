@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+	"yefe_app/v1/internal/domain"
+)
+
+const testClientID = "test-client"
+
+func newTestIssuer(t *testing.T) *MockIssuer {
+	t.Helper()
+	issuer, err := NewMockIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func TestVerifierAcceptsValidToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := NewVerifier(issuer.Config(testClientID))
+
+	token, err := issuer.IssueIDToken(testClientID, "subject-1", map[string]any{
+		"email":          " User@Example.com ",
+		"email_verified": "true",
+		"name":           "Test User",
+		"nonce":          "nonce-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := verifier.Verify(context.Background(), token, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := domain.ExternalIdentity{
+		Issuer:        issuer.URL(),
+		Subject:       "subject-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Name:          "Test User",
+	}
+	if *identity != want {
+		t.Errorf("Verify() = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestVerifierRejectsInvalidTokens(t *testing.T) {
+	issuer := newTestIssuer(t)
+	other := newTestIssuer(t)
+	verifier := NewVerifier(issuer.Config(testClientID))
+	now := time.Now()
+
+	hashedNonce := sha256.Sum256([]byte("nonce-1"))
+	noNonce := ""
+
+	// Unless a case says otherwise the token carries nonce-1 and nonce-1 is expected
+	tests := []struct {
+		name   string
+		issuer *MockIssuer
+		aud    string
+		claims map[string]any
+		nonce  *string
+		valid  bool
+	}{
+		{name: "valid", valid: true},
+		{name: "signed by another key", issuer: other, claims: map[string]any{"iss": issuer.URL()}},
+		{name: "other issuer", claims: map[string]any{"iss": other.URL()}},
+		{name: "other audience", aud: "other-client"},
+		{name: "one of several audiences", claims: map[string]any{"aud": []any{"other-client", testClientID}}, valid: true},
+		{name: "expired", claims: map[string]any{"exp": now.Add(-time.Hour).Unix()}},
+		{name: "expired within clock skew", claims: map[string]any{"exp": now.Add(-time.Minute).Unix()}, valid: true},
+		{name: "issued in the future", claims: map[string]any{"iat": now.Add(time.Hour).Unix()}},
+		{name: "no subject", claims: map[string]any{"sub": ""}},
+		{name: "hashed nonce", claims: map[string]any{"nonce": hex.EncodeToString(hashedNonce[:])}, valid: true},
+		{name: "other nonce", claims: map[string]any{"nonce": "nonce-2"}},
+		{name: "missing nonce", claims: map[string]any{"nonce": ""}},
+		{name: "no nonce expected", nonce: &noNonce},
+		{name: "no nonce at all", claims: map[string]any{"nonce": ""}, nonce: &noNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := tt.issuer
+			if signer == nil {
+				signer = issuer
+			}
+			aud := tt.aud
+			if aud == "" {
+				aud = testClientID
+			}
+			claims := map[string]any{"nonce": "nonce-1"}
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+			token, err := signer.IssueIDToken(aud, "subject-1", claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce := "nonce-1"
+			if tt.nonce != nil {
+				nonce = *tt.nonce
+			}
+
+			_, err = verifier.Verify(context.Background(), token, nonce)
+			if tt.valid && err != nil {
+				t.Errorf("Verify() = %v, want a valid token", err)
+			}
+			if !tt.valid && !errors.Is(err, domain.ErrInvalidToken) {
+				t.Errorf("Verify() = %v, want %v", err, domain.ErrInvalidToken)
+			}
+		})
+	}
+}
@@ -116,17 +116,7 @@ func (s *Scheduler) AddJob(id, name, schedule string, fn func(context.Context) e
 		if err != nil {
 			return fmt.Errorf("failed to add cron job: %w", err)
 		}
-		job.cronID = cronID
-
-		// Set next run time
-		entries := s.cron.Entries()
-		for _, entry := range entries {
-			if entry.ID == cronID {
-				nextRun := entry.Next
-				job.NextRun = &nextRun
-				break
-			}
-		}
+		s.setCronEntry(job, cronID)
 	} else {
 		// One-time job, run immediately
 		go s.runJob(job)
@@ -136,6 +126,38 @@ func (s *Scheduler) AddJob(id, name, schedule string, fn func(context.Context) e
 	return nil
 }
 
+// AddScheduleJob adds a recurring job that runs on a schedule a cron spec
+// cannot express, such as a DailySchedule
+func (s *Scheduler) AddScheduleJob(id, name string, schedule cron.Schedule, fn func(context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[id]; exists {
+		return fmt.Errorf("job with ID %s already exists", id)
+	}
+
+	job := &Job{
+		ID:          id,
+		Name:        name,
+		Schedule:    fmt.Sprint(schedule),
+		Function:    fn,
+		Status:      StatusPending,
+		CreatedAt:   time.Now(),
+		IsRecurring: true,
+	}
+	s.setCronEntry(job, s.cron.Schedule(schedule, cron.FuncJob(s.wrapJobFunction(job))))
+
+	s.jobs[id] = job
+	return nil
+}
+
+// setCronEntry links a job to its cron entry and records its next run
+func (s *Scheduler) setCronEntry(job *Job, cronID cron.EntryID) {
+	job.cronID = cronID
+	nextRun := s.cron.Entry(cronID).Next
+	job.NextRun = &nextRun
+}
+
 // AddOneTimeJob adds a job that runs once at a specific time
 func (s *Scheduler) AddOneTimeJob(id, name string, runAt time.Time, fn func(context.Context) error) error {
 	s.mu.Lock()
@@ -178,6 +200,14 @@ func (s *Scheduler) AddOneTimeJob(id, name string, runAt time.Time, fn func(cont
 
 		select {
 		case <-timer.C:
+			// The job may have been removed while it was waiting
+			s.mu.RLock()
+			current, exists := s.jobs[id]
+			s.mu.RUnlock()
+			if !exists || current != job {
+				s.logger.WithField("job_id", id).Info("One-time job was removed before it ran")
+				return
+			}
 			s.logger.WithField("job_id", id).Info("Executing scheduled one-time job")
 			s.runJob(job)
 		case <-s.ctx.Done():
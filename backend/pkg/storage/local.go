@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/logger"
+)
+
+// LocalStore keeps blobs as files under a directory. It serves them itself
+// through ServeHTTP, to links signed with an HMAC of the key and expiry.
+type LocalStore struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocalStore creates a store in dir, creating it if needed. baseURL is
+// where the store's handler is mounted, with a trailing slash.
+func NewLocalStore(dir, baseURL, signingKey string) (*LocalStore, error) {
+	if dir == "" {
+		dir = "data/uploads"
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	key := []byte(signingKey)
+	if len(key) == 0 {
+		logger.Log.Warn("STORAGE_SIGNING_KEY is not set, file links will stop working on restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStore{root: dir, baseURL: baseURL, signingKey: key}, nil
+}
+
+func (s *LocalStore) filePath(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put writes the blob to a temporary file first so readers never see a partial file
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	name, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	segments := strings.Split(cleaned, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(cleaned, expires)},
+	}
+	return s.baseURL + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves a file to a signed link, it expects the request path to
+// be the key, so mount it behind http.StripPrefix
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key, err := cleanKey(r.URL.Path)
+	expires := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		http.Error(w, "Link expired", http.StatusForbidden)
+		return
+	}
+
+	name, _ := s.filePath(key)
+	file, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", max(expiresAt-time.Now().Unix(), 0)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
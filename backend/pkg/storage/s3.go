@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash is the SHA-256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Store keeps blobs in a bucket of S3 or an S3 compatible service. Requests
+// are signed with AWS Signature Version 4.
+type S3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	pathStyle       bool
+	client          *http.Client
+	now             func() time.Time
+}
+
+func NewS3Store(config utils.S3StorageConfig) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("s3 storage needs an endpoint, bucket and credentials")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint:        endpoint,
+		region:          region,
+		bucket:          config.Bucket,
+		accessKeyID:     config.AccessKeyID,
+		secretAccessKey: config.SecretAccessKey,
+		pathStyle:       config.PathStyle,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		now:             time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, unsignedPayload)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("upload", resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, domain.ErrResourceNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.responseError("download", resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	defer resp.Body.Close()
+	// S3 answers 204 whether or not the key existed
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError("delete", resp)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned GET URL, S3 caps ttl at seven days
+func (s *S3Store) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	expires := int64(ttl / time.Second)
+	if expires < 1 {
+		expires = 1
+	}
+	if expires > maxSignedURLTTLSeconds {
+		expires = maxSignedURLTTLSeconds
+	}
+
+	now := s.now().UTC()
+	scope := s.scope(now)
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.accessKeyID + "/" + scope},
+		"X-Amz-Date":          {now.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.FormatInt(expires, 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery,
+		"host:" + objectURL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	signature := s.signature(now, canonicalRequest)
+	objectURL.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return objectURL.String(), nil
+}
+
+// objectURL builds the URL of a key, with its path already escaped the way
+// Signature Version 4 expects
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	objectURL := *s.endpoint
+	objectPath := "/" + cleaned
+	if s.pathStyle {
+		objectPath = "/" + s.bucket + objectPath
+	} else {
+		objectURL.Host = s.bucket + "." + objectURL.Host
+	}
+	objectURL.Path = strings.TrimRight(s.endpoint.Path, "/") + objectPath
+	objectURL.RawPath = uriEncode(objectURL.Path, false)
+	return &objectURL, nil
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+}
+
+// sign adds the Authorization header, signing host and the x-amz headers
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + now.Format(amzDateFormat) + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3Store) signature(now time.Time, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3Store) responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("failed to %s blob: s3 returned %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQueryString sorts and escapes query parameters for signing
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes everything but the unreserved characters of RFC 3986, and
+// slashes unless encodeSlash is set
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+// Package storage implements domain.BlobStore on the local filesystem and on
+// S3 compatible object storage
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"yefe_app/v1/internal/domain"
+	"yefe_app/v1/pkg/utils"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+
+	// maxSignedURLTTLSeconds is the longest validity S3 accepts for a presigned URL
+	maxSignedURLTTLSeconds = 7 * 24 * 60 * 60
+)
+
+// New creates the store selected in the config. publicURL is the base URL of
+// this server, the local store serves its files under publicURL/files/.
+func New(config utils.StorageConfig, publicURL string) (domain.BlobStore, error) {
+	switch config.Backend {
+	case "", BackendLocal:
+		return NewLocalStore(config.Local.Dir, strings.TrimRight(publicURL, "/")+"/files/", config.Local.SigningKey)
+	case BackendS3:
+		return NewS3Store(config.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.Backend)
+	}
+}
+
+// cleanKey rejects keys that are empty or would escape the store's root
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,103 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// kidTimeLayout prefixes every kid so that kids sort by creation time
+	kidTimeLayout = "20060102T150405Z"
+	rsaKeyBits    = 2048
+)
+
+// GenerateKey writes a new private key for the algorithm to dir and returns its
+// kid. Being the newest key, it signs every token issued once it is
+// KeyActivationDelay old.
+func GenerateKey(dir, algorithm string, now time.Time) (string, error) {
+	var key crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", fmt.Errorf("unsupported algorithm %q, use %s or %s", algorithm, AlgRS256, AlgEdDSA)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create keys directory: %w", err)
+	}
+
+	kid := now.UTC().Format(kidTimeLayout) + "-" + strings.ToLower(algorithm)
+	file, err := os.OpenFile(filepath.Join(dir, kid+keyFileExt), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+	return kid, nil
+}
+
+// PruneKeys removes the keys created before olderThan and returns their kids.
+// The newest key is always kept so the set can still sign.
+func PruneKeys(dir string, olderThan time.Time) ([]string, error) {
+	keys, err := loadKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	newest := ""
+	for kid := range keys {
+		if kid > newest {
+			newest = kid
+		}
+	}
+
+	var removed []string
+	for kid := range keys {
+		if kid == newest {
+			continue
+		}
+		created, ok := KeyCreatedAt(kid)
+		if !ok || !created.Before(olderThan) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, kid+keyFileExt)); err != nil {
+			return removed, fmt.Errorf("failed to remove key %s: %w", kid, err)
+		}
+		removed = append(removed, kid)
+	}
+	return removed, nil
+}
+
+// KeyCreatedAt reads the creation time encoded in a kid made by GenerateKey
+func KeyCreatedAt(kid string) (time.Time, bool) {
+	prefix, _, _ := strings.Cut(kid, "-")
+	created, err := time.Parse(kidTimeLayout, prefix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
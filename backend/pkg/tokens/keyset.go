@@ -0,0 +1,316 @@
+// Package tokens signs and verifies the JWT access tokens issued by the API.
+//
+// Signing keys are PKCS#8 PEM files named "<kid>.pem" in a keys directory. A
+// kid starts with its creation time, so the lexically greatest kid is the
+// newest key. The newest key that is at least KeyActivationDelay old is the one
+// used for signing, a younger key is only published until every server and
+// every verifier caching the JWKS knows it. Every key in the directory stays
+// valid for verification, which lets a key be rotated without logging users
+// out: add a key, wait for the old tokens to expire, then remove the old key.
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"yefe_app/v1/internal/domain"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	keyFileExt = ".pem"
+
+	// ReloadInterval is how often servers re-read the keys directory
+	ReloadInterval = 5 * time.Minute
+	// JWKSMaxAge is how long verifiers may cache the JWKS document
+	JWKSMaxAge = 5 * time.Minute
+	// KeyActivationDelay is how long a new key is published before it signs.
+	// By then every server has loaded it and no verifier has a cached JWKS
+	// without it.
+	KeyActivationDelay = ReloadInterval + JWKSMaxAge
+)
+
+// SigningKey is one private key of the key set
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	// ActiveFrom is when the key starts signing, zero for keys whose kid does
+	// not carry a creation time
+	ActiveFrom time.Time
+	key        crypto.Signer
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// KeySet holds the signing keys loaded from a directory and, for tokens issued
+// before asymmetric keys were introduced, the legacy HMAC secret
+type KeySet struct {
+	dir          string
+	legacySecret []byte
+
+	mu   sync.RWMutex
+	keys map[string]*SigningKey
+}
+
+// NewKeySet loads the keys in dir. legacySecret is the hex encoded HS256 secret;
+// tokens signed with it keep verifying, and it signs new tokens only while dir
+// holds no key. Either may be empty, but not both.
+func NewKeySet(dir, legacySecret string) (*KeySet, error) {
+	ks := &KeySet{dir: dir, keys: map[string]*SigningKey{}}
+
+	if legacySecret != "" {
+		secret, err := hex.DecodeString(legacySecret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret encoding: %w", err)
+		}
+		ks.legacySecret = secret
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	if len(ks.keys) == 0 && ks.legacySecret == nil {
+		return nil, errors.New("no JWT signing key or secret configured")
+	}
+	return ks, nil
+}
+
+// Reload re-reads the keys directory, picking up rotated keys without a restart
+func (ks *KeySet) Reload() error {
+	if ks.dir == "" {
+		return nil
+	}
+
+	keys, err := loadKeys(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	return nil
+}
+
+// activeKey returns the newest key whose activation time has passed. When no
+// key is active yet, as right after the first key was added, the newest key
+// signs since verifiers have no older key to rely on.
+func (ks *KeySet) activeKey(now time.Time) *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var active, newest *SigningKey
+	for _, key := range ks.keys {
+		if newest == nil || key.ID > newest.ID {
+			newest = key
+		}
+		if !now.Before(key.ActiveFrom) && (active == nil || key.ID > active.ID) {
+			active = key
+		}
+	}
+	if active == nil {
+		return newest
+	}
+	return active
+}
+
+// ActiveKeyID returns the kid new tokens are signed with, or "" when the
+// legacy HMAC secret is in use
+func (ks *KeySet) ActiveKeyID() string {
+	active := ks.activeKey(time.Now())
+	if active == nil {
+		return ""
+	}
+	return active.ID
+}
+
+// Sign issues a token for the claims with the active key
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	active := ks.activeKey(time.Now())
+
+	if active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.legacySecret)
+	}
+
+	token := jwt.NewWithClaims(active.method(), claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.key)
+}
+
+// Parse verifies a token against the key named by its kid header, or against
+// the legacy secret for tokens without one, and returns its claims
+func (ks *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, ks.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ExtractSessionID verifies a token and returns its session_id claim
+func (ks *KeySet) ExtractSessionID(tokenString string) (string, error) {
+	claims, err := ks.Parse(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	sessionID, ok := claims["session_id"].(string)
+	if !ok || sessionID == "" {
+		return "", domain.ErrInvalidToken
+	}
+	return sessionID, nil
+}
+
+func (ks *KeySet) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || ks.legacySecret == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return ks.legacySecret, nil
+	}
+
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key.Public(), nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	// NotBefore is when the key starts signing, in seconds since the epoch. It
+	// is not a registered JWK parameter, verifiers ignore it.
+	NotBefore int64 `json:"nbf,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key, newest first, including keys that
+// do not sign yet. The legacy HMAC secret is never published.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		if !key.ActiveFrom.IsZero() {
+			jwk.NotBefore = key.ActiveFrom.Unix()
+		}
+		switch pub := key.key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID > set.Keys[j].KeyID
+	})
+	return set
+}
+
+// loadKeys parses every "<kid>.pem" file of dir
+func loadKeys(dir string) (map[string]*SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT keys directory: %w", err)
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), keyFileExt)
+		key, err := readKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT key %s: %w", kid, err)
+		}
+		key.ID = kid
+		if created, ok := KeyCreatedAt(kid); ok {
+			key.ActiveFrom = created.Add(KeyActivationDelay)
+		}
+		keys[kid] = key
+	}
+	return keys, nil
+}
+
+func readKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{Algorithm: AlgRS256, key: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{Algorithm: AlgEdDSA, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
@@ -0,0 +1,69 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestNewKeySignsOnlyOnceActive(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	oldKid, err := GenerateKey(dir, AlgEdDSA, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKid, err := GenerateKey(dir, AlgRS256, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys.ActiveKeyID(); got != oldKid {
+		t.Errorf("signing with %s, want the active key %s", got, oldKid)
+	}
+
+	// The new key is published with the time it starts signing
+	published := map[string]JWK{}
+	for _, key := range keys.JWKS().Keys {
+		published[key.KeyID] = key
+	}
+	if key, ok := published[newKid]; !ok {
+		t.Errorf("new key %s not published", newKid)
+	} else if want := now.Add(KeyActivationDelay).Unix(); key.NotBefore != want {
+		t.Errorf("new key published with nbf %d, want %d", key.NotBefore, want)
+	}
+
+	token, err := keys.Sign(jwt.MapClaims{"session_id": "session-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionID, err := keys.ExtractSessionID(token); err != nil || sessionID != "session-1" {
+		t.Errorf("ExtractSessionID() = %q, %v", sessionID, err)
+	}
+
+	if active := keys.activeKey(now.Add(KeyActivationDelay)); active == nil || active.ID != newKid {
+		t.Errorf("new key not signing after the activation delay")
+	}
+}
+
+func TestFirstKeySignsRightAway(t *testing.T) {
+	dir := t.TempDir()
+	kid, err := GenerateKey(dir, AlgEdDSA, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys.ActiveKeyID(); got != kid {
+		t.Errorf("signing with %q, want the only key %s", got, kid)
+	}
+}
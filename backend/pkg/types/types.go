@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type PaymentProvider string
@@ -37,14 +38,26 @@ const (
 	EventLoginFailed         SecurityEventType = "login_failed"
 	EventLogout              SecurityEventType = "logout"
 	EventLogoutFailed        SecurityEventType = "logout_failed"
+	EventTokenRefreshed      SecurityEventType = "token_refreshed"
+	EventRefreshTokenReuse   SecurityEventType = "refresh_token_reuse"
 	EventAccountCreated      SecurityEventType = "account_created"
 	EventAccountDeleted      SecurityEventType = "account_deleted"
+	EventDeletionScheduled   SecurityEventType = "account_deletion_scheduled"
+	EventDeletionCancelled   SecurityEventType = "account_deletion_cancelled"
 	EventProfileUpdated      SecurityEventType = "profile_updated"
+	EventEmailVerification   SecurityEventType = "email_verification_sent"
+	EventEmailVerified       SecurityEventType = "email_verified"
 	EventPasswordChange      SecurityEventType = "password_change"
 	EventPasswordReset       SecurityEventType = "password_reset"
 	EventAccountLocked       SecurityEventType = "account_locked"
 	EventAccountUnlocked     SecurityEventType = "account_unlocked"
 	EventSuspiciousActivity  SecurityEventType = "suspicious_activity"
+	EventMFAEnrolled         SecurityEventType = "mfa_enrolled"
+	EventMFADisabled         SecurityEventType = "mfa_disabled"
+	EventMFAVerified         SecurityEventType = "mfa_verified"
+	EventMFAFailed           SecurityEventType = "mfa_failed"
+	EventIdentityLinked      SecurityEventType = "identity_linked"
+	EventAccountClaimed      SecurityEventType = "account_claimed"
 	EventAuthFailed          SecurityEventType = "authentication_faield"
 	EventUnauthorizedAccess  SecurityEventType = "unauthorized_access"
 	EventPaymentFailed       SecurityEventType = "payment_failed"
@@ -105,12 +118,62 @@ func (r ReminderRequest) Validate() error {
 	return err
 }
 
+// QuietHours is a daily window on the 24 hour clock, in the user's timezone,
+// in which notifications are held back until it ends. It may run past
+// midnight, e.g. "22:00" to "07:00". It is off when both times are empty.
+type QuietHours struct {
+	Start string `json:"notification_quiet_hours_start"`
+	End   string `json:"notification_quiet_hours_end"`
+}
+
+func (q QuietHours) Enabled() bool {
+	return q.Start != "" && q.End != "" && q.Start != q.End
+}
+
+func (q QuietHours) Validate() error {
+	if q.Start == "" && q.End == "" {
+		return nil
+	}
+	for _, value := range []string{q.Start, q.End} {
+		if _, err := time.Parse("15:04", value); err != nil {
+			return fmt.Errorf("quiet hours must be two times such as 22:00, got %q", value)
+		}
+	}
+	return nil
+}
+
+// NotificationCaps limits how many notifications a user gets in a day, in
+// total and per category. Zero is no limit.
+type NotificationCaps struct {
+	Daily      int `json:"notification_caps_daily"`
+	Reminders  int `json:"notification_caps_reminders"`
+	Challenges int `json:"notification_caps_challenges"`
+	Campaigns  int `json:"notification_caps_campaigns"`
+}
+
 type NotificationsPref struct {
-	MorningPrompt     bool            `gorm:"default:true" json:"notification_morning_prompt"`
-	EveningReflection bool            `gorm:"default:true" json:"notification_evening_reflection"`
-	Challenge         bool            `gorm:"default:true" json:"notification_challange"`
-	Language          string          `gorm:"default:false" json:"notification_language"`
-	Reminders         ReminderRequest `gorm:"embedded;embeddedPrefix:reminders_" json:"notification_reminders"`
+	MorningPrompt     bool             `gorm:"default:true" json:"notification_morning_prompt"`
+	EveningReflection bool             `gorm:"default:true" json:"notification_evening_reflection"`
+	Challenge         bool             `gorm:"default:true" json:"notification_challange"`
+	Language          string           `gorm:"default:false" json:"notification_language"`
+	Reminders         ReminderRequest  `gorm:"embedded;embeddedPrefix:reminders_" json:"notification_reminders"`
+	QuietHours        QuietHours       `gorm:"embedded;embeddedPrefix:quiet_hours_" json:"notification_quiet_hours"`
+	Caps              NotificationCaps `gorm:"embedded;embeddedPrefix:caps_" json:"notification_caps"`
+}
+
+// DefaultNotificationsPref is used for accounts created without the
+// onboarding form, such as OAuth sign-ups. Reminders use the 12 hour clock.
+func DefaultNotificationsPref() NotificationsPref {
+	return NotificationsPref{
+		MorningPrompt:     true,
+		EveningReflection: true,
+		Challenge:         true,
+		Language:          "English",
+		Reminders: ReminderRequest{
+			MorningReminder: "07:00",
+			EveningReminder: "08:00",
+		},
+	}
 }
 
 type JSONMap map[string]any
@@ -1,5 +1,7 @@
 package utils
 
 const (
-	DAILY = "0 0 0 * * *"
+	DAILY              = "0 0 0 * * *"
+	EVERY_MINUTE       = "0 * * * * *"
+	EVERY_FIVE_MINUTES = "0 */5 * * * *"
 )
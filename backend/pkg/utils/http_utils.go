@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,8 +8,6 @@ import (
 	"strings"
 	"time"
 	"yefe_app/v1/internal/domain"
-
-	"github.com/golang-jwt/jwt"
 )
 
 // Response helpers
@@ -40,17 +37,92 @@ func GetClientIP(r *http.Request) string {
 	return strings.Split(r.RemoteAddr, ":")[0]
 }
 
+// DeviceLabelFromUserAgent builds a short human readable device description such
+// as "Chrome on Windows" from a User-Agent header
+func DeviceLabelFromUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	platform := "Unknown OS"
+	switch {
+	case strings.Contains(ua, "iphone"):
+		platform = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		platform = "iPad"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os") || strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	client := ""
+	switch {
+	case strings.Contains(ua, "dart/") || strings.Contains(ua, "okhttp") || strings.Contains(ua, "cfnetwork"):
+		client = "Yefe app"
+	case strings.Contains(ua, "edg/"):
+		client = "Edge"
+	case strings.Contains(ua, "firefox/"):
+		client = "Firefox"
+	case strings.Contains(ua, "chrome/"):
+		client = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		client = "Safari"
+	}
+
+	if client == "" {
+		return platform
+	}
+	return client + " on " + platform
+}
+
 func HandleDomainError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, domain.ErrUserNotFound):
 		ErrorResponse(w, http.StatusNotFound, "User not found", nil)
 
+	case errors.Is(err, domain.ErrUnknownProvider):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusNotFound, "Unknown identity provider", nil)
+
 	case errors.Is(err, domain.ErrInvalidPlanType),
 		errors.Is(err, domain.ErrInvalidUserStatus),
+		errors.Is(err, domain.ErrInvalidDateOfBirth),
+		errors.Is(err, domain.ErrInvalidTimezone),
+		errors.Is(err, domain.ErrInvalidQuietHours),
+		errors.Is(err, domain.ErrInvalidRequest),
+		errors.Is(err, domain.ErrInvalidCampaignData),
+		errors.Is(err, domain.ErrInvalidAchievementCriteria),
+		errors.Is(err, domain.ErrInvalidPuzzle),
 		errors.Is(err, domain.ErrInvalidPlanTransition):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
 
+	case errors.Is(err, domain.ErrResourceNotFound):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusNotFound, "Resource not found", nil)
+
+	case errors.Is(err, domain.ErrCampaignNotScheduled),
+		errors.Is(err, domain.ErrPointsAlreadyReversed),
+		errors.Is(err, domain.ErrGroupFull),
+		errors.Is(err, domain.ErrAlreadyGroupMember),
+		errors.Is(err, domain.ErrTooManyGroups):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusConflict, err.Error(), nil)
+
+	case errors.Is(err, domain.ErrUnsupportedMedia):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+
+	case errors.Is(err, domain.ErrFileTooLarge):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+
 	case errors.Is(err, domain.ErrUserAlreadyHasPlan):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusConflict, err.Error(), nil)
@@ -59,6 +131,10 @@ func HandleDomainError(w http.ResponseWriter, err error) {
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusConflict, "Plan update in progress", nil)
 
+	case errors.Is(err, domain.ErrGroupOwnerOnly):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusForbidden, err.Error(), nil)
+
 	case errors.Is(err, domain.ErrPremiumPlanRequired):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusForbidden, "Premium plan required", nil)
@@ -77,6 +153,10 @@ func HandleDomainError(w http.ResponseWriter, err error) {
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusForbidden, "Email not verified", nil)
 
+	case errors.Is(err, domain.ErrEmailVerified):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusConflict, "Email already verified", nil)
+
 	case errors.Is(err, domain.ErrWeakPassword):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusBadRequest, "Password too weak", nil)
@@ -86,10 +166,29 @@ func HandleDomainError(w http.ResponseWriter, err error) {
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusConflict, "Resource already exists", nil)
 
-	case errors.Is(err, domain.ErrInvalidToken):
+	case errors.Is(err, domain.ErrInvalidToken),
+		errors.Is(err, domain.ErrRefreshTokenReused):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusUnauthorized, "Invalid token", nil)
 
+	case errors.Is(err, domain.ErrInvalidMFACode):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusUnauthorized, "Invalid two-factor code", nil)
+
+	case errors.Is(err, domain.ErrMFARequired),
+		errors.Is(err, domain.ErrMFAMandatoryForRole):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusForbidden, err.Error(), nil)
+
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusConflict, "Two-factor authentication already enabled", nil)
+
+	case errors.Is(err, domain.ErrMFANotEnabled),
+		errors.Is(err, domain.ErrMFASetupNotStarted):
+		fmt.Println(err)
+		ErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
+
 	case errors.Is(err, domain.ErrRateLimitExceeded):
 		fmt.Println(err)
 		ErrorResponse(w, http.StatusTooManyRequests, "Too many requests", nil)
@@ -163,30 +262,3 @@ func InternalServerError(w http.ResponseWriter, err error) {
 	fmt.Printf("Internal server error: %v\n", err)
 	ErrorResponse(w, http.StatusInternalServerError, "Internal server error", nil)
 }
-
-// Helper function to extract session ID from JWT token
-func ExtractSessionIDFromToken(tokenString, jwtSecret string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		secret, err := hex.DecodeString(jwtSecret)
-		if err != nil {
-			return nil, fmt.Errorf("invalid JWT secret encoding: %w", err)
-		}
-		return secret, nil
-	})
-
-	if err != nil {
-		return "", err
-	}
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if sessionID, exists := claims["session_id"]; exists {
-			if sessionIDStr, ok := sessionID.(string); ok {
-				return sessionIDStr, nil
-			}
-		}
-	}
-
-	return "", domain.ErrInvalidToken
-}
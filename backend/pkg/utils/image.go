@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"yefe_app/v1/internal/domain"
+)
+
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+
+	jpegQuality = 85
+)
+
+// DecodeImage decodes a JPEG or PNG upload. Other formats and images with more
+// than maxPixels pixels fail with domain.ErrUnsupportedMedia. JPEGs are turned
+// upright using their EXIF orientation, since re-encoding drops all metadata.
+func DecodeImage(data []byte, maxPixels int) (*image.RGBA, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != ImageFormatJPEG && format != ImageFormatPNG) {
+		return nil, "", domain.ErrUnsupportedMedia
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, "", domain.ErrUnsupportedMedia
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", domain.ErrUnsupportedMedia
+	}
+	img := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	if format == ImageFormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// EncodeImage encodes img as a JPEG or PNG without any metadata
+func EncodeImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == ImageFormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return buf.Bytes(), err
+}
+
+// ResizeToFit scales img down so neither side exceeds maxSide, smaller images
+// are returned as is
+func ResizeToFit(img *image.RGBA, maxSide int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		return resizeArea(img, img.Bounds(), maxSide, max(h*maxSide/w, 1))
+	}
+	return resizeArea(img, img.Bounds(), max(w*maxSide/h, 1), maxSide)
+}
+
+// SquareThumbnail crops the center square of img and scales it down to size,
+// or less when the image is smaller
+func SquareThumbnail(img *image.RGBA, size int) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	return resizeArea(img, image.Rect(x0, y0, x0+side, y0+side), min(size, side), min(size, side))
+}
+
+// resizeArea scales the crop of src to w x h by averaging the source pixels
+// each destination pixel covers, which is good enough for downscaling
+func resizeArea(src *image.RGBA, crop image.Rectangle, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	cw, ch := crop.Dx(), crop.Dy()
+
+	for dy := 0; dy < h; dy++ {
+		sy0 := crop.Min.Y + dy*ch/h
+		sy1 := max(crop.Min.Y+(dy+1)*ch/h, sy0+1)
+		for dx := 0; dx < w; dx++ {
+			sx0 := crop.Min.X + dx*cw/w
+			sx1 := max(crop.Min.X+(dx+1)*cw/w, sx0+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+			j := dst.PixOffset(dx, dy)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag of a JPEG, 1 (upright) when
+// there is none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// applyOrientation flips and rotates img so an EXIF orientation becomes 1
+func applyOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-dx, dy
+			case 3:
+				sx, sy = w-1-dx, h-1-dy
+			case 4:
+				sx, sy = dx, h-1-dy
+			case 5:
+				sx, sy = dy, dx
+			case 6:
+				sx, sy = dy, h-1-dx
+			case 7:
+				sx, sy = w-1-dy, h-1-dx
+			case 8:
+				sx, sy = w-1-dy, dx
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], img.Pix[img.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"strings"
+	"time"
+	"yefe_app/v1/internal/domain"
+)
+
+// DefaultTimezone applies to users who have not set a timezone
+const DefaultTimezone = "UTC"
+
+// NormalizeTimezone checks that name is an IANA timezone such as
+// "Africa/Lagos". An empty name is DefaultTimezone.
+func NormalizeTimezone(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return DefaultTimezone, nil
+	}
+	// LoadLocation would also accept "Local", which means the server's zone
+	if name == "Local" {
+		return "", domain.ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", domain.ErrInvalidTimezone
+	}
+	return name, nil
+}
+
+// LoadTimezone returns the location of a user's timezone, falling back to UTC
+// when it is empty or unknown
+func LoadTimezone(name string) *time.Location {
+	if name == "" || name == "Local" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
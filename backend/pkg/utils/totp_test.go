@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of RFC 6238 appendix B, "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes, 6 digit codes are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		got, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+		if step, ok := ValidateTOTPCode(rfc6238Secret, tt.want, at, 0); !ok || step != TOTPStep(at) {
+			t.Errorf("code at %d validated %v at step %d, want step %d", tt.unix, ok, step, TOTPStep(at))
+		}
+	}
+}
+
+func TestGenerateTOTPCodeAcceptsLowerCaseAndPadding(t *testing.T) {
+	want, err := GenerateTOTPCode(rfc6238Secret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"gezdgnbvgy3tqojqgezdgnbvgy3tqojq", rfc6238Secret + "===="} {
+		if got, err := GenerateTOTPCode(secret, 1); err != nil || got != want {
+			t.Errorf("GenerateTOTPCode(%q) = %s, %v, want %s", secret, got, err, want)
+		}
+	}
+	if _, err := GenerateTOTPCode("not base32!", 1); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateTOTPCodeSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		valid  bool
+	}{
+		{name: "two steps behind", offset: -2},
+		{name: "one step behind", offset: -1, valid: true},
+		{name: "current step", offset: 0, valid: true},
+		{name: "one step ahead", offset: 1, valid: true},
+		{name: "two steps ahead", offset: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := GenerateTOTPCode(rfc6238Secret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0)
+			if ok != tt.valid {
+				t.Fatalf("ValidateTOTPCode() = %v, want %v", ok, tt.valid)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("matched step %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPCodeRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+	code, err := GenerateTOTPCode(rfc6238Secret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("fresh code rejected")
+	}
+	// The step the code was used at is stored as lastUsedStep
+	if _, ok := ValidateTOTPCode(rfc6238Secret, code, now, step); ok {
+		t.Error("code accepted again at the step it was used")
+	}
+	if _, ok := ValidateTOTPCode(rfc6238Secret, code, now.Add(TOTPPeriod), step); ok {
+		t.Error("code accepted again within the skew of the next step")
+	}
+
+	// An older code still within the skew is not accepted after a newer one
+	older, err := GenerateTOTPCode(rfc6238Secret, current-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ValidateTOTPCode(rfc6238Secret, older, now, step); ok {
+		t.Error("older code accepted after a newer one was used")
+	}
+
+	// The next code is accepted
+	next, err := GenerateTOTPCode(rfc6238Secret, current+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ValidateTOTPCode(rfc6238Secret, next, now.Add(TOTPPeriod), step); !ok || got != current+1 {
+		t.Errorf("next code validated %v at step %d, want step %d", ok, got, current+1)
+	}
+}
+
+func TestValidateTOTPCodeFormat(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{" 287082 ", "287082\n"} {
+		if _, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0); !ok {
+			t.Errorf("code %q rejected", code)
+		}
+	}
+	for _, code := range []string{"", "28708", "2870820", "94287082", "287083"} {
+		if _, ok := ValidateTOTPCode(rfc6238Secret, code, now, 0); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"abcde-fghij", "abcde-fghij"},
+		{"ABCDE-FGHIJ", "abcde-fghij"},
+		{"abcdefghij", "abcde-fghij"},
+		{" AbCdEfGhIj ", "abcde-fghij"},
+		{"ab-cde-fgh-ij", "abcde-fghij"},
+		// Codes of the wrong length are left without a dash and never match
+		{"abcde-fghi", "abcdefghi"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes := GenerateRecoveryCodes(10)
+	if len(codes) != 10 {
+		t.Fatalf("generated %d codes, want 10", len(codes))
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || NormalizeRecoveryCode(code) != code {
+			t.Errorf("code %q is not in the xxxxx-xxxxx form", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base32"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -40,6 +42,53 @@ type (
 		EmailConfig    EmailConfig         `yaml:"email_config"`
 		StripeConfig   PaymentConfig       `yaml:"payment_config"`
 		FirebaseConfig FirebaseConfig      `yaml:"firebase_config"`
+		OAuth          OAuthConfig         `yaml:"oauth"`
+		Storage        StorageConfig       `yaml:"storage"`
+		Push           PushConfig          `yaml:"push"`
+	}
+	// PushConfig selects how push notifications are sent
+	PushConfig struct {
+		// Sender is "firebase", "log" to only log notifications, or "memory" to
+		// record them for tests. firebase is used when empty.
+		Sender string `yaml:"sender"`
+	}
+	// StorageConfig selects the blob store for uploaded files, see pkg/storage
+	StorageConfig struct {
+		// Backend is "local" or "s3", local is used when empty
+		Backend string `yaml:"backend"`
+		// SignedURLTTL is how long links handed to clients keep working
+		SignedURLTTL time.Duration      `yaml:"signed_url_ttl"`
+		Local        LocalStorageConfig `yaml:"local"`
+		S3           S3StorageConfig    `yaml:"s3"`
+	}
+	LocalStorageConfig struct {
+		Dir string `yaml:"dir"`
+		// SigningKey signs the links to files, a random key is used when empty
+		// which invalidates links on restart
+		SigningKey string `yaml:"signing_key"`
+	}
+	S3StorageConfig struct {
+		// Endpoint is the base URL, such as https://s3.eu-west-1.amazonaws.com
+		// or the URL of an S3 compatible service
+		Endpoint        string `yaml:"endpoint"`
+		Region          string `yaml:"region"`
+		Bucket          string `yaml:"bucket"`
+		AccessKeyID     string `yaml:"access_key_id"`
+		SecretAccessKey string `yaml:"secret_access_key"`
+		// PathStyle puts the bucket in the path instead of the host name, as
+		// most self hosted services expect
+		PathStyle bool `yaml:"path_style"`
+	}
+	// OAuthConfig lists the OpenID Connect providers users can sign in with,
+	// keyed by the name used in /v1/auth/oauth/{provider}
+	OAuthConfig struct {
+		Providers map[string]OIDCProviderConfig `yaml:"providers"`
+	}
+	OIDCProviderConfig struct {
+		Issuer string `yaml:"issuer"`
+		// ClientIDs is a comma separated list of accepted audiences, the
+		// provider is disabled while it is empty
+		ClientIDs string `yaml:"client_ids"`
 	}
 	FirebaseConfig struct {
 		Type                    string `yaml:"type" json:"type"`
@@ -64,6 +113,13 @@ type (
 		ProdURL      string `yaml:"prod_url,omitempty"`
 		Env          string `yaml:"environment"`
 		AllowedHosts string `yaml:"allowed_hosts"`
+		// RequireVerifiedEmailForPaid blocks accounts with an unverified email from paid features
+		RequireVerifiedEmailForPaid bool `yaml:"require_verified_email_for_paid"`
+		// JWTKeysDir holds the asymmetric JWT signing keys, see pkg/tokens
+		JWTKeysDir string `yaml:"jwt_keys_dir"`
+		// AccountDeletionGracePeriod is how long a deleted account can still be
+		// restored by logging in before its data is purged
+		AccountDeletionGracePeriod time.Duration `yaml:"account_deletion_grace_period"`
 	}
 	PersistenceSettings struct {
 		PostgresSQl DBSettings `yaml:"postgres"`
@@ -166,6 +222,21 @@ func GenerateSecureToken() string {
 	return base32.StdEncoding.EncodeToString(bytes)
 }
 
+// HashToken returns the hex encoded SHA-256 digest of a one-time token so that
+// only the digest has to be persisted
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// PublicURL returns the base URL used in links sent to users
+func (s ServerSettings) PublicURL() string {
+	if s.Env == types.PROD {
+		return s.ProdURL
+	}
+	return s.DevURl
+}
+
 // generateID generates a new UUID
 func GenerateID() string {
 	return uuid.NewString()